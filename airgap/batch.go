@@ -0,0 +1,349 @@
+// Package airgap moves issuance through batch files for a signer that runs on an offline machine.
+//
+// Issuance takes two round trips over the air gap, since blind messages can only be created from parameters
+// of the signer. The online side writes a params request for a number of items, the offline Signer runs
+// GetParams for all of them, keeps the server half in a ParamStore and answers with the client half and its
+// ParamID. The online side blinds the messages of its clients and writes a sign request of ParamID,
+// BlindingParamClient and BlindMessage per item, the Signer signs all items and answers with the blind
+// signatures. Match checks a response against its request and returns the results per item.
+//
+// Batch files are ASN.1 DER and authenticated with an HMAC-SHA256 under a key shared by both sides. Every
+// batch has a random BatchID and every item a random RequestID that the response repeats. Responses carry the
+// hash of the request they answer and one item per request item, in order, so responses to other or replayed
+// requests and responses that lack items are rejected by Match. The Signer records the BatchID of every request
+// it processes in a SpentStore and refuses to process a request twice or after MaxAge.
+//
+// Items that fail on the Signer are answered with an error message instead of data. The rest of the batch is
+// processed.
+package airgap
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"errors"
+	"time"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+)
+
+// Version is the version of the batch format written by Marshal
+const Version = 1
+
+// IDSize is the size of BatchIDs and RequestIDs
+const IDSize = 16
+
+// Kind is the kind of a batch
+type Kind int
+
+// Kinds of batches
+const (
+	KindParamsRequest  Kind = 1 // Asks for one set of parameters per item
+	KindParamsResponse Kind = 2 // Carries ParamID and BlindingParamClient per item
+	KindSignRequest    Kind = 3 // Carries ParamID, BlindingParamClient and BlindMessage per item
+	KindSignResponse   Kind = 4 // Carries BlindSignature per item
+)
+
+var (
+	// ErrBadMAC is returned if a batch file is not authenticated by the key
+	ErrBadMAC = errors.New("airgap: Batch MAC does not verify")
+	// ErrBatchVersion is returned for batch files of unknown version
+	ErrBatchVersion = errors.New("airgap: Unsupported batch version")
+	// ErrBatchKind is returned if a batch is not of the expected kind
+	ErrBatchKind = errors.New("airgap: Unexpected batch kind")
+	// ErrBatchSigner is returned if a batch belongs to another signer
+	ErrBatchSigner = errors.New("airgap: Batch belongs to another signer")
+	// ErrBatchReplayed is returned by the Signer for requests it has processed before
+	ErrBatchReplayed = errors.New("airgap: Batch already processed")
+	// ErrBatchExpired is returned by the Signer for requests older than MaxAge
+	ErrBatchExpired = errors.New("airgap: Batch expired")
+	// ErrBadItem is returned for batches with malformed or duplicate items
+	ErrBadItem = errors.New("airgap: Invalid batch item")
+	// ErrBatchMismatch is returned by Match if a response does not answer the request
+	ErrBatchMismatch = errors.New("airgap: Response does not belong to request")
+	// ErrPartialBatch is returned by Match if a response does not answer every item of the request
+	ErrPartialBatch = errors.New("airgap: Response does not answer every request item")
+)
+
+// Item is an entry of a batch. Fields not used by the kind of the batch are empty
+type Item struct {
+	RequestID []byte // Chosen by the online side, repeated in the response
+	ParamID   []byte // ID of the parameters in the ParamStore of the Signer
+	Params    []byte // Marshalled BlindingParamClient
+	Message   []byte // Marshalled BlindMessage
+	Signature []byte // Marshalled BlindSignature
+	Error     string `asn1:"utf8"` // Reason the item failed on the Signer, empty on success
+}
+
+// Batch is the content of a batch file
+type Batch struct {
+	Version     int
+	Kind        Kind
+	BatchID     []byte    // Random for requests, the BatchID of the request for responses
+	Scheme      string    // Scheme of the signer
+	KeyID       []byte    // eccutil.KeyID of the signer
+	Created     time.Time `asn1:"generalized"`
+	RequestHash []byte    // SHA-256 of the request file a response answers, empty for requests
+	Items       []Item
+}
+
+// file is the outer encoding of a batch file
+type file struct {
+	Batch asn1.RawValue
+	MAC   []byte
+}
+
+// newID returns a random ID
+func newID() ([]byte, error) {
+	id := make([]byte, IDSize)
+	_, err := rand.Read(id)
+	if err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// newBatch returns an empty batch of kind for the signer pubKey of scheme
+func newBatch(kind Kind, scheme string, pubKey *eccutil.Point, now time.Time) *Batch {
+	b := new(Batch)
+	b.Version = Version
+	b.Kind = kind
+	b.Scheme = scheme
+	b.KeyID = eccutil.KeyID(pubKey)
+	b.Created = time.Unix(now.Unix(), 0).UTC()
+	b.RequestHash = []byte{}
+	return b
+}
+
+// NewParamsRequest returns a params request for n items to the signer pubKey of scheme
+func NewParamsRequest(scheme string, pubKey *eccutil.Point, n int) (*Batch, error) {
+	if n < 0 {
+		return nil, genericblinding.ErrBatchLength
+	}
+	b := newBatch(KindParamsRequest, scheme, pubKey, time.Now())
+	var err error
+	b.BatchID, err = newID()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < n; i++ {
+		id, err := newID()
+		if err != nil {
+			return nil, err
+		}
+		b.Items = append(b.Items, Item{RequestID: id})
+	}
+	return b, nil
+}
+
+// NewSignRequest returns an empty sign request to the signer pubKey of scheme. Add items with AddSign
+func NewSignRequest(scheme string, pubKey *eccutil.Point) (*Batch, error) {
+	b := newBatch(KindSignRequest, scheme, pubKey, time.Now())
+	var err error
+	b.BatchID, err = newID()
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// AddSign adds an item to a sign request that asks to sign bm with the parameters paramID, which were returned
+// together with bpc. It returns the RequestID of the item
+func (b *Batch) AddSign(paramID []byte, bpc genericblinding.BlindingParamClient, bm genericblinding.BlindMessage) ([]byte, error) {
+	if b.Kind != KindSignRequest {
+		return nil, ErrBatchKind
+	}
+	params, err := bpc.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	message, err := bm.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	b.Items = append(b.Items, Item{RequestID: id, ParamID: paramID, Params: params, Message: message})
+	return id, nil
+}
+
+// IsRequest returns true for params and sign requests
+func (b *Batch) IsRequest() bool {
+	return b.Kind == KindParamsRequest || b.Kind == KindSignRequest
+}
+
+// checkSigner verifies that b belongs to the signer pubKey of scheme
+func (b *Batch) checkSigner(scheme string, pubKey *eccutil.Point) error {
+	if b.Scheme != scheme || !bytes.Equal(b.KeyID, eccutil.KeyID(pubKey)) {
+		return ErrBatchSigner
+	}
+	return nil
+}
+
+// checkItems verifies that b has a BatchID and that its RequestIDs are well formed and unique
+func (b *Batch) checkItems() error {
+	if len(b.BatchID) != IDSize {
+		return ErrBadItem
+	}
+	seen := make(map[string]bool, len(b.Items))
+	for _, item := range b.Items {
+		if len(item.RequestID) != IDSize || seen[string(item.RequestID)] {
+			return ErrBadItem
+		}
+		seen[string(item.RequestID)] = true
+	}
+	return nil
+}
+
+// Marshal returns the batch file of b authenticated with key
+func (b *Batch) Marshal(key []byte) ([]byte, error) {
+	for i := range b.Items {
+		fillItem(&b.Items[i])
+	}
+	body, err := asn1.Marshal(*b)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(file{Batch: asn1.RawValue{FullBytes: body}, MAC: computeMAC(key, body)})
+}
+
+// fillItem replaces nil fields of item by empty ones, which encode the same and compare equal after Parse
+func fillItem(item *Item) {
+	for _, f := range []*[]byte{&item.RequestID, &item.ParamID, &item.Params, &item.Message, &item.Signature} {
+		if *f == nil {
+			*f = []byte{}
+		}
+	}
+}
+
+// computeMAC returns the HMAC-SHA256 of body under key
+func computeMAC(key, body []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+// Parse decodes the batch file data and verifies that it is authenticated with key and well formed
+func Parse(data, key []byte) (*Batch, error) {
+	f := new(file)
+	rest, err := asn1.Unmarshal(data, f)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, asn1.SyntaxError{Msg: "trailing data"}
+	}
+	if !hmac.Equal(f.MAC, computeMAC(key, f.Batch.FullBytes)) {
+		return nil, ErrBadMAC
+	}
+	b := new(Batch)
+	rest, err = asn1.Unmarshal(f.Batch.FullBytes, b)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, asn1.SyntaxError{Msg: "trailing data"}
+	}
+	if b.Version != Version {
+		return nil, ErrBatchVersion
+	}
+	if b.Kind < KindParamsRequest || b.Kind > KindSignResponse {
+		return nil, ErrBatchKind
+	}
+	if err := b.checkItems(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Result is the outcome of an item of a request
+type Result struct {
+	RequestID []byte
+	ParamID   []byte                              // Params responses only
+	Params    genericblinding.BlindingParamClient // Params responses only
+	Signature genericblinding.BlindSignature      // Sign responses only
+	Err       error                               // Reason the item failed, nil on success
+}
+
+// Match checks that the response file answers the request file, both authenticated with key, of the signer
+// pubKey of scheme. It returns one Result per request item, in the order of the request. If items failed,
+// the results of the others are returned with a genericblinding.BatchError for the failed ones.
+// The online side should discard the request once it has been matched, so that a response cannot be used twice
+func Match(key []byte, scheme string, pubKey *eccutil.Point, request, response []byte) ([]Result, error) {
+	req, err := Parse(request, key)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := Parse(response, key)
+	if err != nil {
+		return nil, err
+	}
+	if !req.IsRequest() || resp.Kind != req.Kind+1 {
+		return nil, ErrBatchKind
+	}
+	if err := req.checkSigner(scheme, pubKey); err != nil {
+		return nil, err
+	}
+	if err := resp.checkSigner(scheme, pubKey); err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(request)
+	if !bytes.Equal(resp.BatchID, req.BatchID) || !bytes.Equal(resp.RequestHash, hash[:]) {
+		return nil, ErrBatchMismatch
+	}
+	if len(resp.Items) != len(req.Items) {
+		return nil, ErrPartialBatch
+	}
+	results := make([]Result, len(req.Items))
+	errs := make(genericblinding.BatchError, len(req.Items))
+	for i, item := range resp.Items {
+		if !bytes.Equal(item.RequestID, req.Items[i].RequestID) {
+			return nil, ErrPartialBatch
+		}
+		results[i].RequestID = item.RequestID
+		errs[i] = results[i].decode(item, resp.Kind, scheme, pubKey)
+		results[i].Err = errs[i]
+	}
+	return results, errs.Err()
+}
+
+// decode fills r from the response item of kind
+func (r *Result) decode(item Item, kind Kind, scheme string, pubKey *eccutil.Point) error {
+	if item.Error != "" {
+		return errors.New(item.Error)
+	}
+	if kind == KindParamsResponse {
+		if len(item.ParamID) != genericblinding.ParamIDSize {
+			return ErrBadItem
+		}
+		bd, err := decode(item.Params, scheme, pubKey, genericblinding.TypeBlindingParamClient)
+		if err != nil {
+			return err
+		}
+		r.ParamID, r.Params = item.ParamID, bd
+		return nil
+	}
+	bd, err := decode(item.Signature, scheme, pubKey, genericblinding.TypeBlindSignature)
+	if err != nil {
+		return err
+	}
+	r.Signature = bd
+	return nil
+}
+
+// decode unmarshals BlindingData of dataType for the signer pubKey of scheme
+func decode(b []byte, scheme string, pubKey *eccutil.Point, dataType genericblinding.DataType) (genericblinding.BlindingData, error) {
+	bd, err := genericblinding.Decode(b, pubKey)
+	if err != nil {
+		return nil, err
+	}
+	if s, t, _ := bd.SchemeData(); s != scheme || t != dataType {
+		return nil, genericblinding.ErrBadType
+	}
+	return bd, nil
+}
@@ -0,0 +1,175 @@
+package airgap
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+	"github.com/ronperry/cryptoedge/jjm"
+)
+
+var testKey = []byte("airgap key")
+
+// newTestSigner returns a Signer for a new jjm signer and a client for it
+func newTestSigner(t *testing.T) (*Signer, *jjm.GenericBlindingClient) {
+	c := eccutil.SetCurve(elliptic.P256, rand.Reader, eccutil.Sha1Hash)
+	privkey, pubkey, err := c.GenerateKey()
+	if err != nil {
+		t.Fatalf("Error creating keys: %s", err)
+	}
+	server := jjm.NewGenericBlindingServer(privkey, pubkey, c)
+	s := NewSigner(server, jjm.SchemeName, pubkey, testKey, genericblinding.NewMemoryParamStore(time.Hour), genericblinding.NewMemorySpentStore())
+	return s, jjm.NewGenericBlindingClient(pubkey, c)
+}
+
+// getParams runs a params request for n items through s
+func getParams(t *testing.T, s *Signer, n int) []Result {
+	req, err := NewParamsRequest(jjm.SchemeName, s.pubKey, n)
+	if err != nil {
+		t.Fatalf("NewParamsRequest failed: %s", err)
+	}
+	request, err := req.Marshal(testKey)
+	if err != nil {
+		t.Fatalf("Marshal failed: %s", err)
+	}
+	response, err := s.Process(request)
+	if err != nil {
+		t.Fatalf("Process failed: %s", err)
+	}
+	results, err := Match(testKey, jjm.SchemeName, s.pubKey, request, response)
+	if err != nil {
+		t.Fatalf("Match failed: %s", err)
+	}
+	if len(results) != n {
+		t.Fatalf("Wrong number of results: %d", len(results))
+	}
+	return results
+}
+
+func Test_Issuance(t *testing.T) {
+	s, client := newTestSigner(t)
+	params := getParams(t, s, 3)
+	req, err := NewSignRequest(jjm.SchemeName, s.pubKey)
+	if err != nil {
+		t.Fatalf("NewSignRequest failed: %s", err)
+	}
+	cms := make([]genericblinding.ClearMessage, len(params))
+	bfs := make([]genericblinding.BlindingFactors, len(params))
+	ids := make([][]byte, len(params))
+	for i, p := range params {
+		cms[i] = jjm.NewClearMessage([]byte{'m', byte(i)})
+		var bm genericblinding.BlindMessage
+		bfs[i], bm, err = client.Blind(p.Params, cms[i])
+		if err != nil {
+			t.Fatalf("Blind failed: %s", err)
+		}
+		ids[i], err = req.AddSign(p.ParamID, p.Params, bm)
+		if err != nil {
+			t.Fatalf("AddSign failed: %s", err)
+		}
+	}
+	request, err := req.Marshal(testKey)
+	if err != nil {
+		t.Fatalf("Marshal failed: %s", err)
+	}
+	response, err := s.Process(request)
+	if err != nil {
+		t.Fatalf("Process failed: %s", err)
+	}
+	results, err := Match(testKey, jjm.SchemeName, s.pubKey, request, response)
+	if err != nil {
+		t.Fatalf("Match failed: %s", err)
+	}
+	for i, r := range results {
+		if !bytes.Equal(r.RequestID, ids[i]) {
+			t.Errorf("Item %d: wrong RequestID", i)
+		}
+		cs, cm, err := client.Unblind(bfs[i], cms[i], r.Signature)
+		if err != nil {
+			t.Fatalf("Unblind failed: %s", err)
+		}
+		if ok, err := client.Verify(cs, cm); !ok || err != nil {
+			t.Errorf("Item %d: signature does not verify: %v", i, err)
+		}
+	}
+
+	// Replayed request
+	if _, err := s.Process(request); err != ErrBatchReplayed {
+		t.Errorf("Replayed request: %v", err)
+	}
+	// Parameters used twice in a new request
+	req.BatchID, _ = newID()
+	request, _ = req.Marshal(testKey)
+	response, err = s.Process(request)
+	if err != nil {
+		t.Fatalf("Process failed: %s", err)
+	}
+	_, err = Match(testKey, jjm.SchemeName, s.pubKey, request, response)
+	var be genericblinding.BatchError
+	if !errors.As(err, &be) || len(be) != 3 || be[0] == nil {
+		t.Errorf("Reused parameters: %v", err)
+	}
+}
+
+func Test_Integrity(t *testing.T) {
+	s, _ := newTestSigner(t)
+	req, _ := NewParamsRequest(jjm.SchemeName, s.pubKey, 2)
+	request, _ := req.Marshal(testKey)
+	if _, err := Parse(request, []byte("other key")); err != ErrBadMAC {
+		t.Errorf("Wrong key: %v", err)
+	}
+	tampered := append([]byte(nil), request...)
+	tampered[len(tampered)/2] ^= 1
+	if _, err := s.Process(tampered); err == nil {
+		t.Error("Tampered request accepted")
+	}
+	if _, err := s.Process(request[:len(request)-1]); err == nil {
+		t.Error("Truncated request accepted")
+	}
+	_, otherKey, err := eccutil.SetCurve(elliptic.P256, rand.Reader, eccutil.Sha1Hash).GenerateKey()
+	if err != nil {
+		t.Fatalf("Error creating keys: %s", err)
+	}
+	other, _ := NewParamsRequest(jjm.SchemeName, otherKey, 1)
+	b, _ := other.Marshal(testKey)
+	if _, err := s.Process(b); err != ErrBatchSigner {
+		t.Errorf("Foreign signer: %v", err)
+	}
+	s.Clock = func() time.Time { return time.Now().Add(DefaultMaxAge + time.Minute) }
+	if _, err := s.Process(request); err != ErrBatchExpired {
+		t.Errorf("Expired request: %v", err)
+	}
+	s.Clock = nil
+
+	response, err := s.Process(request)
+	if err != nil {
+		t.Fatalf("Process failed: %s", err)
+	}
+	// Response to another request
+	req2, _ := NewParamsRequest(jjm.SchemeName, s.pubKey, 2)
+	request2, _ := req2.Marshal(testKey)
+	if _, err := Match(testKey, jjm.SchemeName, s.pubKey, request2, response); err != ErrBatchMismatch {
+		t.Errorf("Foreign response: %v", err)
+	}
+	// Partial response, authenticated
+	resp, err := Parse(response, testKey)
+	if err != nil {
+		t.Fatalf("Parse failed: %s", err)
+	}
+	resp.Items = resp.Items[:1]
+	partial, _ := resp.Marshal(testKey)
+	if _, err := Match(testKey, jjm.SchemeName, s.pubKey, request, partial); err != ErrPartialBatch {
+		t.Errorf("Partial response: %v", err)
+	}
+	// Duplicate RequestIDs
+	req.Items[1].RequestID = req.Items[0].RequestID
+	dup, _ := req.Marshal(testKey)
+	if _, err := Parse(dup, testKey); err != ErrBadItem {
+		t.Errorf("Duplicate RequestID: %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package airgap
+
+import (
+	"crypto/sha256"
+	"errors"
+	"time"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+)
+
+// DefaultMaxAge is the default age after which the Signer refuses requests
+const DefaultMaxAge = 7 * 24 * time.Hour
+
+// Signer processes request files on the offline machine
+type Signer struct {
+	server genericblinding.BlindingServer
+	scheme string
+	pubKey *eccutil.Point
+	key    []byte
+	params genericblinding.ParamStore
+	spent  genericblinding.SpentStore
+	MaxAge time.Duration    // Maximum age of requests, DefaultMaxAge if 0
+	Clock  func() time.Time // Time source, time.Now if nil
+}
+
+// NewSigner returns a Signer that answers requests authenticated with key using server, the signer pubKey of
+// scheme. The server half of parameters is kept in params between the params and the sign request, which must
+// therefore persist across runs, see genericblinding.FileParamStore. The BatchIDs of processed requests are
+// recorded in spent
+func NewSigner(server genericblinding.BlindingServer, scheme string, pubKey *eccutil.Point, key []byte, params genericblinding.ParamStore, spent genericblinding.SpentStore) *Signer {
+	s := new(Signer)
+	s.server = server
+	s.scheme = scheme
+	s.pubKey = pubKey
+	s.key = key
+	s.params = params
+	s.spent = spent
+	return s
+}
+
+func (s *Signer) now() time.Time {
+	if s.Clock != nil {
+		return s.Clock()
+	}
+	return time.Now()
+}
+
+func (s *Signer) maxAge() time.Duration {
+	if s.MaxAge == 0 {
+		return DefaultMaxAge
+	}
+	return s.MaxAge
+}
+
+// Process answers the request file and returns the response file. Requests that are not authenticated, belong
+// to another signer, have expired or have been processed before are refused as a whole. Failed items are
+// answered with their error
+func (s *Signer) Process(request []byte) ([]byte, error) {
+	req, err := Parse(request, s.key)
+	if err != nil {
+		return nil, err
+	}
+	if !req.IsRequest() {
+		return nil, ErrBatchKind
+	}
+	if err := req.checkSigner(s.scheme, s.pubKey); err != nil {
+		return nil, err
+	}
+	now := s.now()
+	if now.After(req.Created.Add(s.maxAge())) {
+		return nil, ErrBatchExpired
+	}
+	if err := s.spent.Spend(req.BatchID); errors.Is(err, genericblinding.ErrSpent) {
+		return nil, ErrBatchReplayed
+	} else if err != nil {
+		return nil, err
+	}
+	resp := newBatch(req.Kind+1, s.scheme, s.pubKey, now)
+	resp.BatchID = req.BatchID
+	hash := sha256.Sum256(request)
+	resp.RequestHash = hash[:]
+	resp.Items = make([]Item, len(req.Items))
+	for i, item := range req.Items {
+		resp.Items[i].RequestID = item.RequestID
+		var err error
+		if req.Kind == KindParamsRequest {
+			err = s.getParams(&resp.Items[i])
+		} else {
+			err = s.sign(item, &resp.Items[i])
+		}
+		if err != nil {
+			resp.Items[i] = Item{RequestID: item.RequestID, Error: err.Error()}
+		}
+	}
+	return resp.Marshal(s.key)
+}
+
+// getParams fills out with new parameters
+func (s *Signer) getParams(out *Item) error {
+	bpc, bps, err := s.server.GetParams()
+	if err != nil {
+		return err
+	}
+	params, err := bpc.Marshal()
+	if err != nil {
+		return err
+	}
+	id, err := s.params.Put(bps)
+	if err != nil {
+		return err
+	}
+	out.ParamID, out.Params = id, params
+	return nil
+}
+
+// sign fills out with the signature of the sign request item
+func (s *Signer) sign(item Item, out *Item) error {
+	if _, err := decode(item.Params, s.scheme, s.pubKey, genericblinding.TypeBlindingParamClient); err != nil {
+		return err
+	}
+	bm, err := decode(item.Message, s.scheme, s.pubKey, genericblinding.TypeBlindMessage)
+	if err != nil {
+		return err
+	}
+	bps, err := s.params.Take(item.ParamID)
+	if err != nil {
+		return err
+	}
+	bs, err := s.server.Sign(bps, bm)
+	if err != nil {
+		return err
+	}
+	out.Signature, err = bs.Marshal()
+	return err
+}
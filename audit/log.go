@@ -0,0 +1,224 @@
+// Package audit keeps a tamper-evident log of the operations of a blind signer. Server wraps a BlindingServer
+// and appends a Record for every GetParams and Sign to a Log.
+//
+// The log is a file of JSON records, one per line. Each record carries the hash of its predecessor and its own
+// hash over all its fields, so that changing, inserting or removing a record breaks the chain. With a key the
+// hashes are HMACs, which cannot be recomputed without the key. Removing records from the end of the log
+// cannot be detected from the log alone, keep the Head of the log elsewhere and pass it to Verify.
+//
+// Records contain the time, scheme, key ID, the UniqueID of the parameters and the outcome of an operation.
+// They never contain blinding factors, clear messages or blind messages, nor the identity of the caller.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Operations of records
+const (
+	OpGetParams = "getParams"
+	OpSign      = "sign"
+)
+
+// OutcomeOK is the outcome of successful operations. Failed operations record the error message
+const OutcomeOK = "ok"
+
+var (
+	// ErrModified is returned if a record does not match its hash or is out of sequence
+	ErrModified = errors.New("audit: Log has been modified")
+	// ErrTruncated is returned if a log ends before the expected head or with a partial record
+	ErrTruncated = errors.New("audit: Log has been truncated")
+)
+
+// Record is an entry of the log
+type Record struct {
+	Seq     uint64    `json:"seq"`            // Position in the log, starting at 1
+	Time    time.Time `json:"time"`           // Time of the operation
+	Op      string    `json:"op"`             // OpGetParams or OpSign
+	Scheme  string    `json:"scheme"`         // Scheme of the signer
+	KeyID   string    `json:"keyId"`          // Hex encoded eccutil.KeyID of the signer
+	ParamID string    `json:"paramId"`        // Hex encoded UniqueID of the BlindingParamServer
+	Outcome string    `json:"outcome"`        // OutcomeOK or the error message
+	Prev    string    `json:"prev"`           // Hash of the previous record, empty for the first
+	Hash    string    `json:"hash,omitempty"` // Hash of this record without Hash
+}
+
+// Head identifies the last record of a log
+type Head struct {
+	Seq  uint64
+	Hash string
+}
+
+// hashRecord returns the hash of r, which is computed with Hash empty
+func hashRecord(r Record, key []byte) (string, error) {
+	r.Hash = ""
+	b, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	var h hash.Hash
+	if key != nil {
+		h = hmac.New(sha256.New, key)
+	} else {
+		h = sha256.New()
+	}
+	h.Write(b)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Verify reads the log from r and checks its chain with key, which may be nil for plain hashes. fn, if not nil,
+// is called for each record and can stop verification by returning an error. Verify returns the Head of the
+// last valid record. If expected is not nil, the log must contain the record expected describes
+func Verify(r io.Reader, key []byte, expected *Head, fn func(Record) error) (Head, error) {
+	head, _, err := verify(r, key, expected, fn)
+	return head, err
+}
+
+// verify is Verify that also returns the size of the valid records
+func verify(r io.Reader, key []byte, expected *Head, fn func(Record) error) (Head, int64, error) {
+	br := bufio.NewReader(r)
+	var head Head
+	var size int64
+	for {
+		line, err := br.ReadBytes('\n')
+		if err == io.EOF && len(line) == 0 {
+			break
+		}
+		if err == io.EOF {
+			return head, size, ErrTruncated
+		}
+		if err != nil {
+			return head, size, err
+		}
+		var rec Record
+		d := json.NewDecoder(bytes.NewReader(line))
+		d.DisallowUnknownFields()
+		if d.Decode(&rec) != nil || rec.Seq != head.Seq+1 || rec.Prev != head.Hash {
+			return head, size, ErrModified
+		}
+		h, err := hashRecord(rec, key)
+		if err != nil {
+			return head, size, err
+		}
+		if !hmac.Equal([]byte(h), []byte(rec.Hash)) {
+			return head, size, ErrModified
+		}
+		if expected != nil && rec.Seq == expected.Seq && rec.Hash != expected.Hash {
+			return head, size, ErrModified
+		}
+		if fn != nil {
+			if err := fn(rec); err != nil {
+				return head, size, err
+			}
+		}
+		head = Head{Seq: rec.Seq, Hash: rec.Hash}
+		size += int64(len(line))
+	}
+	if expected != nil && head.Seq < expected.Seq {
+		return head, size, ErrTruncated
+	}
+	return head, size, nil
+}
+
+// Log appends records to a file. Every record is synced to disk before Append returns. The file must not be
+// shared between processes
+type Log struct {
+	lock  sync.Mutex
+	file  *os.File
+	key   []byte
+	head  Head
+	size  int64            // Size of the complete records
+	Clock func() time.Time // Time source, time.Now if nil
+}
+
+// OpenLog opens or creates the log in file name. The existing records are verified with key, which may be nil
+// for plain hashes. A partial last record, left by a crash, is removed. OpenLog fails with ErrModified if the
+// chain is broken
+func OpenLog(name string, key []byte) (*Log, error) {
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	head, size, err := verify(f, key, nil, nil)
+	if err == ErrTruncated {
+		err = f.Truncate(size)
+	}
+	if err == nil {
+		_, err = f.Seek(size, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	l := new(Log)
+	l.file = f
+	l.key = key
+	l.head = head
+	l.size = size
+	return l, nil
+}
+
+// Append completes r with sequence number, time if it is zero, and hashes, and writes it to the log
+func (l *Log) Append(r Record) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if r.Time.IsZero() {
+		if l.Clock != nil {
+			r.Time = l.Clock()
+		} else {
+			r.Time = time.Now()
+		}
+	}
+	r.Time = r.Time.UTC()
+	r.Seq = l.head.Seq + 1
+	r.Prev = l.head.Hash
+	var err error
+	r.Hash, err = hashRecord(r, l.key)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	_, err = l.file.Write(b)
+	if err == nil {
+		err = l.file.Sync()
+	}
+	if err != nil {
+		// Remove a partial record, so that later records continue the chain
+		if l.file.Truncate(l.size) == nil {
+			l.file.Seek(l.size, io.SeekStart)
+		}
+		return err
+	}
+	l.head = Head{Seq: r.Seq, Hash: r.Hash}
+	l.size += int64(len(b))
+	return nil
+}
+
+// Head returns the Head of the log
+func (l *Log) Head() Head {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.head
+}
+
+// Close closes the file
+func (l *Log) Close() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.file.Close()
+}
@@ -0,0 +1,146 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/jjm"
+)
+
+var testKey = []byte("audit key")
+
+// writeTestLog issues two signatures through an audited server and returns the log file and its head
+func writeTestLog(t *testing.T) (string, Head) {
+	name := filepath.Join(t.TempDir(), "audit.log")
+	log, err := OpenLog(name, testKey)
+	if err != nil {
+		t.Fatalf("OpenLog failed: %s", err)
+	}
+	defer log.Close()
+	c := eccutil.SetCurve(elliptic.P256, rand.Reader, eccutil.Sha1Hash)
+	privkey, pubkey, err := c.GenerateKey()
+	if err != nil {
+		t.Fatalf("Error creating keys: %s", err)
+	}
+	s := NewServer(jjm.NewGenericBlindingServer(privkey, pubkey, c), jjm.SchemeName, pubkey, log)
+	client := jjm.NewGenericBlindingClient(pubkey, c)
+	for i := 0; i < 2; i++ {
+		bpc, bps, err := s.GetParams()
+		if err != nil {
+			t.Fatalf("GetParams failed: %s", err)
+		}
+		_, bm, err := client.Blind(bpc, jjm.NewClearMessage([]byte("Secret message")))
+		if err != nil {
+			t.Fatalf("Blind failed: %s", err)
+		}
+		_, err = s.Sign(bps, bm)
+		if err != nil {
+			t.Fatalf("Sign failed: %s", err)
+		}
+		if i == 1 {
+			_, err = s.Sign(bps, jjm.NewClearMessage([]byte("Secret message")))
+			if err == nil {
+				t.Fatal("Sign must fail for a message of the wrong type")
+			}
+		}
+	}
+	return name, log.Head()
+}
+
+func Test_Log(t *testing.T) {
+	name, head := writeTestLog(t)
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %s", err)
+	}
+	if bytes.Contains(b, []byte("Secret message")) {
+		t.Error("Log contains the clear message")
+	}
+	var ops []string
+	got, err := Verify(bytes.NewReader(b), testKey, &head, func(r Record) error {
+		ops = append(ops, r.Op+" "+r.Outcome)
+		if r.Scheme != jjm.SchemeName || len(r.KeyID) != 64 || len(r.ParamID) != 64 {
+			t.Errorf("Incomplete record: %v", r)
+		}
+		return nil
+	})
+	if err != nil || got != head {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if len(ops) != 5 || ops[3] != OpSign+" "+OutcomeOK || ops[4] == OpSign+" "+OutcomeOK {
+		t.Errorf("Wrong records: %q", ops)
+	}
+
+	lines := strings.SplitAfter(string(b), "\n")
+	for _, tc := range []struct {
+		name string
+		log  string
+		key  []byte
+		err  error
+	}{
+		{"WrongKey", string(b), []byte("other"), ErrModified},
+		{"Modified", strings.Replace(string(b), `"outcome":"ok"`, `"outcome":"no"`, 1), testKey, ErrModified},
+		{"Removed", lines[0] + strings.Join(lines[2:], ""), testKey, ErrModified},
+		{"RemovedFirst", strings.Join(lines[1:], ""), testKey, ErrModified},
+		{"RemovedLast", strings.Join(lines[:4], ""), testKey, ErrTruncated},
+		{"Partial", string(b[:len(b)-10]), testKey, ErrTruncated},
+	} {
+		_, err := Verify(strings.NewReader(tc.log), tc.key, &head, nil)
+		if err != tc.err {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.err, err)
+		}
+	}
+}
+
+func Test_Reopen(t *testing.T) {
+	name, head := writeTestLog(t)
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %s", err)
+	}
+	err = os.WriteFile(name, append(b, `{"seq":6,`...), 0600)
+	if err != nil {
+		t.Fatalf("WriteFile failed: %s", err)
+	}
+	log, err := OpenLog(name, testKey)
+	if err != nil {
+		t.Fatalf("Reopening log with partial record failed: %s", err)
+	}
+	if log.Head() != head {
+		t.Errorf("Wrong head after reopening: %v", log.Head())
+	}
+	err = log.Append(Record{Op: OpGetParams, Outcome: OutcomeOK})
+	log.Close()
+	if err != nil {
+		t.Fatalf("Append failed: %s", err)
+	}
+	next, err := Verify(mustOpen(t, name), testKey, &head, nil)
+	if err != nil || next.Seq != head.Seq+1 {
+		t.Errorf("Chain not continued after reopening: %v %v", next, err)
+	}
+
+	err = os.WriteFile(name, bytes.Replace(b, []byte(jjm.SchemeName), []byte("XXX"), 1), 0600)
+	if err != nil {
+		t.Fatalf("WriteFile failed: %s", err)
+	}
+	_, err = OpenLog(name, testKey)
+	if err != ErrModified {
+		t.Errorf("Opening a modified log must fail: %v", err)
+	}
+}
+
+// mustOpen opens file name for reading
+func mustOpen(t *testing.T, name string) *os.File {
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatalf("Open failed: %s", err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"context"
+	"encoding/hex"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+)
+
+// Server is a BlindingServer that records all operations in a Log. Signatures are only returned after their
+// record has been written, if the log fails, Sign fails
+type Server struct {
+	server genericblinding.BlindingServerContext
+	scheme string
+	keyID  string
+	log    *Log
+}
+
+// NewServer returns a Server that records the operations of server, the signer pubKey of scheme, in log
+func NewServer(server genericblinding.BlindingServer, scheme string, pubKey *eccutil.Point, log *Log) *Server {
+	s := new(Server)
+	s.server = genericblinding.ServerWithContext(server)
+	s.scheme = scheme
+	s.keyID = hex.EncodeToString(eccutil.KeyID(pubKey))
+	s.log = log
+	return s
+}
+
+// record appends the record of op on the parameters bps with the result err
+func (s *Server) record(op string, bps genericblinding.BlindingParamServer, err error) error {
+	r := Record{Op: op, Scheme: s.scheme, KeyID: s.keyID, Outcome: OutcomeOK}
+	if bps != nil {
+		r.ParamID = hex.EncodeToString(bps.UniqueID())
+	}
+	if err != nil {
+		r.Outcome = err.Error()
+	}
+	return s.log.Append(r)
+}
+
+// GetParams generates one-time parameters and records them
+func (s *Server) GetParams() (genericblinding.BlindingParamClient, genericblinding.BlindingParamServer, error) {
+	return s.GetParamsContext(context.Background())
+}
+
+// GetParamsContext is GetParams with a context
+func (s *Server) GetParamsContext(ctx context.Context) (genericblinding.BlindingParamClient, genericblinding.BlindingParamServer, error) {
+	bpc, bps, err := s.server.GetParamsContext(ctx)
+	if logErr := s.record(OpGetParams, bps, err); logErr != nil && err == nil {
+		return nil, nil, logErr
+	}
+	return bpc, bps, err
+}
+
+// Sign signs bm and records the outcome
+func (s *Server) Sign(bps genericblinding.BlindingParamServer, bm genericblinding.BlindMessage) (genericblinding.BlindSignature, error) {
+	return s.SignContext(context.Background(), bps, bm)
+}
+
+// SignContext is Sign with a context
+func (s *Server) SignContext(ctx context.Context, bps genericblinding.BlindingParamServer, bm genericblinding.BlindMessage) (genericblinding.BlindSignature, error) {
+	bs, err := s.server.SignContext(ctx, bps, bm)
+	if logErr := s.record(OpSign, bps, err); logErr != nil && err == nil {
+		return nil, logErr
+	}
+	return bs, err
+}
@@ -0,0 +1,195 @@
+// Package blindagent implements a signing agent that keeps the private keys of blind signers out of the
+// processes that issue signatures, similar to ssh-agent. The agent listens on a Unix socket and serves only
+// GetParams and Sign, front-ends use a blindnet.Client returned by Dial as their BlindingServer.
+//
+// A connection starts with a handshake in blindnet frames: the client may send FrameListKeys any number of
+// times and then selects one key with FrameSelectKey. After FrameKeySelected the connection carries the
+// blindnet protocol for that key.
+package blindagent
+
+import (
+	"encoding/asn1"
+	"errors"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/ronperry/cryptoedge/blindnet"
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+)
+
+// Frame types of the handshake. They do not collide with the frame types of blindnet
+const (
+	FrameListKeys    blindnet.FrameType = iota + 0x80 // Request for the keys of the agent, empty payload
+	FrameKeys                                         // Response to FrameListKeys, KeysPayload
+	FrameSelectKey                                    // Request to use a key for the connection, Key
+	FrameKeySelected                                  // Response to FrameSelectKey, empty payload
+)
+
+// CodeKeyUnknown is the error code of FrameError if the selected key is not held by the agent
+const CodeKeyUnknown = "key_unknown"
+
+var (
+	// ErrKeyUnknown is returned if the agent does not hold the selected key
+	ErrKeyUnknown = errors.New("blindagent: Unknown key")
+	// ErrKeyExists is returned when adding a key the agent already holds
+	ErrKeyExists = errors.New("blindagent: Key already added")
+)
+
+// Key identifies a signer key held by the agent
+type Key struct {
+	Scheme string
+	PubKey eccutil.Point
+}
+
+// KeysPayload is the payload of FrameKeys
+type KeysPayload struct {
+	Keys []Key
+}
+
+// agentKey is a key and the server signing with it
+type agentKey struct {
+	Key
+	server *blindnet.Server
+}
+
+// Agent serves the signers added to it on Unix socket connections
+type Agent struct {
+	lock         sync.RWMutex
+	keys         []*agentKey
+	MaxFrameSize int // Limit for request frames, blindnet.DefaultMaxFrameSize if 0. Set before Add
+}
+
+// NewAgent returns an Agent without keys
+func NewAgent() *Agent {
+	return new(Agent)
+}
+
+// Add makes server, the signer of pubKey for scheme, available to clients. Pending parameters are kept in
+// store, which the caller must expire periodically
+func (a *Agent) Add(scheme string, server genericblinding.BlindingServer, pubKey *eccutil.Point, store genericblinding.ParamStore) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.find(scheme, pubKey) != nil {
+		return ErrKeyExists
+	}
+	k := new(agentKey)
+	k.Scheme = scheme
+	k.PubKey = *pubKey
+	k.server = blindnet.NewServer(server, pubKey, store)
+	k.server.MaxFrameSize = a.MaxFrameSize
+	a.keys = append(a.keys, k)
+	return nil
+}
+
+// Keys returns the keys held by the agent
+func (a *Agent) Keys() []Key {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	keys := make([]Key, len(a.keys))
+	for i, k := range a.keys {
+		keys[i] = k.Key
+	}
+	return keys
+}
+
+// find returns the key of scheme and pubKey or nil. The caller holds the lock
+func (a *Agent) find(scheme string, pubKey *eccutil.Point) *agentKey {
+	for _, k := range a.keys {
+		if k.Scheme == scheme && eccutil.PointEqual(&k.PubKey, pubKey) {
+			return k
+		}
+	}
+	return nil
+}
+
+// Listen creates a Unix socket at path that only the owner can connect to. The directory of path should not
+// be writable by others, since the socket is accessible for a moment before its mode is set
+func Listen(path string) (net.Listener, error) {
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	err = os.Chmod(path, 0600)
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Serve accepts connections on l and serves each in its own goroutine. It returns the error of Accept
+func (a *Agent) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go a.ServeConn(conn)
+	}
+}
+
+// ServeConn runs the handshake on conn and then serves the selected key until the client closes the
+// connection. It closes conn
+func (a *Agent) ServeConn(conn net.Conn) error {
+	maxSize := a.MaxFrameSize
+	if maxSize == 0 {
+		maxSize = blindnet.DefaultMaxFrameSize
+	}
+	for {
+		f, err := blindnet.ReadFrame(conn, maxSize)
+		if err != nil {
+			conn.Close()
+			return err
+		}
+		k, resp := a.handshake(f)
+		err = blindnet.WriteFrame(conn, resp)
+		if err != nil {
+			conn.Close()
+			return err
+		}
+		if k != nil {
+			return k.server.ServeConn(conn)
+		}
+	}
+}
+
+// handshake answers a handshake frame. It returns the key if one was selected
+func (a *Agent) handshake(f blindnet.Frame) (*agentKey, blindnet.Frame) {
+	resp := blindnet.Frame{RequestID: f.RequestID}
+	var err error
+	code := blindnet.CodeBadFrame
+	switch f.Type {
+	case FrameListKeys:
+		resp.Type = FrameKeys
+		resp.Payload, err = asn1.Marshal(KeysPayload{Keys: a.Keys()})
+		code = blindnet.CodeInternal
+	case FrameSelectKey:
+		var sel Key
+		rest, e := asn1.Unmarshal(f.Payload, &sel)
+		if e == nil && len(rest) > 0 {
+			e = asn1.SyntaxError{Msg: "trailing data"}
+		}
+		if e != nil {
+			err, code = e, blindnet.CodeMalformed
+			break
+		}
+		a.lock.RLock()
+		k := a.find(sel.Scheme, &sel.PubKey)
+		a.lock.RUnlock()
+		if k == nil {
+			err, code = ErrKeyUnknown, CodeKeyUnknown
+			break
+		}
+		resp.Type = FrameKeySelected
+		return k, resp
+	default:
+		err = blindnet.ErrFrameType
+	}
+	if err != nil {
+		resp.Type = blindnet.FrameError
+		resp.Payload, _ = asn1.Marshal(blindnet.ErrorPayload{Code: code, Message: err.Error()})
+	}
+	return nil, resp
+}
@@ -0,0 +1,115 @@
+package blindagent
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+	"github.com/ronperry/cryptoedge/jjm"
+	"github.com/ronperry/cryptoedge/singhdas"
+)
+
+// newTestKey returns the private and public key of a new signer
+func newTestKey(t *testing.T) (*eccutil.Curve, []byte, *eccutil.Point) {
+	c := eccutil.SetCurve(elliptic.P256, rand.Reader, eccutil.Sha1Hash)
+	privkey, pubkey, err := c.GenerateKey()
+	if err != nil {
+		t.Fatalf("Error creating keys: %s", err)
+	}
+	return c, privkey, pubkey
+}
+
+// issue issues and verifies a signature of cm through server
+func issue(server genericblinding.BlindingServer, client genericblinding.BlindingClient, cm genericblinding.ClearMessage) error {
+	bpc, bps, err := server.GetParams()
+	if err != nil {
+		return err
+	}
+	bf, bm, err := client.Blind(bpc, cm)
+	if err != nil {
+		return err
+	}
+	bs, err := server.Sign(bps, bm)
+	if err != nil {
+		return err
+	}
+	cs, cmOut, err := client.Unblind(bf, cm, bs)
+	if err != nil {
+		return err
+	}
+	ok, err := client.Verify(cs, cmOut)
+	if !ok && err == nil {
+		err = genericblinding.ErrBadSignature
+	}
+	return err
+}
+
+func Test_Agent(t *testing.T) {
+	a := NewAgent()
+	c, sPriv, sPub := newTestKey(t)
+	err := a.Add(singhdas.SchemeName, singhdas.NewGenericBlindingServer(sPriv, sPub, c), sPub, genericblinding.NewMemoryParamStore(time.Minute))
+	if err != nil {
+		t.Fatalf("Add failed: %s", err)
+	}
+	_, jPriv, jPub := newTestKey(t)
+	err = a.Add(jjm.SchemeName, jjm.NewGenericBlindingServer(jPriv, jPub, c), jPub, genericblinding.NewMemoryParamStore(time.Minute))
+	if err != nil {
+		t.Fatalf("Add failed: %s", err)
+	}
+	err = a.Add(jjm.SchemeName, jjm.NewGenericBlindingServer(jPriv, jPub, c), jPub, genericblinding.NewMemoryParamStore(time.Minute))
+	if err != ErrKeyExists {
+		t.Errorf("Adding a key twice must fail with ErrKeyExists: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "agent.sock")
+	l, err := Listen(path)
+	if err != nil {
+		t.Fatalf("Listen failed: %s", err)
+	}
+	defer l.Close()
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat failed: %s", err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Errorf("Socket must only be accessible by owner: %s", fi.Mode())
+	}
+	go a.Serve(l)
+
+	keys, err := List(path)
+	if err != nil {
+		t.Fatalf("List failed: %s", err)
+	}
+	if len(keys) != 2 || keys[0].Scheme != singhdas.SchemeName || !eccutil.PointEqual(&keys[1].PubKey, jPub) {
+		t.Errorf("List returned wrong keys: %v", keys)
+	}
+
+	s, err := Dial(path, singhdas.SchemeName, sPub)
+	if err != nil {
+		t.Fatalf("Dial failed: %s", err)
+	}
+	defer s.Close()
+	err = issue(s, singhdas.NewGenericBlindingClient(sPub, c), singhdas.NewClearMessage([]byte("Message")))
+	if err != nil {
+		t.Errorf("Signing through agent failed: %s", err)
+	}
+	j, err := Dial(path, jjm.SchemeName, jPub)
+	if err != nil {
+		t.Fatalf("Dial failed: %s", err)
+	}
+	defer j.Close()
+	err = issue(j, jjm.NewGenericBlindingClient(jPub, c), jjm.NewClearMessage([]byte("Message")))
+	if err != nil {
+		t.Errorf("Signing through agent failed: %s", err)
+	}
+
+	_, err = Dial(path, jjm.SchemeName, sPub)
+	if err != ErrKeyUnknown {
+		t.Errorf("Dial must fail with ErrKeyUnknown for key of other scheme: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package blindagent
+
+import (
+	"encoding/asn1"
+	"net"
+
+	"github.com/ronperry/cryptoedge/blindnet"
+	"github.com/ronperry/cryptoedge/eccutil"
+)
+
+// roundTrip sends a handshake request on conn and returns the payload of the response of type respType
+func roundTrip(conn net.Conn, reqType blindnet.FrameType, payload []byte, respType blindnet.FrameType) ([]byte, error) {
+	err := blindnet.WriteFrame(conn, blindnet.Frame{Type: reqType, Payload: payload})
+	if err != nil {
+		return nil, err
+	}
+	f, err := blindnet.ReadFrame(conn, blindnet.DefaultMaxFrameSize)
+	if err != nil {
+		return nil, err
+	}
+	if f.Type == blindnet.FrameError {
+		var ep blindnet.ErrorPayload
+		_, err = asn1.Unmarshal(f.Payload, &ep)
+		if err != nil {
+			return nil, blindnet.ErrResponse
+		}
+		if ep.Code == CodeKeyUnknown {
+			return nil, ErrKeyUnknown
+		}
+		return nil, &blindnet.Error{Code: ep.Code, Message: ep.Message}
+	}
+	if f.Type != respType {
+		return nil, blindnet.ErrFrameType
+	}
+	return f.Payload, nil
+}
+
+// ListKeys returns the keys held by the agent on conn. The connection stays in the handshake
+func ListKeys(conn net.Conn) ([]Key, error) {
+	payload, err := roundTrip(conn, FrameListKeys, nil, FrameKeys)
+	if err != nil {
+		return nil, err
+	}
+	var kp KeysPayload
+	rest, err := asn1.Unmarshal(payload, &kp)
+	if err != nil || len(rest) > 0 {
+		return nil, blindnet.ErrResponse
+	}
+	return kp.Keys, nil
+}
+
+// SelectKey ends the handshake on conn by selecting the signer pubKey of scheme. The connection then carries
+// the blindnet protocol for that key
+func SelectKey(conn net.Conn, scheme string, pubKey *eccutil.Point) error {
+	payload, err := asn1.Marshal(Key{Scheme: scheme, PubKey: *pubKey})
+	if err != nil {
+		return err
+	}
+	_, err = roundTrip(conn, FrameSelectKey, payload, FrameKeySelected)
+	return err
+}
+
+// List connects to the agent at the socket path and returns its keys
+func List(path string) ([]Key, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return ListKeys(conn)
+}
+
+// Dial connects to the agent at the socket path and returns a genericblinding.BlindingServer for the signer
+// pubKey of scheme
+func Dial(path, scheme string, pubKey *eccutil.Point) (*blindnet.Client, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	err = SelectKey(conn, scheme, pubKey)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return blindnet.NewClient(conn, scheme, pubKey), nil
+}
@@ -0,0 +1,192 @@
+package blindhttp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+)
+
+// AuthScheme is the HTTP authentication scheme of blind signed tokens. The credentials are the unpadded
+// base64url encodings of the marshalled ClearSignature and ClearMessage, separated by a dot:
+//
+//	Authorization: BlindToken <signature>.<message>
+const AuthScheme = "BlindToken"
+
+var (
+	// ErrNoToken is returned if a request does not carry a blind token
+	ErrNoToken = errors.New("blindhttp: Blind token required")
+	// ErrMalformedToken is returned if the Authorization header cannot be parsed
+	ErrMalformedToken = errors.New("blindhttp: Malformed Authorization header")
+	// ErrInvalidToken is returned if a token does not decode or its signature does not verify
+	ErrInvalidToken = errors.New("blindhttp: Invalid blind token")
+)
+
+// authKey is the context key of the ClearMessage of an authenticated request
+type authKey struct{}
+
+// Authenticator admits requests that carry a valid blind token of its signer. Each token is admitted once
+type Authenticator struct {
+	client genericblinding.BlindingClientContext
+	scheme string
+	pubKey *eccutil.Point
+	store  genericblinding.SpentStore
+	Realm  string // Realm of the challenges, omitted if empty
+}
+
+// NewAuthenticator returns an Authenticator for tokens signed by pubKey in scheme, verified by client. Redeemed
+// tokens are recorded in store
+func NewAuthenticator(client genericblinding.BlindingClient, scheme string, pubKey *eccutil.Point, store genericblinding.SpentStore) *Authenticator {
+	a := new(Authenticator)
+	a.client = genericblinding.ClientWithContext(client)
+	a.scheme = scheme
+	a.pubKey = pubKey
+	a.store = store
+	return a
+}
+
+// EncodeToken returns the credentials of the Authorization header for the token cs, cm
+func EncodeToken(cs genericblinding.ClearSignature, cm genericblinding.ClearMessage) (string, error) {
+	sig, err := cs.Marshal()
+	if err != nil {
+		return "", err
+	}
+	msg, err := cm.Marshal()
+	if err != nil {
+		return "", err
+	}
+	return AuthScheme + " " + base64.RawURLEncoding.EncodeToString(sig) + "." + base64.RawURLEncoding.EncodeToString(msg), nil
+}
+
+// SetToken sets the Authorization header of r to the token cs, cm
+func SetToken(r *http.Request, cs genericblinding.ClearSignature, cm genericblinding.ClearMessage) error {
+	credentials, err := EncodeToken(cs, cm)
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Authorization", credentials)
+	return nil
+}
+
+// TokenMessage returns the ClearMessage of the token that authenticated the request of ctx
+func TokenMessage(ctx context.Context) (genericblinding.ClearMessage, bool) {
+	cm, ok := ctx.Value(authKey{}).(genericblinding.ClearMessage)
+	return cm, ok
+}
+
+// decodeToken parses the Authorization headers of a request
+func (a *Authenticator) decodeToken(headers []string) (genericblinding.ClearSignature, genericblinding.ClearMessage, error) {
+	if len(headers) == 0 {
+		return nil, nil, ErrNoToken
+	}
+	if len(headers) > 1 {
+		return nil, nil, ErrMalformedToken
+	}
+	scheme, credentials, _ := strings.Cut(headers[0], " ")
+	if !strings.EqualFold(scheme, AuthScheme) {
+		return nil, nil, ErrNoToken
+	}
+	sig, msg, ok := strings.Cut(strings.TrimSpace(credentials), ".")
+	if !ok {
+		return nil, nil, ErrMalformedToken
+	}
+	sigBytes, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return nil, nil, ErrMalformedToken
+	}
+	msgBytes, err := base64.RawURLEncoding.DecodeString(msg)
+	if err != nil {
+		return nil, nil, ErrMalformedToken
+	}
+	csi, err := genericblinding.Decode(sigBytes, a.pubKey)
+	if err != nil {
+		return nil, nil, ErrInvalidToken
+	}
+	cs, ok := csi.(genericblinding.ClearSignature)
+	if _, err = genericblinding.MatchMessage(csi, a.scheme, genericblinding.TypeClearSignature, a.pubKey); err != nil || !ok {
+		return nil, nil, ErrInvalidToken
+	}
+	cmi, err := genericblinding.Decode(msgBytes, a.pubKey)
+	if err != nil {
+		return nil, nil, ErrInvalidToken
+	}
+	cm, ok := cmi.(genericblinding.ClearMessage)
+	if _, err = genericblinding.MatchMessage(cmi, a.scheme, genericblinding.TypeClearMessage, a.pubKey); err != nil || !ok {
+		return nil, nil, ErrInvalidToken
+	}
+	return cs, cm, nil
+}
+
+// Authenticate verifies the token of r and marks it as spent. It returns the ClearMessage of the token, or
+// ErrNoToken, ErrMalformedToken, ErrInvalidToken, genericblinding.ErrSpent or the error of the SpentStore
+func (a *Authenticator) Authenticate(r *http.Request) (genericblinding.ClearMessage, error) {
+	cs, cm, err := a.decodeToken(r.Header.Values("Authorization"))
+	if err != nil {
+		return nil, err
+	}
+	ok, err := a.client.VerifyContext(r.Context(), cs, cm)
+	if ctxErr := r.Context().Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	if err != nil || !ok {
+		return nil, ErrInvalidToken
+	}
+	err = a.store.Spend(genericblinding.SpentID(cs, cm))
+	if err != nil {
+		return nil, err
+	}
+	return cm, nil
+}
+
+// Middleware returns a handler that calls next only for requests with a valid, unspent token. The
+// ClearMessage of the token is available to next through TokenMessage. Rejected requests receive an Error
+// body and, except for server errors, a WWW-Authenticate challenge as in RFC 6750
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cm, err := a.Authenticate(r)
+		if err != nil {
+			a.reject(w, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), authKey{}, cm)))
+	})
+}
+
+// Challenge returns the WWW-Authenticate challenge. errorCode and description are omitted if empty
+func (a *Authenticator) Challenge(errorCode, description string) string {
+	params := make([]string, 0, 5)
+	if a.Realm != "" {
+		params = append(params, "realm="+quote(a.Realm))
+	}
+	params = append(params, "scheme="+quote(a.scheme), "key_id="+quote(hex.EncodeToString(eccutil.KeyID(a.pubKey))))
+	if errorCode != "" {
+		params = append(params, "error="+quote(errorCode))
+	}
+	if description != "" {
+		params = append(params, "error_description="+quote(description))
+	}
+	return AuthScheme + " " + strings.Join(params, ", ")
+}
+
+// quote returns s as quoted-string
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// reject writes the response for a request that failed authentication with err
+func (a *Authenticator) reject(w http.ResponseWriter, err error) {
+	switch err {
+	case ErrNoToken:
+		w.Header().Set("WWW-Authenticate", a.Challenge("", ""))
+	case ErrMalformedToken:
+		w.Header().Set("WWW-Authenticate", a.Challenge(CodeInvalidRequest, err.Error()))
+	case ErrInvalidToken, genericblinding.ErrSpent:
+		w.Header().Set("WWW-Authenticate", a.Challenge(CodeInvalidToken, err.Error()))
+	}
+	writeError(w, err)
+}
@@ -0,0 +1,134 @@
+package blindhttp
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+	"github.com/ronperry/cryptoedge/jjm"
+)
+
+// issueToken obtains a signature of msg from ts
+func issueToken(t *testing.T, ts *testServer, msg string) (genericblinding.ClearSignature, genericblinding.ClearMessage) {
+	c := NewClient(ts.URL, jjm.SchemeName, ts.pubKey)
+	bpc, bps, err := c.GetParams()
+	if err != nil {
+		t.Fatalf("GetParams failed: %s", err)
+	}
+	bf, bm, err := ts.client.Blind(bpc, jjm.NewClearMessage([]byte(msg)))
+	if err != nil {
+		t.Fatalf("Blind failed: %s", err)
+	}
+	bs, err := c.Sign(bps, bm)
+	if err != nil {
+		t.Fatalf("Sign failed: %s", err)
+	}
+	cs, cm, err := ts.client.Unblind(bf, jjm.NewClearMessage([]byte(msg)), bs)
+	if err != nil {
+		t.Fatalf("Unblind failed: %s", err)
+	}
+	return cs, cm
+}
+
+func Test_Authenticator(t *testing.T) {
+	ts := newTestServer(t)
+	a := NewAuthenticator(ts.client, jjm.SchemeName, ts.pubKey, genericblinding.NewMemorySpentStore())
+	a.Realm = "test"
+	h := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cm, ok := TokenMessage(r.Context())
+		if !ok {
+			t.Error("Token message missing from context")
+		} else {
+			io.WriteString(w, string(cm.UniqueID()))
+		}
+	}))
+
+	cs, cm := issueToken(t, ts, "Message")
+	token, err := EncodeToken(cs, cm)
+	if err != nil {
+		t.Fatalf("EncodeToken failed: %s", err)
+	}
+	otherCS, _ := issueToken(t, ts, "Other")
+	forged, err := EncodeToken(otherCS, cm)
+	if err != nil {
+		t.Fatalf("EncodeToken failed: %s", err)
+	}
+	// Malleated copies of the token: s+N is not canonical, f x R and r/f is another valid signature
+	c := eccutil.SetCurve(elliptic.P256, rand.Reader, eccutil.Sha1Hash)
+	plusN := cs.(jjm.ClearSignature)
+	plusN.ScalarS = new(big.Int).Add(plusN.ScalarS, c.Params.N)
+	plusNToken, err := EncodeToken(plusN, cm)
+	if err != nil {
+		t.Fatalf("EncodeToken failed: %s", err)
+	}
+	scaled := cs.(jjm.ClearSignature)
+	f := big.NewInt(3)
+	fInv, _ := c.ModInverse(f)
+	scaled.PointR = *c.ScalarMult(&scaled.PointR, f.Bytes())
+	scaled.ScalarR = c.Mod(new(big.Int).Mul(scaled.ScalarR, fInv))
+	if ok, err := ts.client.Verify(scaled, cm); !ok || err != nil {
+		t.Fatalf("Scaled signature must verify: %v", err)
+	}
+	scaledToken, err := EncodeToken(scaled, cm)
+	if err != nil {
+		t.Fatalf("EncodeToken failed: %s", err)
+	}
+
+	for _, tc := range []struct {
+		name      string
+		header    []string
+		status    int
+		code      string
+		challenge string // error of the challenge, empty for a challenge without error
+	}{
+		{"Missing", nil, http.StatusUnauthorized, CodeTokenRequired, ""},
+		{"OtherScheme", []string{"Bearer abc"}, http.StatusUnauthorized, CodeTokenRequired, ""},
+		{"NoDot", []string{AuthScheme + " abc"}, http.StatusBadRequest, CodeInvalidRequest, CodeInvalidRequest},
+		{"BadBase64", []string{AuthScheme + " a+b.c"}, http.StatusBadRequest, CodeInvalidRequest, CodeInvalidRequest},
+		{"Twice", []string{token, token}, http.StatusBadRequest, CodeInvalidRequest, CodeInvalidRequest},
+		{"Garbage", []string{AuthScheme + " abc.def"}, http.StatusUnauthorized, CodeInvalidToken, CodeInvalidToken},
+		{"Forged", []string{forged}, http.StatusUnauthorized, CodeInvalidToken, CodeInvalidToken},
+		{"Valid", []string{strings.ToLower(AuthScheme) + token[len(AuthScheme):]}, http.StatusOK, "", ""},
+		{"Spent", []string{token}, http.StatusUnauthorized, CodeTokenSpent, CodeInvalidToken},
+		{"SpentPlusN", []string{plusNToken}, http.StatusUnauthorized, CodeInvalidToken, CodeInvalidToken},
+		{"SpentScaled", []string{scaledToken}, http.StatusUnauthorized, CodeTokenSpent, CodeInvalidToken},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for _, v := range tc.header {
+				req.Header.Add("Authorization", v)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tc.status {
+				t.Fatalf("Wrong status %d, expected %d: %s", rec.Code, tc.status, rec.Body)
+			}
+			challenge := rec.Header().Get("WWW-Authenticate")
+			if tc.status == http.StatusOK {
+				if challenge != "" || rec.Body.String() != string(cm.UniqueID()) {
+					t.Errorf("Wrong response for valid token: %q %q", challenge, rec.Body)
+				}
+				return
+			}
+			var e Error
+			if err := json.Unmarshal(rec.Body.Bytes(), &e); err != nil || e.Code != tc.code {
+				t.Errorf("Wrong error body: %s", rec.Body)
+			}
+			if !strings.HasPrefix(challenge, AuthScheme+` realm="test", scheme="JJM", key_id="`) {
+				t.Errorf("Wrong challenge: %s", challenge)
+			}
+			hasError := strings.Contains(challenge, "error=")
+			if tc.challenge == "" && hasError || tc.challenge != "" && !strings.Contains(challenge, `error="`+tc.challenge+`"`) {
+				t.Errorf("Wrong challenge error, expected %q: %s", tc.challenge, challenge)
+			}
+		})
+	}
+}
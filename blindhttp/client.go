@@ -0,0 +1,267 @@
+package blindhttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+	"github.com/ronperry/cryptoedge/pow"
+)
+
+// Defaults of Client
+const (
+	DefaultTimeout    = 10 * time.Second
+	DefaultRetries    = 3
+	DefaultRetryWait  = 100 * time.Millisecond
+	DefaultMaxPoWBits = pow.DefaultMaxBits // Every challenge of a pow.Server with default MaxBits
+)
+
+var (
+	// ErrResponse is returned if the response of the server cannot be parsed
+	ErrResponse = errors.New("blindhttp: Malformed response")
+	// ErrPoWTooHard is returned if the server demands more proof of work than MaxPoWBits
+	ErrPoWTooHard = errors.New("blindhttp: Proof of work too hard")
+)
+
+// Client is a genericblinding.BlindingServer that calls the endpoints of a remote Handler. All data returned
+// by the server is checked to belong to Scheme and PubKey.
+//
+// Requests are repeated after network errors, timeouts and temporary failures of the server. Parameters fetched
+// by a lost params request expire unused. A sign request that the Handler failed has consumed the parameters,
+// so sign is only repeated if the response may have been lost: after network errors, timeouts and gateway
+// errors. The Handler answers a repeated sign request with the signature it already issued
+type Client struct {
+	URL         string // Root of the Handler, without trailing slash
+	Scheme      string
+	PubKey      *eccutil.Point
+	HTTPClient  *http.Client  // http.DefaultClient if nil
+	ContentType string        // Encoding of requests and responses, ContentTypeDER if empty
+	Timeout     time.Duration // Timeout of each attempt, DefaultTimeout if 0
+	Retries     int           // Number of repetitions of failed requests, DefaultRetries if 0, none if < 0
+	RetryWait   time.Duration // Wait before the first repetition, doubled for each further. DefaultRetryWait if 0
+	MaxPoWBits  int           // Highest difficulty of pow challenges that are solved, DefaultMaxPoWBits if 0
+}
+
+// NewClient returns a Client for the Handler at url that signs for scheme with pubKey
+func NewClient(url, scheme string, pubKey *eccutil.Point) *Client {
+	c := new(Client)
+	c.URL = strings.TrimSuffix(url, "/")
+	c.Scheme = scheme
+	c.PubKey = pubKey
+	return c
+}
+
+// GetParams fetches one-time parameters from the server. The BlindingParamServer is a genericblinding.ParamRef
+func (c *Client) GetParams() (genericblinding.BlindingParamClient, genericblinding.BlindingParamServer, error) {
+	return c.GetParamsContext(context.Background())
+}
+
+// GetParamsContext is GetParams with a context. If the server demands a proof of work, the challenge is solved
+// and the request repeated once
+func (c *Client) GetParamsContext(ctx context.Context) (genericblinding.BlindingParamClient, genericblinding.BlindingParamServer, error) {
+	header, bpc, err := c.do(ctx, ParamsPath, nil, "", nil, genericblinding.TypeBlindingParamClient)
+	var e *Error
+	if errors.As(err, &e) && e.Challenge != "" {
+		var solution string
+		solution, err = c.solve(ctx, e.Challenge)
+		if err == nil {
+			header, bpc, err = c.do(ctx, ParamsPath, nil, solution, nil, genericblinding.TypeBlindingParamClient)
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	id, err := hex.DecodeString(header.Get(ParamIDHeader))
+	if err != nil || len(id) == 0 {
+		return nil, nil, ErrResponse
+	}
+	return bpc, genericblinding.NewParamRef(c.Scheme, c.PubKey, id), nil
+}
+
+// Sign requests a signature of bm with the parameters referenced by bpsi, a genericblinding.ParamRef returned
+// by GetParams
+func (c *Client) Sign(bpsi genericblinding.BlindingParamServer, bm genericblinding.BlindMessage) (genericblinding.BlindSignature, error) {
+	return c.SignContext(context.Background(), bpsi, bm)
+}
+
+// SignContext is Sign with a context
+func (c *Client) SignContext(ctx context.Context, bpsi genericblinding.BlindingParamServer, bm genericblinding.BlindMessage) (genericblinding.BlindSignature, error) {
+	_, err := genericblinding.MatchMessage(bpsi, c.Scheme, genericblinding.TypeBlindingParamServer, c.PubKey)
+	if err != nil {
+		return nil, err
+	}
+	ref, ok := bpsi.(genericblinding.ParamRef)
+	if !ok {
+		return nil, genericblinding.ErrBadType
+	}
+	_, err = genericblinding.MatchMessage(bm, c.Scheme, genericblinding.TypeBlindMessage, c.PubKey)
+	if err != nil {
+		return nil, err
+	}
+	var body []byte
+	if c.contentType() == ContentTypeJSON {
+		body, err = genericblinding.MarshalJSON(bm)
+	} else {
+		body, err = bm.Marshal()
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, bs, err := c.do(ctx, SignPath, ref.ID, "", body, genericblinding.TypeBlindSignature)
+	return bs, err
+}
+
+// solve returns the encoded solution of the encoded challenge, unless it is harder than MaxPoWBits
+func (c *Client) solve(ctx context.Context, challenge string) (string, error) {
+	ch, err := pow.ParseChallenge(challenge)
+	if err != nil {
+		return "", ErrResponse
+	}
+	maxBits := c.MaxPoWBits
+	if maxBits == 0 {
+		maxBits = DefaultMaxPoWBits
+	}
+	if ch.Bits > maxBits {
+		return "", ErrPoWTooHard
+	}
+	solution, err := pow.Solve(ctx, ch)
+	if err != nil {
+		return "", err
+	}
+	return solution.String(), nil
+}
+
+func (c *Client) contentType() string {
+	if c.ContentType == "" {
+		return ContentTypeDER
+	}
+	return c.ContentType
+}
+
+// do posts body to path, repeating the request as configured, and returns the header of the response and
+// the BlindingData in its body, which must be of dataType. id and solution are sent if not empty
+func (c *Client) do(ctx context.Context, path string, id []byte, solution string, body []byte, dataType genericblinding.DataType) (http.Header, genericblinding.BlindingData, error) {
+	retries := c.Retries
+	if retries == 0 {
+		retries = DefaultRetries
+	}
+	wait := c.RetryWait
+	if wait == 0 {
+		wait = DefaultRetryWait
+	}
+	for attempt := 0; ; attempt++ {
+		header, b, err := c.post(ctx, path, id, solution, body)
+		if err == nil {
+			bd, err := c.decode(b, dataType)
+			return header, bd, err
+		}
+		if attempt >= retries || !temporary(err) || path == SignPath && !lost(err) {
+			return nil, nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(wait << uint(attempt)):
+		}
+	}
+}
+
+// post sends one request and returns header and body of a successful response
+func (c *Client) post(ctx context.Context, path string, id []byte, solution string, body []byte) (http.Header, []byte, error) {
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Accept", c.contentType())
+	if body != nil {
+		req.Header.Set("Content-Type", c.contentType())
+	}
+	if id != nil {
+		req.Header.Set(ParamIDHeader, hex.EncodeToString(id))
+	}
+	if solution != "" {
+		req.Header.Set(PoWSolutionHeader, solution)
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, DefaultMaxBodySize))
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		e := new(Error)
+		if json.Unmarshal(b, e) != nil || e.Code == "" {
+			e.Code, e.Message = CodeInternal, resp.Status
+		}
+		e.Status = resp.StatusCode
+		return nil, nil, e
+	}
+	return resp.Header, b, nil
+}
+
+// decode parses b and checks that it is dataType of the expected scheme and signer
+func (c *Client) decode(b []byte, dataType genericblinding.DataType) (genericblinding.BlindingData, error) {
+	var bd genericblinding.BlindingData
+	var err error
+	if c.contentType() == ContentTypeJSON {
+		bd, err = genericblinding.DecodeJSON(b, c.PubKey)
+	} else {
+		bd, err = genericblinding.Decode(b, c.PubKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, err = genericblinding.MatchMessage(bd, c.Scheme, dataType, c.PubKey)
+	if err != nil {
+		return nil, err
+	}
+	return bd, nil
+}
+
+// temporary returns true if a request that failed with err can be repeated
+func temporary(err error) bool {
+	var e *Error
+	if errors.As(err, &e) {
+		if e.Code == CodeQuotaExceeded { // Would only be repeated in vain
+			return false
+		}
+		switch e.Status {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout, http.StatusTooManyRequests:
+			return true
+		}
+		return false
+	}
+	// Network errors and timeouts of a single attempt
+	return !errors.Is(err, context.Canceled)
+}
+
+// lost returns true if the response to a request that failed with err may have been lost or the request never
+// reached the Handler
+func lost(err error) bool {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Status == http.StatusBadGateway || e.Status == http.StatusGatewayTimeout
+	}
+	return true
+}
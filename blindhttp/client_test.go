@@ -0,0 +1,276 @@
+package blindhttp
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+	"github.com/ronperry/cryptoedge/jjm"
+	"github.com/ronperry/cryptoedge/pow"
+	"github.com/ronperry/cryptoedge/quota"
+)
+
+// flakyHandler fails the first request to each path. If lose is set, the request is served before the
+// failure is reported, as if the response got lost
+type flakyHandler struct {
+	handler http.Handler
+	lose    bool
+	lock    sync.Mutex
+	failed  map[string]bool
+}
+
+func (fh *flakyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fh.lock.Lock()
+	fail := !fh.failed[r.URL.Path]
+	fh.failed[r.URL.Path] = true
+	fh.lock.Unlock()
+	if !fail {
+		fh.handler.ServeHTTP(w, r)
+		return
+	}
+	if fh.lose {
+		fh.handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	http.Error(w, "Bad gateway", http.StatusBadGateway)
+}
+
+func newTestClient(t *testing.T, lose bool) (*Client, genericblinding.BlindingClient) {
+	c := eccutil.SetCurve(elliptic.P256, rand.Reader, eccutil.Sha1Hash)
+	privkey, pubkey, err := c.GenerateKey()
+	if err != nil {
+		t.Fatalf("Error creating keys: %s", err)
+	}
+	h := NewHandler(jjm.NewGenericBlindingServer(privkey, pubkey, c), pubkey, genericblinding.NewMemoryParamStore(time.Minute))
+	ts := httptest.NewServer(&flakyHandler{handler: h, lose: lose, failed: make(map[string]bool)})
+	t.Cleanup(ts.Close)
+	client := NewClient(ts.URL+"/", jjm.SchemeName, pubkey)
+	client.RetryWait = time.Millisecond
+	return client, jjm.NewGenericBlindingClient(pubkey, c)
+}
+
+// roundTrip issues and verifies a signature through server
+func roundTrip(t *testing.T, server genericblinding.BlindingServer, client genericblinding.BlindingClient) {
+	cm := jjm.NewClearMessage([]byte("Message over remote server"))
+	bpc, bps, err := server.GetParams()
+	if err != nil {
+		t.Fatalf("GetParams failed: %s", err)
+	}
+	bf, bm, err := client.Blind(bpc, cm)
+	if err != nil {
+		t.Fatalf("Blind failed: %s", err)
+	}
+	bs, err := server.Sign(bps, bm)
+	if err != nil {
+		t.Fatalf("Sign failed: %s", err)
+	}
+	cs, cmOut, err := client.Unblind(bf, cm, bs)
+	if err != nil {
+		t.Fatalf("Unblind failed: %s", err)
+	}
+	ok, err := client.Verify(cs, cmOut)
+	if !ok || err != nil {
+		t.Errorf("Signature does not verify: %v", err)
+	}
+}
+
+func Test_Client(t *testing.T) {
+	var _ genericblinding.BlindingServer = new(Client)
+	var _ genericblinding.BlindingServerContext = new(Client)
+	for _, contentType := range []string{ContentTypeDER, ContentTypeJSON} {
+		server, client := newTestClient(t, false)
+		server.ContentType = contentType
+		roundTrip(t, server, client)
+	}
+}
+
+func Test_ClientRetry(t *testing.T) {
+	server, client := newTestClient(t, true)
+	roundTrip(t, server, client)
+
+	server, client = newTestClient(t, false)
+	server.Retries = -1
+	_, _, err := server.GetParams()
+	var e *Error
+	if !errors.As(err, &e) || e.Status != http.StatusBadGateway {
+		t.Errorf("GetParams without retries must fail: %v", err)
+	}
+}
+
+func Test_ClientSignRetry(t *testing.T) {
+	c := eccutil.SetCurve(elliptic.P256, rand.Reader, eccutil.Sha1Hash)
+	privkey, pubkey, err := c.GenerateKey()
+	if err != nil {
+		t.Fatalf("Error creating keys: %s", err)
+	}
+	h := NewHandler(jjm.NewGenericBlindingServer(privkey, pubkey, c), pubkey, genericblinding.NewMemoryParamStore(time.Minute))
+	var status, requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != SignPath {
+			h.ServeHTTP(w, r)
+			return
+		}
+		atomic.AddInt32(&requests, 1)
+		http.Error(w, "Failed", int(atomic.LoadInt32(&status)))
+	}))
+	defer ts.Close()
+	server := NewClient(ts.URL, jjm.SchemeName, pubkey)
+	server.Retries = 2
+	server.RetryWait = time.Millisecond
+	client := jjm.NewGenericBlindingClient(pubkey, c)
+	for _, tc := range []struct {
+		status   int32
+		requests int32
+	}{
+		{http.StatusServiceUnavailable, 1}, // Parameters consumed by the failed sign
+		{http.StatusTooManyRequests, 1},
+		{http.StatusBadGateway, 3}, // Response may have been lost
+		{http.StatusGatewayTimeout, 3},
+	} {
+		bpc, bps, err := server.GetParams()
+		if err != nil {
+			t.Fatalf("GetParams failed: %s", err)
+		}
+		_, bm, err := client.Blind(bpc, jjm.NewClearMessage([]byte("Message")))
+		if err != nil {
+			t.Fatalf("Blind failed: %s", err)
+		}
+		atomic.StoreInt32(&status, tc.status)
+		atomic.StoreInt32(&requests, 0)
+		_, err = server.Sign(bps, bm)
+		var e *Error
+		if !errors.As(err, &e) || e.Status != int(tc.status) {
+			t.Errorf("%d: Sign must fail: %v", tc.status, err)
+		}
+		if n := atomic.LoadInt32(&requests); n != tc.requests {
+			t.Errorf("%d: %d sign requests, expected %d", tc.status, n, tc.requests)
+		}
+	}
+}
+
+func Test_ClientErrors(t *testing.T) {
+	server, client := newTestClient(t, false)
+	server.Retries = -1
+	_, _, _ = server.GetParams() // Consume the failure of flakyHandler
+	bpc, bps, err := server.GetParams()
+	if err != nil {
+		t.Fatalf("GetParams failed: %s", err)
+	}
+	_, bm, err := client.Blind(bpc, jjm.NewClearMessage([]byte("Message")))
+	if err != nil {
+		t.Fatalf("Blind failed: %s", err)
+	}
+	ref := bps.(genericblinding.ParamRef)
+	b, err := ref.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal failed: %s", err)
+	}
+	bpsi, err := ref.Unmarshal(b)
+	if err != nil || string(bpsi.UniqueID()) != string(ref.ID) {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	_, _ = server.Sign(bps, bm) // Consume the failure of flakyHandler
+	_, err = server.Sign(genericblinding.NewParamRef(jjm.SchemeName, server.PubKey, make([]byte, genericblinding.ParamIDSize)), bm)
+	if !errors.Is(err, genericblinding.ErrParamUnknown) {
+		t.Errorf("Sign with unknown parameters must fail with ErrParamUnknown: %v", err)
+	}
+	_, err = server.Sign(genericblinding.NewParamRef("XXX", server.PubKey, ref.ID), bm)
+	if err != genericblinding.ErrBadScheme {
+		t.Errorf("Sign with foreign parameters must fail with ErrBadScheme: %v", err)
+	}
+
+	c := eccutil.SetCurve(elliptic.P256, rand.Reader, eccutil.Sha1Hash)
+	_, other, err := c.GenerateKey()
+	if err != nil {
+		t.Fatalf("Error creating keys: %s", err)
+	}
+	foreign := NewClient(server.URL, jjm.SchemeName, other)
+	_, _, err = foreign.GetParams()
+	if err == nil {
+		t.Error("GetParams must fail for parameters of other signer")
+	}
+	foreign = NewClient(server.URL, "XXX", server.PubKey)
+	_, _, err = foreign.GetParams()
+	if err != genericblinding.ErrBadScheme {
+		t.Errorf("GetParams must fail with ErrBadScheme for other scheme: %v", err)
+	}
+}
+
+func Test_ClientQuota(t *testing.T) {
+	c := eccutil.SetCurve(elliptic.P256, rand.Reader, eccutil.Sha1Hash)
+	privkey, pubkey, err := c.GenerateKey()
+	if err != nil {
+		t.Fatalf("Error creating keys: %s", err)
+	}
+	policy := quota.Policy{Default: []quota.Limit{{Ops: quota.OpGetParams, Kind: quota.FixedWindow, Count: 1, Period: time.Hour}}}
+	qs, err := quota.NewServer(jjm.NewGenericBlindingServer(privkey, pubkey, c), jjm.SchemeName, pubkey, policy, quota.NewMemoryStore())
+	if err != nil {
+		t.Fatalf("NewServer failed: %s", err)
+	}
+	var requests int32
+	h := NewHandler(qs, pubkey, genericblinding.NewMemoryParamStore(time.Minute))
+	ts := httptest.NewServer(quota.HTTPIdentity(func(r *http.Request) string {
+		atomic.AddInt32(&requests, 1)
+		return r.Header.Get("X-User")
+	}, h))
+	defer ts.Close()
+	client := NewClient(ts.URL, jjm.SchemeName, pubkey)
+	client.RetryWait = time.Millisecond
+	_, _, err = client.GetParams()
+	if err != nil {
+		t.Fatalf("GetParams failed: %s", err)
+	}
+	_, _, err = client.GetParams()
+	if !errors.Is(err, quota.ErrQuotaExceeded) {
+		t.Errorf("Second GetParams must exceed quota: %v", err)
+	}
+	if atomic.LoadInt32(&requests) != 2 {
+		t.Errorf("Exceeded quota must not be retried: %d requests", requests)
+	}
+	resp, err := http.Post(ts.URL+ParamsPath, ContentTypeDER, nil)
+	if err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") == "" {
+		t.Errorf("Exceeded quota must be reported as 429 with Retry-After: %d %q", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+}
+
+func Test_ClientPoW(t *testing.T) {
+	c := eccutil.SetCurve(elliptic.P256, rand.Reader, eccutil.Sha1Hash)
+	privkey, pubkey, err := c.GenerateKey()
+	if err != nil {
+		t.Fatalf("Error creating keys: %s", err)
+	}
+	ps := pow.NewServer(jjm.NewGenericBlindingServer(privkey, pubkey, c), []byte("pow key"))
+	ps.MinBits = 6
+	ts := httptest.NewServer(NewHandler(ps, pubkey, genericblinding.NewMemoryParamStore(time.Minute)))
+	defer ts.Close()
+	client := NewClient(ts.URL, jjm.SchemeName, pubkey)
+	roundTrip(t, client, jjm.NewGenericBlindingClient(pubkey, c))
+
+	client.MaxPoWBits = 5
+	_, _, err = client.GetParams()
+	if err != ErrPoWTooHard {
+		t.Errorf("GetParams must refuse hard challenges: %v", err)
+	}
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+ParamsPath, nil)
+	req.Header.Set(PoWSolutionHeader, "malformed")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Malformed solution must be reported as 403: %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,166 @@
+package blindhttp
+
+import (
+	"context"
+	"encoding/asn1"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+	"github.com/ronperry/cryptoedge/pow"
+	"github.com/ronperry/cryptoedge/quota"
+)
+
+// Error codes of error responses
+const (
+	CodeMalformed        = "malformed"
+	CodeBadScheme        = "bad_scheme"
+	CodeBadType          = "bad_type"
+	CodeBadSigner        = "bad_signer"
+	CodeBadMessage       = "bad_message"
+	CodeParamUnknown     = "param_unknown"
+	CodeParamExpired     = "param_expired"
+	CodeParamUsed        = "param_used"
+	CodeTooLarge         = "too_large"
+	CodeUnsupportedMedia = "unsupported_media_type"
+	CodeNotAcceptable    = "not_acceptable"
+	CodeMethod           = "method_not_allowed"
+	CodeNotFound         = "not_found"
+	CodeUnavailable      = "unavailable"
+	CodeTokenRequired    = "token_required"
+	CodeInvalidRequest   = "invalid_request" // Also the error of the WWW-Authenticate challenge
+	CodeInvalidToken     = "invalid_token"   // Also the error of the WWW-Authenticate challenge
+	CodeTokenSpent       = "token_spent"
+	CodeQuotaExceeded    = "quota_exceeded"
+	CodePoWRequired      = "pow_required"
+	CodePoWInvalid       = "pow_invalid"
+	CodeInternal         = "internal"
+)
+
+var (
+	// ErrMissingParamID is returned if a sign request does not carry a parameter ID
+	ErrMissingParamID = errors.New("blindhttp: Missing or malformed parameter ID")
+	// ErrTooLarge is returned if a request body exceeds the size limit
+	ErrTooLarge = errors.New("blindhttp: Request body too large")
+	// ErrUnsupportedMedia is returned for request bodies that are neither DER nor JSON
+	ErrUnsupportedMedia = errors.New("blindhttp: Unsupported content type")
+	// ErrNotAcceptable is returned if the client accepts neither DER nor JSON
+	ErrNotAcceptable = errors.New("blindhttp: No acceptable content type")
+	// ErrMethod is returned for requests with a method other than POST
+	ErrMethod = errors.New("blindhttp: Method not allowed")
+	// ErrNotFound is returned for unknown paths
+	ErrNotFound = errors.New("blindhttp: Not found")
+	// ErrInternal is reported to clients instead of errors that are not mapped to a code
+	ErrInternal = errors.New("blindhttp: Internal server error")
+)
+
+// Error is the JSON body of all error responses
+type Error struct {
+	Status    int    `json:"-"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Challenge string `json:"challenge,omitempty"` // pow.Challenge to solve before the request is repeated
+}
+
+// Error returns the message of the response
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the error that Code stands for, or nil for unknown codes
+func (e *Error) Unwrap() error {
+	for _, m := range errorMap {
+		if m.code == e.Code {
+			return m.err
+		}
+	}
+	return nil
+}
+
+// errorMap maps errors to HTTP status and code. The first entry of each code is the error that Unwrap returns
+var errorMap = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{genericblinding.ErrBadScheme, http.StatusBadRequest, CodeBadScheme},
+	{genericblinding.ErrUnknownScheme, http.StatusBadRequest, CodeBadScheme},
+	{genericblinding.ErrBadType, http.StatusBadRequest, CodeBadType},
+	{genericblinding.ErrUnknownType, http.StatusBadRequest, CodeBadType},
+	{genericblinding.ErrBadSigner, http.StatusBadRequest, CodeBadSigner},
+	{ErrMissingParamID, http.StatusBadRequest, CodeMalformed},
+	{genericblinding.ErrJSONField, http.StatusBadRequest, CodeMalformed},
+	{genericblinding.ErrEnvelopeVersion, http.StatusBadRequest, CodeMalformed},
+	{genericblinding.ErrBadEnvelope, http.StatusBadRequest, CodeMalformed},
+	{eccutil.ErrBadBlindParam, http.StatusUnprocessableEntity, CodeBadMessage},
+	{eccutil.ErrBadCoordinate, http.StatusUnprocessableEntity, CodeBadMessage},
+	{eccutil.ErrCoordinateBase, http.StatusUnprocessableEntity, CodeBadMessage},
+	{eccutil.ErrNotRelPrime, http.StatusUnprocessableEntity, CodeBadMessage},
+	{genericblinding.ErrParamUnknown, http.StatusNotFound, CodeParamUnknown},
+	{genericblinding.ErrParamExpired, http.StatusGone, CodeParamExpired},
+	{genericblinding.ErrParamUsed, http.StatusConflict, CodeParamUsed},
+	{eccutil.ErrParamReuse, http.StatusConflict, CodeParamUsed},
+	{ErrTooLarge, http.StatusRequestEntityTooLarge, CodeTooLarge},
+	{ErrUnsupportedMedia, http.StatusUnsupportedMediaType, CodeUnsupportedMedia},
+	{ErrNotAcceptable, http.StatusNotAcceptable, CodeNotAcceptable},
+	{ErrMethod, http.StatusMethodNotAllowed, CodeMethod},
+	{ErrNotFound, http.StatusNotFound, CodeNotFound},
+	{ErrNoToken, http.StatusUnauthorized, CodeTokenRequired},
+	{ErrMalformedToken, http.StatusBadRequest, CodeInvalidRequest},
+	{ErrInvalidToken, http.StatusUnauthorized, CodeInvalidToken},
+	{genericblinding.ErrSpent, http.StatusUnauthorized, CodeTokenSpent},
+	{quota.ErrQuotaExceeded, http.StatusTooManyRequests, CodeQuotaExceeded},
+	{pow.ErrSolutionRequired, http.StatusForbidden, CodePoWRequired},
+	{pow.ErrBadSolution, http.StatusForbidden, CodePoWInvalid},
+	{pow.ErrBadChallenge, http.StatusForbidden, CodePoWInvalid},
+	{pow.ErrChallengeExpired, http.StatusForbidden, CodePoWInvalid},
+	{pow.ErrSolutionUsed, http.StatusForbidden, CodePoWInvalid},
+	{eccutil.ErrMaxLoop, http.StatusServiceUnavailable, CodeUnavailable},
+	{context.Canceled, http.StatusServiceUnavailable, CodeUnavailable},
+	{context.DeadlineExceeded, http.StatusServiceUnavailable, CodeUnavailable},
+}
+
+// NewError returns the Error response for err. Errors that are not mapped to a code are reported as
+// ErrInternal, so no details of the server leak to clients. A *pow.ChallengeError passes on its challenge
+func NewError(err error) *Error {
+	for _, m := range errorMap {
+		if errors.Is(err, m.err) {
+			e := &Error{Status: m.status, Code: m.code, Message: m.err.Error()}
+			var challenge *pow.ChallengeError
+			if errors.As(err, &challenge) {
+				e.Challenge = challenge.Challenge.String()
+			}
+			return e
+		}
+	}
+	var maxBytes *http.MaxBytesError
+	if errors.As(err, &maxBytes) {
+		return &Error{Status: http.StatusRequestEntityTooLarge, Code: CodeTooLarge, Message: ErrTooLarge.Error()}
+	}
+	var structural asn1.StructuralError
+	var syntax asn1.SyntaxError
+	var jsonSyntax *json.SyntaxError
+	var jsonType *json.UnmarshalTypeError
+	if errors.As(err, &structural) || errors.As(err, &syntax) || errors.As(err, &jsonSyntax) || errors.As(err, &jsonType) {
+		return &Error{Status: http.StatusBadRequest, Code: CodeMalformed, Message: err.Error()}
+	}
+	return &Error{Status: http.StatusInternalServerError, Code: CodeInternal, Message: ErrInternal.Error()}
+}
+
+// writeError writes the Error response for err. Exceeded quotas set Retry-After
+func writeError(w http.ResponseWriter, err error) {
+	e := NewError(err)
+	b, _ := json.Marshal(e)
+	var exceeded *quota.ExceededError
+	if errors.As(err, &exceeded) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(exceeded.RetryAfter.Seconds()))))
+	}
+	w.Header().Set("Content-Type", ContentTypeJSON)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(e.Status)
+	w.Write(b)
+}
@@ -0,0 +1,249 @@
+// Package blindhttp exposes a genericblinding.BlindingServer over HTTP.
+//
+// The Handler serves two endpoints, both POST only:
+//
+//	/params	returns a BlindingParamClient. The ID of the matching BlindingParamServer is returned in the
+//		Blind-Param-ID header. The BlindingParamServer itself is kept in a ParamStore and never leaves the server
+//	/sign	signs the BlindMessage in the request body with the parameters named by the Blind-Param-ID header
+//		and returns the BlindSignature
+//
+// Bodies are either ASN.1 DER (ContentTypeDER) as produced by BlindingData.Marshal or JSON (ContentTypeJSON) as
+// produced by genericblinding.MarshalJSON. The request body of /sign is decoded according to its Content-Type,
+// the response body is encoded according to the Accept header of the request. Error responses are always JSON
+// encoded Error values.
+//
+// A sign request whose response was lost can be repeated: The Handler remembers the signatures it issued for
+// ReplayTTL and returns the same signature for the same parameter ID and BlindMessage. Client implements
+// genericblinding.BlindingServer over these endpoints.
+//
+// If the server is a pow.Server, a params request without valid solution fails with an Error that carries a
+// challenge. The request is repeated with its solution in the Blind-PoW-Solution header. Client does this
+// automatically.
+//
+// Authenticator is the redeeming side: its Middleware admits each request that carries an unspent signature of
+// the signer in a BlindToken Authorization header exactly once.
+package blindhttp
+
+import (
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+	"github.com/ronperry/cryptoedge/pow"
+)
+
+// Content types of request and response bodies
+const (
+	ContentTypeDER  = "application/x-blinding+der"
+	ContentTypeJSON = "application/json"
+)
+
+// Paths of the endpoints, relative to the root of the Handler
+const (
+	ParamsPath = "/params"
+	SignPath   = "/sign"
+)
+
+// ParamIDHeader carries the hex encoded ID of the BlindingParamServer
+const ParamIDHeader = "Blind-Param-ID"
+
+// PoWSolutionHeader carries the pow.Solution of a params request
+const PoWSolutionHeader = "Blind-PoW-Solution"
+
+// DefaultMaxBodySize is the default limit for request bodies
+const DefaultMaxBodySize = 64 << 10
+
+// DefaultReplayTTL is the default time a signature can be fetched again by a repeated sign request
+const DefaultReplayTTL = 5 * time.Minute
+
+// Handler serves GetParams and Sign of a BlindingServer over HTTP. Use http.StripPrefix to mount it below a
+// path. Handler does not expire parameters, call ParamStore.Expire periodically
+type Handler struct {
+	server      *genericblinding.StoredServer
+	pubKey      *eccutil.Point
+	replays     *replayCache
+	MaxBodySize int64         // Limit for request bodies, DefaultMaxBodySize if 0
+	ReplayTTL   time.Duration // Time a signature is kept for repeated sign requests, DefaultReplayTTL if 0
+}
+
+// NewHandler returns a Handler for server, the signer of pubKey, that keeps pending parameters in store
+func NewHandler(server genericblinding.BlindingServer, pubKey *eccutil.Point, store genericblinding.ParamStore) *Handler {
+	h := new(Handler)
+	h.server = genericblinding.NewStoredServer(server, store)
+	h.pubKey = pubKey
+	h.replays = newReplayCache()
+	return h
+}
+
+// ServeHTTP dispatches to the endpoints
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != ParamsPath && r.URL.Path != SignPath {
+		writeError(w, ErrNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, ErrMethod)
+		return
+	}
+	contentType, err := negotiate(r.Header.Get("Accept"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if r.URL.Path == ParamsPath {
+		h.params(w, r, contentType)
+		return
+	}
+	h.sign(w, r, contentType)
+}
+
+// params serves GetParams. A solution in the request is passed on to a pow.Server through the context
+func (h *Handler) params(w http.ResponseWriter, r *http.Request, contentType string) {
+	ctx := r.Context()
+	if header := r.Header.Get(PoWSolutionHeader); header != "" {
+		solution, err := pow.ParseSolution(header)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		ctx = pow.WithSolution(ctx, solution)
+	}
+	id, bpc, err := h.server.GetParamsContext(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set(ParamIDHeader, hex.EncodeToString(id))
+	writeData(w, bpc, contentType)
+}
+
+// sign serves Sign
+func (h *Handler) sign(w http.ResponseWriter, r *http.Request, contentType string) {
+	id, err := hex.DecodeString(r.Header.Get(ParamIDHeader))
+	if err != nil || len(id) == 0 {
+		writeError(w, ErrMissingParamID)
+		return
+	}
+	bm, err := h.readData(w, r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	// Check before the parameters are taken from the store
+	if _, dataType, _ := bm.SchemeData(); dataType != genericblinding.TypeBlindMessage {
+		writeError(w, genericblinding.ErrBadType)
+		return
+	}
+	bs, err := h.server.SignContext(r.Context(), id, bm)
+	if errors.Is(err, genericblinding.ErrParamUsed) {
+		if replay := h.replays.get(id, bm); replay != nil {
+			bs, err = replay, nil
+		}
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	ttl := h.ReplayTTL
+	if ttl == 0 {
+		ttl = DefaultReplayTTL
+	}
+	h.replays.put(id, bm, bs, ttl)
+	writeData(w, bs, contentType)
+}
+
+// readData decodes the BlindingData in the body of r
+func (h *Handler) readData(w http.ResponseWriter, r *http.Request) (genericblinding.BlindingData, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (mediaType != ContentTypeDER && mediaType != ContentTypeJSON) {
+		return nil, ErrUnsupportedMedia
+	}
+	limit := h.MaxBodySize
+	if limit == 0 {
+		limit = DefaultMaxBodySize
+	}
+	b, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil {
+		return nil, err
+	}
+	if mediaType == ContentTypeJSON {
+		return genericblinding.DecodeJSON(b, h.pubKey)
+	}
+	return genericblinding.Decode(b, h.pubKey)
+}
+
+// writeData writes bd encoded as contentType
+func writeData(w http.ResponseWriter, bd genericblinding.BlindingData, contentType string) {
+	var b []byte
+	var err error
+	if contentType == ContentTypeJSON {
+		b, err = genericblinding.MarshalJSON(bd)
+	} else {
+		b, err = bd.Marshal()
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(b)))
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(b)
+}
+
+// negotiate returns the content type of the response for the Accept header accept. Each content type gets the
+// quality of the most specific media range that matches it, a quality of 0 means not acceptable. DER is
+// preferred if the client accepts both equally
+func negotiate(accept string) (string, error) {
+	if accept == "" {
+		return ContentTypeDER, nil
+	}
+	candidates := []string{ContentTypeDER, ContentTypeJSON}
+	quality := make([]float64, len(candidates))
+	specificity := make([]int, len(candidates)) // 0 unmatched, 1 */*, 2 application/*, 3 exact
+	for _, a := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(a))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if qs, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(qs, 64)
+			if err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		for i, contentType := range candidates {
+			level := 0
+			switch mediaType {
+			case contentType:
+				level = 3
+			case "application/*":
+				level = 2
+			case "*/*":
+				level = 1
+			}
+			if level > specificity[i] {
+				quality[i], specificity[i] = q, level
+			}
+		}
+	}
+	best, bestQ := "", 0.0
+	for i, contentType := range candidates {
+		if quality[i] > bestQ {
+			best, bestQ = contentType, quality[i]
+		}
+	}
+	if best == "" {
+		return "", ErrNotAcceptable
+	}
+	return best, nil
+}
@@ -0,0 +1,250 @@
+package blindhttp
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+	"github.com/ronperry/cryptoedge/jjm"
+)
+
+type testServer struct {
+	*httptest.Server
+	client genericblinding.BlindingClient
+	pubKey *eccutil.Point
+}
+
+func newTestServer(t *testing.T) *testServer {
+	c := eccutil.SetCurve(elliptic.P256, rand.Reader, eccutil.Sha1Hash)
+	privkey, pubkey, err := c.GenerateKey()
+	if err != nil {
+		t.Fatalf("Error creating keys: %s", err)
+	}
+	h := NewHandler(jjm.NewGenericBlindingServer(privkey, pubkey, c), pubkey, genericblinding.NewMemoryParamStore(time.Minute))
+	h.MaxBodySize = 4096
+	ts := &testServer{Server: httptest.NewServer(h), client: jjm.NewGenericBlindingClient(pubkey, c), pubKey: pubkey}
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// post sends body to path and returns the response and its body
+func (ts *testServer) post(t *testing.T, path string, header map[string]string, body []byte) (*http.Response, []byte) {
+	req, err := http.NewRequest(http.MethodPost, ts.URL+path, bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("NewRequest: %s", err)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Reading response failed: %s", err)
+	}
+	return resp, b
+}
+
+// expectError checks that the response is an Error with status and code
+func expectError(t *testing.T, name string, resp *http.Response, body []byte, status int, code string) {
+	e := new(Error)
+	err := json.Unmarshal(body, e)
+	if err != nil || resp.StatusCode != status || e.Code != code {
+		t.Errorf("%s: expected %d %s, got %d %s", name, status, code, resp.StatusCode, body)
+	}
+}
+
+// blind gets parameters in contentType and returns the parameter ID, blinding factors and blind message
+func (ts *testServer) blind(t *testing.T, contentType string) (string, genericblinding.BlindingFactors, genericblinding.BlindMessage) {
+	resp, b := ts.post(t, ParamsPath, map[string]string{"Accept": contentType}, nil)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != contentType {
+		t.Fatalf("GetParams failed: %d %s %s", resp.StatusCode, resp.Header.Get("Content-Type"), b)
+	}
+	var bpc genericblinding.BlindingData
+	var err error
+	if contentType == ContentTypeJSON {
+		bpc, err = genericblinding.DecodeJSON(b, ts.pubKey)
+	} else {
+		bpc, err = genericblinding.Decode(b, ts.pubKey)
+	}
+	if err != nil {
+		t.Fatalf("Decoding params failed: %s", err)
+	}
+	bf, bm, err := ts.client.Blind(bpc, jjm.NewClearMessage([]byte("Message over HTTP")))
+	if err != nil {
+		t.Fatalf("Blind failed: %s", err)
+	}
+	return resp.Header.Get(ParamIDHeader), bf, bm
+}
+
+func Test_Handler(t *testing.T) {
+	ts := newTestServer(t)
+	for _, contentType := range []string{ContentTypeDER, ContentTypeJSON} {
+		id, bf, bm := ts.blind(t, contentType)
+		var body []byte
+		var err error
+		if contentType == ContentTypeJSON {
+			body, err = genericblinding.MarshalJSON(bm)
+		} else {
+			body, err = bm.Marshal()
+		}
+		if err != nil {
+			t.Fatalf("Marshal failed: %s", err)
+		}
+		header := map[string]string{"Content-Type": contentType, "Accept": contentType, ParamIDHeader: id}
+		resp, b := ts.post(t, SignPath, header, body)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Sign failed: %d %s", resp.StatusCode, b)
+		}
+		var bs genericblinding.BlindingData
+		if contentType == ContentTypeJSON {
+			bs, err = genericblinding.DecodeJSON(b, ts.pubKey)
+		} else {
+			bs, err = genericblinding.Decode(b, ts.pubKey)
+		}
+		if err != nil {
+			t.Fatalf("Decoding signature failed: %s", err)
+		}
+		cs, cm, err := ts.client.Unblind(bf, jjm.NewClearMessage([]byte("Message over HTTP")), bs)
+		if err != nil {
+			t.Fatalf("Unblind failed: %s", err)
+		}
+		ok, err := ts.client.Verify(cs, cm)
+		if !ok || err != nil {
+			t.Errorf("Signature over HTTP does not verify: %v", err)
+		}
+
+		// A repeated request gets the same signature, a different message none
+		resp, b2 := ts.post(t, SignPath, header, body)
+		if resp.StatusCode != http.StatusOK || !bytes.Equal(b, b2) {
+			t.Errorf("Repeated sign request not answered with same signature: %d %s", resp.StatusCode, b2)
+		}
+		_, _, other := ts.blind(t, ContentTypeDER)
+		body, err = other.Marshal()
+		if err != nil {
+			t.Fatalf("Marshal failed: %s", err)
+		}
+		header["Content-Type"] = ContentTypeDER
+		resp, b = ts.post(t, SignPath, header, body)
+		expectError(t, "Reused parameters", resp, b, http.StatusConflict, CodeParamUsed)
+	}
+}
+
+func Test_HandlerErrors(t *testing.T) {
+	ts := newTestServer(t)
+	id, _, bm := ts.blind(t, ContentTypeDER)
+	body, err := bm.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal failed: %s", err)
+	}
+	unknown := make([]byte, genericblinding.ParamIDSize)
+	header := func(contentType, id string) map[string]string {
+		return map[string]string{"Content-Type": contentType, ParamIDHeader: id}
+	}
+
+	resp, b := ts.post(t, SignPath, header(ContentTypeDER, ""), body)
+	expectError(t, "Missing ID", resp, b, http.StatusBadRequest, CodeMalformed)
+	resp, b = ts.post(t, SignPath, header(ContentTypeDER, hex.EncodeToString(unknown)), body)
+	expectError(t, "Unknown ID", resp, b, http.StatusNotFound, CodeParamUnknown)
+	resp, b = ts.post(t, SignPath, header("text/plain", id), body)
+	expectError(t, "Content type", resp, b, http.StatusUnsupportedMediaType, CodeUnsupportedMedia)
+	resp, b = ts.post(t, SignPath, header(ContentTypeDER, id), make([]byte, 8192))
+	expectError(t, "Body size", resp, b, http.StatusRequestEntityTooLarge, CodeTooLarge)
+	resp, b = ts.post(t, SignPath, header(ContentTypeDER, id), []byte{0x30, 0x03, 0x02})
+	expectError(t, "Malformed body", resp, b, http.StatusBadRequest, CodeMalformed)
+	resp, b = ts.post(t, SignPath, header(ContentTypeJSON, id), []byte(`{"SchemeName":"XXX","DataType":4}`))
+	expectError(t, "Unknown scheme", resp, b, http.StatusBadRequest, CodeBadScheme)
+	resp, b = ts.post(t, ParamsPath, map[string]string{"Accept": "text/html"}, nil)
+	expectError(t, "Accept", resp, b, http.StatusNotAcceptable, CodeNotAcceptable)
+	resp, b = ts.post(t, "/other", nil, nil)
+	expectError(t, "Path", resp, b, http.StatusNotFound, CodeNotFound)
+
+	resp, err = http.Get(ts.URL + ParamsPath)
+	if err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed || resp.Header.Get("Allow") != http.MethodPost {
+		t.Errorf("GET must fail with 405: %d", resp.StatusCode)
+	}
+
+	// None of the failed requests consumed the parameters
+	resp, b = ts.post(t, SignPath, header(ContentTypeDER, id), body)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Sign failed after failed requests: %d %s", resp.StatusCode, b)
+	}
+}
+
+func Test_Negotiate(t *testing.T) {
+	for accept, expect := range map[string]string{
+		"":                            ContentTypeDER,
+		"*/*":                         ContentTypeDER,
+		ContentTypeJSON:               ContentTypeJSON,
+		"application/json, */*;q=0.1": ContentTypeJSON,
+		"application/json;q=0.5, " + ContentTypeDER: ContentTypeDER,
+		"text/html, application/json;q=0.9":         ContentTypeJSON,
+		"text/html":                                 "",
+		ContentTypeJSON + ";q=0":                    "",
+		"*/*;q=0":                                   "",
+		ContentTypeDER + ";q=0, */*":                ContentTypeJSON,
+		ContentTypeJSON + ";q=0, application/*":     ContentTypeDER,
+		"application/*;q=0.5, " + ContentTypeJSON:   ContentTypeJSON,
+		ContentTypeJSON + ";q=2":                    "",
+	} {
+		contentType, err := negotiate(accept)
+		if contentType != expect || (expect == "") != (err == ErrNotAcceptable) {
+			t.Errorf("negotiate(%q) = %q, %v, expected %q", accept, contentType, err, expect)
+		}
+	}
+}
+
+func Test_NewError(t *testing.T) {
+	e := NewError(io.ErrUnexpectedEOF)
+	if e.Status != http.StatusInternalServerError || e.Message != ErrInternal.Error() {
+		t.Errorf("Unmapped error leaked: %v", e)
+	}
+	e = NewError(eccutil.ErrParamReuse)
+	if e.Code != CodeParamUsed || e.Unwrap() != genericblinding.ErrParamUsed {
+		t.Errorf("Wrong mapping of ErrParamReuse: %v", e)
+	}
+}
+
+func Test_ReplayCache(t *testing.T) {
+	now := time.Now()
+	rc := newReplayCache()
+	rc.now = func() time.Time { return now }
+	pubKey := new(eccutil.Point)
+	bm := jjm.NewBlindMessage(pubKey)
+	bm.M1, bm.M2 = big.NewInt(1), big.NewInt(2)
+	other := bm
+	other.M1 = big.NewInt(3)
+	bs := jjm.NewBlindSignature(pubKey)
+	for i := 0; i < 3; i++ {
+		rc.put([]byte{byte(i)}, bm, bs, time.Minute)
+		now = now.Add(time.Second)
+	}
+	if rc.get([]byte{0}, bm) == nil {
+		t.Error("Signature must be replayed")
+	}
+	if rc.get([]byte{0}, other) != nil {
+		t.Error("Signature must not be replayed for another message")
+	}
+	now = now.Add(time.Minute - time.Second)
+	rc.put([]byte{3}, bm, bs, time.Minute)
+	if len(rc.replays) != 2 || len(rc.queue) != 2 || rc.get([]byte{0}, bm) != nil || rc.get([]byte{2}, bm) == nil {
+		t.Errorf("Expired replays must be removed: %d replays, %d queued", len(rc.replays), len(rc.queue))
+	}
+}
@@ -0,0 +1,60 @@
+package blindhttp
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/ronperry/cryptoedge/genericblinding"
+)
+
+// replay is a signature issued for a parameter ID
+type replay struct {
+	message   []byte // UniqueID of the signed BlindMessage
+	signature genericblinding.BlindSignature
+	expires   time.Time
+}
+
+// replayCache keeps issued signatures by parameter ID so that repeated sign requests get the same answer
+type replayCache struct {
+	lock    sync.Mutex
+	replays map[string]replay
+	queue   []string // IDs in the order of put, and so of expiry as long as the ttl does not change
+	now     func() time.Time
+}
+
+func newReplayCache() *replayCache {
+	rc := new(replayCache)
+	rc.replays = make(map[string]replay)
+	rc.now = time.Now
+	return rc
+}
+
+// put remembers bs as signature of bm with the parameters id for ttl and removes expired entries from the
+// front of the queue
+func (rc *replayCache) put(id []byte, bm genericblinding.BlindMessage, bs genericblinding.BlindSignature, ttl time.Duration) {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+	now := rc.now()
+	n := 0
+	for ; n < len(rc.queue) && now.After(rc.replays[rc.queue[n]].expires); n++ {
+		delete(rc.replays, rc.queue[n])
+	}
+	rc.queue = rc.queue[n:]
+	if _, ok := rc.replays[string(id)]; !ok {
+		rc.replays[string(id)] = replay{message: bm.UniqueID(), signature: bs, expires: now.Add(ttl)}
+		rc.queue = append(rc.queue, string(id))
+	}
+}
+
+// get returns the signature issued for bm with the parameters id, or nil if there is none. A different
+// BlindMessage for the same parameters is never answered
+func (rc *replayCache) get(id []byte, bm genericblinding.BlindMessage) genericblinding.BlindSignature {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+	r, ok := rc.replays[string(id)]
+	if !ok || rc.now().After(r.expires) || !bytes.Equal(r.message, bm.UniqueID()) {
+		return nil
+	}
+	return r.signature
+}
@@ -0,0 +1,207 @@
+package blindnet
+
+import (
+	"context"
+	"encoding/asn1"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+)
+
+var (
+	// ErrClosed is returned for requests on a closed Client
+	ErrClosed = errors.New("blindnet: Connection closed")
+	// ErrResponse is returned if a response frame cannot be parsed
+	ErrResponse = errors.New("blindnet: Malformed response")
+)
+
+// Client is a genericblinding.BlindingServer that sends requests to a Server over one connection. Requests of
+// concurrent callers are multiplexed on the connection. All data returned by the server is checked to belong to
+// Scheme and PubKey
+type Client struct {
+	conn      net.Conn
+	Scheme    string
+	PubKey    *eccutil.Point
+	writeLock sync.Mutex
+	lock      sync.Mutex
+	pending   map[uint32]chan Frame
+	nextID    uint32
+	err       error // Set once the connection failed
+}
+
+// NewClient returns a Client on conn for the signer pubKey of scheme. The Client owns conn
+func NewClient(conn net.Conn, scheme string, pubKey *eccutil.Point) *Client {
+	c := new(Client)
+	c.conn = conn
+	c.Scheme = scheme
+	c.PubKey = pubKey
+	c.pending = make(map[uint32]chan Frame)
+	go c.readLoop()
+	return c
+}
+
+// Close closes the connection. Pending requests fail with ErrClosed
+func (c *Client) Close() error {
+	c.fail(ErrClosed)
+	return c.conn.Close()
+}
+
+// readLoop delivers response frames to the waiting requests until the connection fails
+func (c *Client) readLoop() {
+	for {
+		f, err := ReadFrame(c.conn, DefaultMaxFrameSize)
+		if err != nil {
+			c.fail(err)
+			return
+		}
+		c.lock.Lock()
+		ch, ok := c.pending[f.RequestID]
+		delete(c.pending, f.RequestID)
+		c.lock.Unlock()
+		if ok {
+			ch <- f
+		}
+	}
+}
+
+// fail marks the connection failed with err and wakes all pending requests
+func (c *Client) fail(err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.err == nil {
+		c.err = err
+	}
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+}
+
+// roundTrip sends a request frame and waits for the response of type respType
+func (c *Client) roundTrip(ctx context.Context, reqType FrameType, payload []byte, respType FrameType) ([]byte, error) {
+	ch := make(chan Frame, 1)
+	c.lock.Lock()
+	if c.err != nil {
+		err := c.err
+		c.lock.Unlock()
+		return nil, err
+	}
+	c.nextID++
+	id := c.nextID
+	c.pending[id] = ch
+	c.lock.Unlock()
+
+	c.writeLock.Lock()
+	err := WriteFrame(c.conn, Frame{Type: reqType, RequestID: id, Payload: payload})
+	c.writeLock.Unlock()
+	if err != nil {
+		c.fail(err)
+		return nil, err
+	}
+	select {
+	case <-ctx.Done():
+		c.lock.Lock()
+		delete(c.pending, id)
+		c.lock.Unlock()
+		return nil, ctx.Err()
+	case f, ok := <-ch:
+		if !ok {
+			c.lock.Lock()
+			err = c.err
+			c.lock.Unlock()
+			return nil, err
+		}
+		return c.response(f, respType)
+	}
+}
+
+// response returns the payload of f, which must be of respType, or the error of a FrameError
+func (c *Client) response(f Frame, respType FrameType) ([]byte, error) {
+	if f.Type == FrameError {
+		var ep ErrorPayload
+		_, err := asn1.Unmarshal(f.Payload, &ep)
+		if err != nil {
+			return nil, ErrResponse
+		}
+		return nil, &Error{Code: ep.Code, Message: ep.Message}
+	}
+	if f.Type != respType {
+		return nil, ErrFrameType
+	}
+	return f.Payload, nil
+}
+
+// decode unmarshals b and checks that it is dataType of the expected scheme and signer
+func (c *Client) decode(b []byte, dataType genericblinding.DataType) (genericblinding.BlindingData, error) {
+	bd, err := genericblinding.Decode(b, c.PubKey)
+	if err != nil {
+		return nil, err
+	}
+	_, err = genericblinding.MatchMessage(bd, c.Scheme, dataType, c.PubKey)
+	if err != nil {
+		return nil, err
+	}
+	return bd, nil
+}
+
+// GetParams fetches one-time parameters from the server. The BlindingParamServer is a genericblinding.ParamRef
+func (c *Client) GetParams() (genericblinding.BlindingParamClient, genericblinding.BlindingParamServer, error) {
+	return c.GetParamsContext(context.Background())
+}
+
+// GetParamsContext is GetParams with a context
+func (c *Client) GetParamsContext(ctx context.Context) (genericblinding.BlindingParamClient, genericblinding.BlindingParamServer, error) {
+	payload, err := c.roundTrip(ctx, FrameGetParams, nil, FrameParams)
+	if err != nil {
+		return nil, nil, err
+	}
+	var pp ParamsPayload
+	_, err = asn1.Unmarshal(payload, &pp)
+	if err != nil || len(pp.ID) == 0 {
+		return nil, nil, ErrResponse
+	}
+	bpc, err := c.decode(pp.Params, genericblinding.TypeBlindingParamClient)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bpc, genericblinding.NewParamRef(c.Scheme, c.PubKey, pp.ID), nil
+}
+
+// Sign requests a signature of bm with the parameters referenced by bpsi, a genericblinding.ParamRef returned
+// by GetParams
+func (c *Client) Sign(bpsi genericblinding.BlindingParamServer, bm genericblinding.BlindMessage) (genericblinding.BlindSignature, error) {
+	return c.SignContext(context.Background(), bpsi, bm)
+}
+
+// SignContext is Sign with a context. If ctx is done before the response arrives, the server may still have
+// used the parameters
+func (c *Client) SignContext(ctx context.Context, bpsi genericblinding.BlindingParamServer, bm genericblinding.BlindMessage) (genericblinding.BlindSignature, error) {
+	_, err := genericblinding.MatchMessage(bpsi, c.Scheme, genericblinding.TypeBlindingParamServer, c.PubKey)
+	if err != nil {
+		return nil, err
+	}
+	ref, ok := bpsi.(genericblinding.ParamRef)
+	if !ok {
+		return nil, genericblinding.ErrBadType
+	}
+	_, err = genericblinding.MatchMessage(bm, c.Scheme, genericblinding.TypeBlindMessage, c.PubKey)
+	if err != nil {
+		return nil, err
+	}
+	message, err := bm.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	payload, err := asn1.Marshal(SignPayload{ID: ref.ID, Message: message})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.roundTrip(ctx, FrameSign, payload, FrameSignature)
+	if err != nil {
+		return nil, err
+	}
+	return c.decode(resp, genericblinding.TypeBlindSignature)
+}
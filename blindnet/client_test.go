@@ -0,0 +1,222 @@
+package blindnet
+
+import (
+	"context"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+	"github.com/ronperry/cryptoedge/singhdas"
+)
+
+// newTestServer returns a Server, a BlindingClient for its signer and the signer's public key
+func newTestServer(t *testing.T) (*Server, genericblinding.BlindingClient, *eccutil.Point) {
+	c := eccutil.SetCurve(elliptic.P256, rand.Reader, eccutil.Sha1Hash)
+	privkey, pubkey, err := c.GenerateKey()
+	if err != nil {
+		t.Fatalf("Error creating keys: %s", err)
+	}
+	s := NewServer(singhdas.NewGenericBlindingServer(privkey, pubkey, c), pubkey, genericblinding.NewMemoryParamStore(time.Minute))
+	return s, singhdas.NewGenericBlindingClient(pubkey, c), pubkey
+}
+
+// roundTrip issues and verifies a signature through server
+func roundTrip(server genericblinding.BlindingServer, client genericblinding.BlindingClient, msg []byte) error {
+	cm := singhdas.NewClearMessage(msg)
+	bpc, bps, err := server.GetParams()
+	if err != nil {
+		return err
+	}
+	bf, bm, err := client.Blind(bpc, cm)
+	if err != nil {
+		return err
+	}
+	bs, err := server.Sign(bps, bm)
+	if err != nil {
+		return err
+	}
+	cs, cmOut, err := client.Unblind(bf, cm, bs)
+	if err != nil {
+		return err
+	}
+	ok, err := client.Verify(cs, cmOut)
+	if !ok && err == nil {
+		err = genericblinding.ErrBadSignature
+	}
+	return err
+}
+
+// testConcurrent runs parallel round trips over one Client
+func testConcurrent(t *testing.T, c *Client, client genericblinding.BlindingClient) {
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = roundTrip(c, client, []byte{byte(i)})
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("Round trip %d failed: %s", i, err)
+		}
+	}
+}
+
+func Test_Pipe(t *testing.T) {
+	s, client, pubKey := newTestServer(t)
+	cconn, sconn := net.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- s.ServeConn(sconn)
+	}()
+	c := NewClient(cconn, singhdas.SchemeName, pubKey)
+	testConcurrent(t, c, client)
+	c.Close()
+	if err := <-done; err != nil {
+		t.Errorf("ServeConn failed: %s", err)
+	}
+	_, _, err := c.GetParams()
+	if err != ErrClosed {
+		t.Errorf("Request on closed client must fail with ErrClosed: %v", err)
+	}
+}
+
+func Test_Listeners(t *testing.T) {
+	s, client, pubKey := newTestServer(t)
+	for _, network := range []string{"tcp", "unix"} {
+		address := "127.0.0.1:0"
+		if network == "unix" {
+			address = filepath.Join(t.TempDir(), "blindnet.sock")
+		}
+		l, err := net.Listen(network, address)
+		if err != nil {
+			t.Fatalf("Listen on %s failed: %s", network, err)
+		}
+		go s.Serve(l)
+		conn, err := net.Dial(network, l.Addr().String())
+		if err != nil {
+			t.Fatalf("Dial %s failed: %s", network, err)
+		}
+		c := NewClient(conn, singhdas.SchemeName, pubKey)
+		testConcurrent(t, c, client)
+		c.Close()
+		l.Close()
+	}
+}
+
+func Test_ClientErrors(t *testing.T) {
+	s, client, pubKey := newTestServer(t)
+	cconn, sconn := net.Pipe()
+	go s.ServeConn(sconn)
+	c := NewClient(cconn, singhdas.SchemeName, pubKey)
+	defer c.Close()
+
+	bpc, _, err := c.GetParams()
+	if err != nil {
+		t.Fatalf("GetParams failed: %s", err)
+	}
+	_, bm, err := client.Blind(bpc, singhdas.NewClearMessage([]byte("Message")))
+	if err != nil {
+		t.Fatalf("Blind failed: %s", err)
+	}
+	_, err = c.Sign(genericblinding.NewParamRef(singhdas.SchemeName, pubKey, make([]byte, genericblinding.ParamIDSize)), bm)
+	if !errors.Is(err, genericblinding.ErrParamUnknown) {
+		t.Errorf("Sign with unknown parameters must fail with ErrParamUnknown: %v", err)
+	}
+	_, err = c.roundTrip(context.Background(), FrameType(99), nil, FrameSignature)
+	var e *Error
+	if !errors.As(err, &e) || e.Code != CodeBadFrame {
+		t.Errorf("Unknown frame type must fail with bad_frame: %v", err)
+	}
+	_, err = c.roundTrip(context.Background(), FrameSign, []byte{0x30, 0x01}, FrameSignature)
+	if !errors.As(err, &e) || e.Code != CodeMalformed {
+		t.Errorf("Malformed payload must fail with malformed: %v", err)
+	}
+
+	_, other, err := eccutil.SetCurve(elliptic.P256, rand.Reader, eccutil.Sha1Hash).GenerateKey()
+	if err != nil {
+		t.Fatalf("Error creating keys: %s", err)
+	}
+	fconn, sconn := net.Pipe()
+	go s.ServeConn(sconn)
+	foreign := NewClient(fconn, singhdas.SchemeName, other)
+	defer foreign.Close()
+	_, _, err = foreign.GetParams()
+	if err == nil {
+		t.Error("GetParams must fail for parameters of other signer")
+	}
+}
+
+// blockingServer is a BlindingServer whose GetParams waits for release
+type blockingServer struct {
+	genericblinding.BlindingServer
+	lock    sync.Mutex
+	running int
+	max     int
+	release chan struct{}
+}
+
+func (bs *blockingServer) GetParams() (genericblinding.BlindingParamClient, genericblinding.BlindingParamServer, error) {
+	bs.lock.Lock()
+	bs.running++
+	bs.max = max(bs.max, bs.running)
+	bs.lock.Unlock()
+	<-bs.release
+	bs.lock.Lock()
+	bs.running--
+	bs.lock.Unlock()
+	return bs.BlindingServer.GetParams()
+}
+
+func Test_MaxInFlight(t *testing.T) {
+	c := eccutil.SetCurve(elliptic.P256, rand.Reader, eccutil.Sha1Hash)
+	privkey, pubkey, err := c.GenerateKey()
+	if err != nil {
+		t.Fatalf("Error creating keys: %s", err)
+	}
+	bs := &blockingServer{BlindingServer: singhdas.NewGenericBlindingServer(privkey, pubkey, c), release: make(chan struct{})}
+	s := NewServer(bs, pubkey, genericblinding.NewMemoryParamStore(time.Minute))
+	s.MaxInFlight = 2
+	cconn, sconn := net.Pipe()
+	defer cconn.Close()
+	go s.ServeConn(sconn)
+
+	const n = 5
+	written := make(chan int, n)
+	go func() {
+		for i := 0; i < n; i++ {
+			if err := WriteFrame(cconn, Frame{Type: FrameGetParams, RequestID: uint32(i)}); err != nil {
+				return
+			}
+			written <- i
+		}
+	}()
+	// The server reads two frames, the third write blocks until a request is done
+	time.Sleep(50 * time.Millisecond)
+	if len(written) != 2 {
+		t.Errorf("Server read %d frames while at the limit", len(written))
+	}
+	close(bs.release)
+	for i := 0; i < n; i++ {
+		f, err := ReadFrame(cconn, DefaultMaxFrameSize)
+		if err != nil {
+			t.Fatalf("ReadFrame failed: %s", err)
+		}
+		if f.Type != FrameParams {
+			t.Errorf("Wrong response type %d", f.Type)
+		}
+	}
+	if bs.max != 2 {
+		t.Errorf("%d requests ran concurrently, limit is 2", bs.max)
+	}
+}
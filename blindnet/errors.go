@@ -0,0 +1,91 @@
+package blindnet
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+	"github.com/ronperry/cryptoedge/quota"
+)
+
+// Error codes of FrameError
+const (
+	CodeMalformed     = "malformed"
+	CodeBadScheme     = "bad_scheme"
+	CodeBadType       = "bad_type"
+	CodeBadSigner     = "bad_signer"
+	CodeBadMessage    = "bad_message"
+	CodeParamUnknown  = "param_unknown"
+	CodeParamExpired  = "param_expired"
+	CodeParamUsed     = "param_used"
+	CodeBadFrame      = "bad_frame"
+	CodeUnavailable   = "unavailable"
+	CodeQuotaExceeded = "quota_exceeded"
+	CodeInternal      = "internal"
+)
+
+// ErrInternal is reported to clients instead of errors that are not mapped to a code
+var ErrInternal = errors.New("blindnet: Internal server error")
+
+// Error is an error reported by the server in a FrameError
+type Error struct {
+	Code    string
+	Message string
+}
+
+// Error returns the message of the server
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the error that Code stands for, or nil for unknown codes
+func (e *Error) Unwrap() error {
+	for _, m := range errorMap {
+		if m.code == e.Code {
+			return m.err
+		}
+	}
+	return nil
+}
+
+// errorMap maps errors to codes. The first entry of each code is the error that Unwrap returns
+var errorMap = []struct {
+	err  error
+	code string
+}{
+	{genericblinding.ErrBadScheme, CodeBadScheme},
+	{genericblinding.ErrUnknownScheme, CodeBadScheme},
+	{genericblinding.ErrBadType, CodeBadType},
+	{genericblinding.ErrUnknownType, CodeBadType},
+	{genericblinding.ErrBadSigner, CodeBadSigner},
+	{genericblinding.ErrEnvelopeVersion, CodeMalformed},
+	{genericblinding.ErrBadEnvelope, CodeMalformed},
+	{eccutil.ErrBadBlindParam, CodeBadMessage},
+	{eccutil.ErrBadCoordinate, CodeBadMessage},
+	{eccutil.ErrCoordinateBase, CodeBadMessage},
+	{eccutil.ErrNotRelPrime, CodeBadMessage},
+	{genericblinding.ErrParamUnknown, CodeParamUnknown},
+	{genericblinding.ErrParamExpired, CodeParamExpired},
+	{genericblinding.ErrParamUsed, CodeParamUsed},
+	{eccutil.ErrParamReuse, CodeParamUsed},
+	{ErrFrameType, CodeBadFrame},
+	{quota.ErrQuotaExceeded, CodeQuotaExceeded},
+	{eccutil.ErrMaxLoop, CodeUnavailable},
+	{context.Canceled, CodeUnavailable},
+	{context.DeadlineExceeded, CodeUnavailable},
+}
+
+// newError returns the Error reported for err. Errors that are not mapped to a code are reported as
+// ErrInternal, so no details of the server leak to clients. Malformed payloads are reported as CodeMalformed
+func newError(err error, malformed bool) *Error {
+	for _, m := range errorMap {
+		if errors.Is(err, m.err) {
+			return &Error{Code: m.code, Message: m.err.Error()}
+		}
+	}
+	if malformed {
+		return &Error{Code: CodeMalformed, Message: err.Error()}
+	}
+	return &Error{Code: CodeInternal, Message: ErrInternal.Error()}
+}
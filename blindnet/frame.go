@@ -0,0 +1,104 @@
+// Package blindnet implements a framed binary protocol to issue blind signatures of any genericblinding scheme
+// over a stream connection like TCP, Unix sockets or net.Pipe.
+//
+// Each frame is
+//
+//	length    uint32, big endian. Size of the rest of the frame
+//	type      uint8, a FrameType
+//	requestID uint32, big endian. Chosen by the client, copied into the response
+//	payload   ASN.1 DER
+//
+// The client sends FrameGetParams and FrameSign requests and the server answers each with a FrameParams,
+// FrameSignature or FrameError of the same request ID. Requests are served concurrently, so responses can
+// arrive in any order. The secret BlindingParamServer stays in the ParamStore of the server, clients refer to
+// it by ID.
+package blindnet
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// FrameType identifies the content of a frame
+type FrameType uint8
+
+// Frame types
+const (
+	FrameGetParams FrameType = iota + 1 // Request for parameters, empty payload
+	FrameParams                         // Response to FrameGetParams, ParamsPayload
+	FrameSign                           // Request for a signature, SignPayload
+	FrameSignature                      // Response to FrameSign, marshalled BlindSignature
+	FrameError                          // Response to any request, ErrorPayload
+)
+
+// frameHeaderSize is the size of type and request ID
+const frameHeaderSize = 5
+
+// DefaultMaxFrameSize is the default limit for the size of a frame
+const DefaultMaxFrameSize = 64 << 10
+
+var (
+	// ErrFrameSize is returned if a frame exceeds the size limit or is too short
+	ErrFrameSize = errors.New("blindnet: Bad frame size")
+	// ErrFrameType is returned for frames of unexpected type
+	ErrFrameType = errors.New("blindnet: Unexpected frame type")
+)
+
+// Frame is a message of the protocol
+type Frame struct {
+	Type      FrameType
+	RequestID uint32
+	Payload   []byte
+}
+
+// ParamsPayload is the payload of FrameParams
+type ParamsPayload struct {
+	ID     []byte // ID of the BlindingParamServer in the ParamStore of the server
+	Params []byte // Marshalled BlindingParamClient
+}
+
+// SignPayload is the payload of FrameSign
+type SignPayload struct {
+	ID      []byte // ID returned in ParamsPayload
+	Message []byte // Marshalled BlindMessage
+}
+
+// ErrorPayload is the payload of FrameError
+type ErrorPayload struct {
+	Code    string
+	Message string
+}
+
+// WriteFrame writes f to w with a single Write
+func WriteFrame(w io.Writer, f Frame) error {
+	b := make([]byte, 4+frameHeaderSize+len(f.Payload))
+	binary.BigEndian.PutUint32(b, uint32(frameHeaderSize+len(f.Payload)))
+	b[4] = byte(f.Type)
+	binary.BigEndian.PutUint32(b[5:], f.RequestID)
+	copy(b[4+frameHeaderSize:], f.Payload)
+	_, err := w.Write(b)
+	return err
+}
+
+// ReadFrame reads a frame of at most maxSize bytes, not counting the length, from r
+func ReadFrame(r io.Reader, maxSize int) (Frame, error) {
+	var length [4]byte
+	_, err := io.ReadFull(r, length[:])
+	if err != nil {
+		return Frame{}, err
+	}
+	n := binary.BigEndian.Uint32(length[:])
+	if n < frameHeaderSize || uint64(n) > uint64(maxSize) {
+		return Frame{}, ErrFrameSize
+	}
+	b := make([]byte, n)
+	_, err = io.ReadFull(r, b)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return Frame{}, err
+	}
+	return Frame{Type: FrameType(b[0]), RequestID: binary.BigEndian.Uint32(b[1:]), Payload: b[frameHeaderSize:]}, nil
+}
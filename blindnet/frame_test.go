@@ -0,0 +1,35 @@
+package blindnet
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_Frame(t *testing.T) {
+	buf := new(bytes.Buffer)
+	f := Frame{Type: FrameSign, RequestID: 0x01020304, Payload: []byte("payload")}
+	err := WriteFrame(buf, f)
+	if err != nil {
+		t.Fatalf("WriteFrame failed: %s", err)
+	}
+	expect := []byte{0, 0, 0, 12, 3, 1, 2, 3, 4, 'p', 'a', 'y', 'l', 'o', 'a', 'd'}
+	if !bytes.Equal(buf.Bytes(), expect) {
+		t.Errorf("Unexpected encoding: %x", buf.Bytes())
+	}
+	f2, err := ReadFrame(bytes.NewReader(expect), 12)
+	if err != nil || f2.Type != f.Type || f2.RequestID != f.RequestID || !bytes.Equal(f2.Payload, f.Payload) {
+		t.Errorf("ReadFrame failed: %v %v", f2, err)
+	}
+	_, err = ReadFrame(bytes.NewReader(expect), 11)
+	if err != ErrFrameSize {
+		t.Errorf("Frame above limit must fail with ErrFrameSize: %v", err)
+	}
+	_, err = ReadFrame(bytes.NewReader([]byte{0, 0, 0, 4, 1, 0, 0, 0}), 12)
+	if err != ErrFrameSize {
+		t.Errorf("Short frame must fail with ErrFrameSize: %v", err)
+	}
+	_, err = ReadFrame(bytes.NewReader(expect[:10]), 12)
+	if err == nil {
+		t.Error("Truncated frame must fail")
+	}
+}
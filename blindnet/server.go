@@ -0,0 +1,157 @@
+package blindnet
+
+import (
+	"context"
+	"encoding/asn1"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+)
+
+// DefaultMaxInFlight is the default limit for concurrent requests per connection
+const DefaultMaxInFlight = 16
+
+// Server serves GetParams and Sign of a BlindingServer on stream connections. Server does not expire
+// parameters, call ParamStore.Expire periodically
+type Server struct {
+	server       *genericblinding.StoredServer
+	pubKey       *eccutil.Point
+	MaxFrameSize int // Limit for request frames, DefaultMaxFrameSize if 0
+	MaxInFlight  int // Limit for concurrent requests per connection, DefaultMaxInFlight if 0
+	// ConnContext, if not nil, returns the context of the requests on conn, for example one carrying the
+	// identity of the peer for quota.WithIdentity
+	ConnContext func(ctx context.Context, conn net.Conn) context.Context
+}
+
+// NewServer returns a Server for server, the signer of pubKey, that keeps pending parameters in store
+func NewServer(server genericblinding.BlindingServer, pubKey *eccutil.Point, store genericblinding.ParamStore) *Server {
+	s := new(Server)
+	s.server = genericblinding.NewStoredServer(server, store)
+	s.pubKey = pubKey
+	return s
+}
+
+// Serve accepts connections on l and serves each in its own goroutine. It returns the error of Accept
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.ServeConn(conn)
+	}
+}
+
+// ServeConn serves the requests on conn concurrently until the client closes it or sends a frame that cannot
+// be read. It closes conn. No further frames are read while MaxInFlight requests are running. Requests that are
+// still running when the client closed the connection are answered before ServeConn returns, requests running
+// after a protocol error are cancelled
+func (s *Server) ServeConn(conn net.Conn) error {
+	maxSize := s.MaxFrameSize
+	if maxSize == 0 {
+		maxSize = DefaultMaxFrameSize
+	}
+	maxInFlight := s.MaxInFlight
+	if maxInFlight == 0 {
+		maxInFlight = DefaultMaxInFlight
+	}
+	inFlight := make(chan struct{}, maxInFlight)
+	ctx := context.Background()
+	if s.ConnContext != nil {
+		ctx = s.ConnContext(ctx, conn)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var requests sync.WaitGroup
+	var writeLock sync.Mutex
+	for {
+		inFlight <- struct{}{}
+		f, err := ReadFrame(conn, maxSize)
+		if err == io.EOF {
+			requests.Wait()
+			return conn.Close()
+		}
+		if err != nil {
+			cancel()
+			conn.Close()
+			requests.Wait()
+			return err
+		}
+		requests.Add(1)
+		go func() {
+			defer requests.Done()
+			defer func() { <-inFlight }()
+			resp := s.handle(ctx, f)
+			writeLock.Lock()
+			defer writeLock.Unlock()
+			WriteFrame(conn, resp) // Errors show up in the read loop
+		}()
+	}
+}
+
+// handle serves one request frame and returns the response
+func (s *Server) handle(ctx context.Context, f Frame) Frame {
+	var payload []byte
+	var respType FrameType
+	var err error
+	malformed := false
+	switch f.Type {
+	case FrameGetParams:
+		respType = FrameParams
+		payload, err = s.getParams(ctx)
+	case FrameSign:
+		respType = FrameSignature
+		payload, malformed, err = s.sign(ctx, f.Payload)
+	default:
+		err = ErrFrameType
+	}
+	if err != nil {
+		e := newError(err, malformed)
+		respType = FrameError
+		payload, _ = asn1.Marshal(ErrorPayload{Code: e.Code, Message: e.Message})
+	}
+	return Frame{Type: respType, RequestID: f.RequestID, Payload: payload}
+}
+
+// getParams returns the payload of FrameParams
+func (s *Server) getParams(ctx context.Context) ([]byte, error) {
+	id, bpc, err := s.server.GetParamsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	b, err := bpc.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(ParamsPayload{ID: id, Params: b})
+}
+
+// sign returns the payload of FrameSignature for the payload of FrameSign. malformed is true if the error was
+// caused by the request
+func (s *Server) sign(ctx context.Context, payload []byte) (resp []byte, malformed bool, err error) {
+	var sp SignPayload
+	rest, err := asn1.Unmarshal(payload, &sp)
+	if err != nil {
+		return nil, true, err
+	}
+	if len(rest) > 0 {
+		return nil, true, asn1.SyntaxError{Msg: "trailing data"}
+	}
+	bm, err := genericblinding.Decode(sp.Message, s.pubKey)
+	if err != nil {
+		return nil, true, err
+	}
+	// Check before the parameters are taken from the store
+	if _, dataType, _ := bm.SchemeData(); dataType != genericblinding.TypeBlindMessage {
+		return nil, true, genericblinding.ErrBadType
+	}
+	bs, err := s.server.SignContext(ctx, sp.ID, bm)
+	if err != nil {
+		return nil, false, err
+	}
+	resp, err = bs.Marshal()
+	return resp, false, err
+}
@@ -0,0 +1,350 @@
+package blindrpc
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+)
+
+// DefaultCurve is the curve of keygen if none is given
+const DefaultCurve = "P-256"
+
+// Parameters and results of the methods
+
+type keygenParams struct {
+	Scheme string `json:"scheme"`
+	Curve  string `json:"curve"`
+}
+
+type keygenResult struct {
+	PrivateKey string `json:"privateKey"`
+	PublicKey  string `json:"publicKey"`
+}
+
+type getParamsParams struct {
+	Scheme     string `json:"scheme"`
+	PrivateKey string `json:"privateKey"`
+}
+
+type getParamsResult struct {
+	Params       []byte `json:"params"`
+	ServerParams []byte `json:"serverParams"`
+}
+
+type blindParams struct {
+	Scheme    string `json:"scheme"`
+	PublicKey string `json:"publicKey"`
+	Params    []byte `json:"params"`
+	Message   []byte `json:"message"`
+}
+
+type blindResult struct {
+	Factors      []byte `json:"factors"`
+	BlindMessage []byte `json:"blindMessage"`
+}
+
+type signParams struct {
+	Scheme       string `json:"scheme"`
+	PrivateKey   string `json:"privateKey"`
+	ServerParams []byte `json:"serverParams"`
+	BlindMessage []byte `json:"blindMessage"`
+}
+
+type signResult struct {
+	BlindSignature []byte `json:"blindSignature"`
+}
+
+type unblindParams struct {
+	Scheme         string `json:"scheme"`
+	PublicKey      string `json:"publicKey"`
+	Factors        []byte `json:"factors"`
+	Message        []byte `json:"message"`
+	BlindSignature []byte `json:"blindSignature"`
+}
+
+type unblindResult struct {
+	Signature    []byte `json:"signature"`
+	ClearMessage []byte `json:"clearMessage"`
+}
+
+type verifyParams struct {
+	Scheme       string `json:"scheme"`
+	PublicKey    string `json:"publicKey"`
+	Signature    []byte `json:"signature"`
+	ClearMessage []byte `json:"clearMessage"`
+}
+
+type verifyResult struct {
+	Valid bool `json:"valid"`
+}
+
+// invalidParams returns the Error reporting err as invalid parameters
+func invalidParams(err error) *Error {
+	return &Error{Code: CodeInvalidParams, Message: err.Error()}
+}
+
+// unmarshalParams decodes the parameters of a method into v
+func unmarshalParams(params json.RawMessage, v interface{}) error {
+	if len(params) == 0 {
+		return &Error{Code: CodeInvalidParams, Message: "missing params"}
+	}
+	err := json.Unmarshal(params, v)
+	if err != nil {
+		return invalidParams(err)
+	}
+	return nil
+}
+
+// call runs method with params
+func (s *Server) call(method string, params json.RawMessage) (interface{}, error) {
+	switch method {
+	case "keygen":
+		var p keygenParams
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		return keygen(p)
+	case "getParams":
+		var p getParamsParams
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		return s.getParams(p)
+	case "blind":
+		var p blindParams
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		return blind(p)
+	case "sign":
+		var p signParams
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		return s.sign(p)
+	case "unblind":
+		var p unblindParams
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		return unblind(p)
+	case "verify":
+		var p verifyParams
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		return verify(p)
+	}
+	return nil, &Error{Code: CodeMethodNotFound, Message: "method not found: " + method}
+}
+
+// newCurve returns the eccutil.Curve of curve as used by all methods
+func newCurve(curve func() elliptic.Curve) *eccutil.Curve {
+	return eccutil.SetCurve(curve, rand.Reader, eccutil.Sha1Hash)
+}
+
+// lookupFactory returns the Factory of scheme
+func lookupFactory(scheme string) (genericblinding.Factory, error) {
+	factory, err := genericblinding.LookupFactory(scheme)
+	if err != nil {
+		return factory, invalidParams(err)
+	}
+	return factory, nil
+}
+
+// loadServer returns the BlindingServer for the PEM encoded private key of scheme
+func (s *Server) loadServer(scheme, privateKey string) (genericblinding.BlindingServer, *eccutil.Point, error) {
+	factory, err := lookupFactory(scheme)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyScheme, priv, pubKey, curve, _, err := genericblinding.DecodePrivateKeyPEM([]byte(privateKey))
+	if err != nil {
+		return nil, nil, invalidParams(err)
+	}
+	if keyScheme != scheme {
+		return nil, nil, invalidParams(genericblinding.ErrBadScheme)
+	}
+	id := scheme + "/" + hex.EncodeToString(eccutil.KeyID(pubKey))
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	server, ok := s.servers[id]
+	if !ok {
+		server = factory.NewServer(priv, pubKey, newCurve(func() elliptic.Curve { return curve }))
+		s.servers[id] = server
+	}
+	return server, pubKey, nil
+}
+
+// loadClient returns the BlindingClient for the PEM encoded public key of scheme
+func loadClient(scheme, publicKey string) (genericblinding.Factory, genericblinding.BlindingClient, *eccutil.Point, error) {
+	factory, err := lookupFactory(scheme)
+	if err != nil {
+		return factory, nil, nil, err
+	}
+	pubKey, _, err := genericblinding.DecodePublicKeyPEM([]byte(publicKey))
+	if err != nil {
+		return factory, nil, nil, invalidParams(err)
+	}
+	curve := eccutil.CurveOfPoint(pubKey)
+	return factory, factory.NewClient(pubKey, newCurve(func() elliptic.Curve { return curve })), pubKey, nil
+}
+
+// decode unmarshals an artifact of the signer pubKey
+func decode(b []byte, pubKey *eccutil.Point) (genericblinding.BlindingData, error) {
+	bd, err := genericblinding.Decode(b, pubKey)
+	if err != nil {
+		return nil, invalidParams(err)
+	}
+	return bd, nil
+}
+
+func keygen(p keygenParams) (*keygenResult, error) {
+	if _, err := lookupFactory(p.Scheme); err != nil {
+		return nil, err
+	}
+	if p.Curve == "" {
+		p.Curve = DefaultCurve
+	}
+	curve, ok := eccutil.CurveByName(p.Curve)
+	if !ok {
+		return nil, &Error{Code: CodeInvalidParams, Message: "unknown curve: " + p.Curve}
+	}
+	priv, pubKey, err := newCurve(curve).GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	privPEM, err := genericblinding.EncodePrivateKeyPEM(p.Scheme, priv, pubKey)
+	if err != nil {
+		return nil, err
+	}
+	pubPEM, err := genericblinding.EncodePublicKeyPEM(pubKey)
+	if err != nil {
+		return nil, err
+	}
+	return &keygenResult{PrivateKey: string(privPEM), PublicKey: string(pubPEM)}, nil
+}
+
+func (s *Server) getParams(p getParamsParams) (*getParamsResult, error) {
+	server, _, err := s.loadServer(p.Scheme, p.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	bpc, bps, err := server.GetParams()
+	if err != nil {
+		return nil, err
+	}
+	r := new(getParamsResult)
+	r.Params, err = bpc.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	r.ServerParams, err = bps.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func blind(p blindParams) (*blindResult, error) {
+	factory, client, pubKey, err := loadClient(p.Scheme, p.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	bpc, err := decode(p.Params, pubKey)
+	if err != nil {
+		return nil, err
+	}
+	bf, bm, err := client.Blind(bpc, factory.NewClearMessage(p.Message))
+	if err != nil {
+		return nil, err
+	}
+	r := new(blindResult)
+	r.Factors, err = bf.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	r.BlindMessage, err = bm.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (s *Server) sign(p signParams) (*signResult, error) {
+	server, pubKey, err := s.loadServer(p.Scheme, p.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	bps, err := decode(p.ServerParams, pubKey)
+	if err != nil {
+		return nil, err
+	}
+	bm, err := decode(p.BlindMessage, pubKey)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := server.Sign(bps, bm)
+	if err != nil {
+		return nil, err
+	}
+	r := new(signResult)
+	r.BlindSignature, err = bs.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func unblind(p unblindParams) (*unblindResult, error) {
+	factory, client, pubKey, err := loadClient(p.Scheme, p.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	bf, err := decode(p.Factors, pubKey)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := decode(p.BlindSignature, pubKey)
+	if err != nil {
+		return nil, err
+	}
+	cs, cm, err := client.Unblind(bf, factory.NewClearMessage(p.Message), bs)
+	if err != nil {
+		return nil, err
+	}
+	r := new(unblindResult)
+	r.Signature, err = cs.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	r.ClearMessage, err = cm.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func verify(p verifyParams) (*verifyResult, error) {
+	_, client, pubKey, err := loadClient(p.Scheme, p.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	cs, err := decode(p.Signature, pubKey)
+	if err != nil {
+		return nil, err
+	}
+	cm, err := decode(p.ClearMessage, pubKey)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := client.Verify(cs, cm)
+	if err != nil {
+		return nil, err
+	}
+	return &verifyResult{Valid: ok}, nil
+}
@@ -0,0 +1,200 @@
+// Package blindrpc exposes the registered blind signature schemes as JSON-RPC 2.0 service, for programs that
+// are not written in Go. Requests and responses are JSON objects, one per line. Batches are supported.
+//
+// All artifacts are the Marshal encodings of the genericblinding types, carried as standard base64. Keys are
+// the PEM encodings of genericblinding.EncodePrivateKeyPEM and EncodePublicKeyPEM. The methods are
+//
+//	keygen     {scheme, curve}                                       -> {privateKey, publicKey}
+//	getParams  {scheme, privateKey}                                  -> {params, serverParams}
+//	blind      {scheme, publicKey, params, message}                  -> {factors, blindMessage}
+//	sign       {scheme, privateKey, serverParams, blindMessage}      -> {blindSignature}
+//	unblind    {scheme, publicKey, factors, message, blindSignature} -> {signature, clearMessage}
+//	verify     {scheme, publicKey, signature, clearMessage}          -> {valid}
+//
+// message is the raw message to be signed, clearMessage the ClearMessage returned by unblind. Curve is the
+// name of a named curve, "P-256" if empty. serverParams is the BlindingParamServer, it must not leave the
+// signer.
+package blindrpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/ronperry/cryptoedge/genericblinding"
+)
+
+// Version is the JSON-RPC version implemented
+const Version = "2.0"
+
+// MaxLineSize is the limit for the size of a request line
+const MaxLineSize = 1 << 20
+
+// Error codes of JSON-RPC
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	CodeServerError    = -32000 // Errors of the scheme, e.g. a reused parameter
+)
+
+// Error is a JSON-RPC error object
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error returns the message
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// request is a JSON-RPC request. ID is nil for notifications
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// response is a JSON-RPC response
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// Server answers JSON-RPC requests. It keeps one BlindingServer per private key, so that the scheme can
+// detect reused parameters across requests. Schemes only remember the parameters of their last
+// eccutil.DefaultUniqueTokens signatures, and forget them on restart and across Servers. Callers that need
+// lasting single use keep serverParams in a genericblinding.ParamStore until they sign
+type Server struct {
+	lock    sync.Mutex
+	servers map[string]genericblinding.BlindingServer
+}
+
+// NewServer returns a Server
+func NewServer() *Server {
+	s := new(Server)
+	s.servers = make(map[string]genericblinding.BlindingServer)
+	return s
+}
+
+// Serve reads requests from r and writes the responses to w. Requests are served concurrently, so responses
+// can be written in any order. When r reaches EOF, Serve waits for the pending requests and returns nil. A line
+// longer than MaxLineSize ends Serve with bufio.ErrTooLong
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 4096), MaxLineSize)
+	var requests sync.WaitGroup
+	var writeLock sync.Mutex
+	var writeErr error
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		line = append([]byte(nil), line...)
+		requests.Add(1)
+		go func() {
+			defer requests.Done()
+			resp := s.handleLine(line)
+			if resp == nil {
+				return
+			}
+			writeLock.Lock()
+			defer writeLock.Unlock()
+			if writeErr == nil {
+				_, writeErr = w.Write(append(resp, '\n'))
+			}
+		}()
+	}
+	requests.Wait()
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return writeErr
+}
+
+// handleLine answers a request or batch. It returns nil if there is nothing to answer
+func (s *Server) handleLine(line []byte) []byte {
+	if line[0] != '[' {
+		resp := s.handleRequest(line)
+		if resp == nil {
+			return nil
+		}
+		b, _ := json.Marshal(resp)
+		return b
+	}
+	var batch []json.RawMessage
+	err := json.Unmarshal(line, &batch)
+	if err != nil {
+		b, _ := json.Marshal(errorResponse(nil, &Error{Code: CodeParseError, Message: err.Error()}))
+		return b
+	}
+	if len(batch) == 0 {
+		b, _ := json.Marshal(errorResponse(nil, &Error{Code: CodeInvalidRequest, Message: "empty batch"}))
+		return b
+	}
+	resps := make([]*response, len(batch))
+	var wg sync.WaitGroup
+	for i, raw := range batch {
+		wg.Add(1)
+		go func(i int, raw []byte) {
+			defer wg.Done()
+			resps[i] = s.handleRequest(raw)
+		}(i, raw)
+	}
+	wg.Wait()
+	answered := resps[:0]
+	for _, resp := range resps {
+		if resp != nil {
+			answered = append(answered, resp)
+		}
+	}
+	if len(answered) == 0 {
+		return nil
+	}
+	b, _ := json.Marshal(answered)
+	return b
+}
+
+// errorResponse returns the response reporting err for the request id
+func errorResponse(id json.RawMessage, err *Error) *response {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &response{JSONRPC: Version, Error: err, ID: id}
+}
+
+// handleRequest answers a single request. It returns nil for notifications
+func (s *Server) handleRequest(b []byte) *response {
+	var req request
+	err := json.Unmarshal(b, &req)
+	if err != nil {
+		if _, ok := err.(*json.SyntaxError); ok {
+			return errorResponse(nil, &Error{Code: CodeParseError, Message: err.Error()})
+		}
+		return errorResponse(nil, &Error{Code: CodeInvalidRequest, Message: err.Error()})
+	}
+	if req.JSONRPC != Version || req.Method == "" {
+		return errorResponse(req.ID, &Error{Code: CodeInvalidRequest, Message: "not a JSON-RPC 2.0 request"})
+	}
+	result, err := s.call(req.Method, req.Params)
+	if req.ID == nil {
+		return nil
+	}
+	if err != nil {
+		e, ok := err.(*Error)
+		if !ok {
+			e = &Error{Code: CodeServerError, Message: err.Error()}
+		}
+		return errorResponse(req.ID, e)
+	}
+	return &response{JSONRPC: Version, Result: result, ID: req.ID}
+}
@@ -0,0 +1,169 @@
+package blindrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ronperry/cryptoedge/jcc"
+	"github.com/ronperry/cryptoedge/jjm"
+	"github.com/ronperry/cryptoedge/singhdas"
+)
+
+// testResponse is a response as received by a client
+type testResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result"`
+	Error   *Error          `json:"error"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// call sends a request for method to s and decodes the result into result
+func call(t *testing.T, s *Server, method string, params interface{}, result interface{}) *Error {
+	p, err := json.Marshal(params)
+	if err != nil {
+		t.Fatalf("Marshalling params failed: %s", err)
+	}
+	req, err := json.Marshal(request{JSONRPC: Version, Method: method, Params: p, ID: json.RawMessage("1")})
+	if err != nil {
+		t.Fatalf("Marshalling request failed: %s", err)
+	}
+	var resp testResponse
+	err = json.Unmarshal(s.handleLine(req), &resp)
+	if err != nil {
+		t.Fatalf("Unmarshalling response failed: %s", err)
+	}
+	if resp.JSONRPC != Version || string(resp.ID) != "1" {
+		t.Errorf("Bad response envelope: %s %s", resp.JSONRPC, resp.ID)
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	err = json.Unmarshal(resp.Result, result)
+	if err != nil {
+		t.Fatalf("Unmarshalling result failed: %s", err)
+	}
+	return nil
+}
+
+func Test_Flow(t *testing.T) {
+	s := NewServer()
+	for _, scheme := range []string{jcc.SchemeName, jjm.SchemeName, singhdas.SchemeName} {
+		var keys keygenResult
+		if e := call(t, s, "keygen", keygenParams{Scheme: scheme}, &keys); e != nil {
+			t.Fatalf("%s: keygen failed: %s", scheme, e)
+		}
+		var params getParamsResult
+		if e := call(t, s, "getParams", getParamsParams{Scheme: scheme, PrivateKey: keys.PrivateKey}, &params); e != nil {
+			t.Fatalf("%s: getParams failed: %s", scheme, e)
+		}
+		msg := []byte("Message")
+		var blinded blindResult
+		if e := call(t, s, "blind", blindParams{Scheme: scheme, PublicKey: keys.PublicKey, Params: params.Params, Message: msg}, &blinded); e != nil {
+			t.Fatalf("%s: blind failed: %s", scheme, e)
+		}
+		sp := signParams{Scheme: scheme, PrivateKey: keys.PrivateKey, ServerParams: params.ServerParams, BlindMessage: blinded.BlindMessage}
+		var signed signResult
+		if e := call(t, s, "sign", sp, &signed); e != nil {
+			t.Fatalf("%s: sign failed: %s", scheme, e)
+		}
+		if e := call(t, s, "sign", sp, new(signResult)); scheme != jcc.SchemeName && (e == nil || e.Code != CodeServerError) {
+			t.Errorf("%s: sign must fail for reused parameters: %v", scheme, e) // JCC has no one-time parameters
+		}
+		var unblinded unblindResult
+		if e := call(t, s, "unblind", unblindParams{Scheme: scheme, PublicKey: keys.PublicKey, Factors: blinded.Factors, Message: msg, BlindSignature: signed.BlindSignature}, &unblinded); e != nil {
+			t.Fatalf("%s: unblind failed: %s", scheme, e)
+		}
+		var verified verifyResult
+		if e := call(t, s, "verify", verifyParams{Scheme: scheme, PublicKey: keys.PublicKey, Signature: unblinded.Signature, ClearMessage: unblinded.ClearMessage}, &verified); e != nil {
+			t.Fatalf("%s: verify failed: %s", scheme, e)
+		}
+		if !verified.Valid {
+			t.Errorf("%s: signature does not verify", scheme)
+		}
+	}
+}
+
+func Test_Errors(t *testing.T) {
+	s := NewServer()
+	var keys keygenResult
+	if e := call(t, s, "keygen", keygenParams{Scheme: "NONE"}, &keys); e == nil || e.Code != CodeInvalidParams {
+		t.Errorf("keygen must fail for unknown scheme: %v", e)
+	}
+	if e := call(t, s, "keygen", keygenParams{Scheme: jjm.SchemeName, Curve: "P-192"}, &keys); e == nil || e.Code != CodeInvalidParams {
+		t.Errorf("keygen must fail for unknown curve: %v", e)
+	}
+	if e := call(t, s, "keygen", keygenParams{Scheme: jjm.SchemeName, Curve: "P-384"}, &keys); e != nil {
+		t.Fatalf("keygen failed: %s", e)
+	}
+	var params getParamsResult
+	if e := call(t, s, "getParams", getParamsParams{Scheme: singhdas.SchemeName, PrivateKey: keys.PrivateKey}, &params); e == nil || e.Code != CodeInvalidParams {
+		t.Errorf("getParams must fail for key of other scheme: %v", e)
+	}
+	if e := call(t, s, "frobnicate", nil, &params); e == nil || e.Code != CodeMethodNotFound {
+		t.Errorf("Unknown method must fail: %v", e)
+	}
+
+	for _, tc := range []struct {
+		line string
+		code int
+	}{
+		{`{"jsonrpc":"2.0","method":"keygen",`, CodeParseError},
+		{`{"jsonrpc":"1.0","method":"keygen","id":1}`, CodeInvalidRequest},
+		{`{"jsonrpc":"2.0","method":"keygen","id":1}`, CodeInvalidParams},
+		{`[]`, CodeInvalidRequest},
+		{`[1]`, CodeInvalidRequest},
+	} {
+		resp := s.handleLine([]byte(tc.line))
+		var r testResponse
+		if bytes.HasPrefix(resp, []byte("[")) {
+			var rs []testResponse
+			if err := json.Unmarshal(resp, &rs); err != nil || len(rs) != 1 {
+				t.Fatalf("%s: bad batch response %s", tc.line, resp)
+			}
+			r = rs[0]
+		} else if err := json.Unmarshal(resp, &r); err != nil {
+			t.Fatalf("%s: bad response %s", tc.line, resp)
+		}
+		if r.Error == nil || r.Error.Code != tc.code {
+			t.Errorf("%s: expected error %d, got %s", tc.line, tc.code, resp)
+		}
+	}
+}
+
+func Test_Serve(t *testing.T) {
+	s := NewServer()
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","method":"keygen","params":{"scheme":"JJM"},"id":1}`,
+		``,
+		`{"jsonrpc":"2.0","method":"keygen","params":{"scheme":"JJM"}}`,
+		`[{"jsonrpc":"2.0","method":"keygen","params":{"scheme":"SNG"},"id":"a"},{"jsonrpc":"2.0","method":"nope","id":"b"}]`,
+		`[{"jsonrpc":"2.0","method":"keygen","params":{"scheme":"SNG"}}]`,
+	}, "\n")
+	var out bytes.Buffer
+	err := s.Serve(strings.NewReader(in), &out)
+	if err != nil {
+		t.Fatalf("Serve failed: %s", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Notifications must not be answered: %q", lines)
+	}
+	for _, line := range lines {
+		if strings.HasPrefix(line, "[") {
+			var rs []testResponse
+			if err := json.Unmarshal([]byte(line), &rs); err != nil || len(rs) != 2 {
+				t.Fatalf("Bad batch response: %s", line)
+			}
+			if rs[0].Error != nil || string(rs[0].ID) != `"a"` || rs[1].Error == nil || rs[1].Error.Code != CodeMethodNotFound {
+				t.Errorf("Wrong batch response: %s", line)
+			}
+			continue
+		}
+		var r testResponse
+		if err := json.Unmarshal([]byte(line), &r); err != nil || r.Error != nil || string(r.ID) != "1" {
+			t.Errorf("Wrong response: %s", line)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+// Command bbsgen produces reproducible byte streams with the Blum-Blum-Shub generator of csprng/bbs. The streams
+// are meant for tests and must not be used as cryptographic randomness.
+//
+// Usage:
+//
+//	bbsgen params [-bits n] [-step n] [-out file]
+//	bbsgen bytes [-params file] [-at position] count
+//
+// params generates new parameters with bbs.Params and writes p, q, x0 and step to a new file, one per line as
+// name and value, the numbers in hex. An existing file is not overwritten.
+//
+// bytes writes count bytes of the stream of the parameters in the -params file to stdout. Without -at the stream
+// starts where bbs.New places the generator for the saved step. With -at it starts at the absolute byte
+// position of the stream from x0, independent of step, so that separate processes can produce parts of the same
+// stream.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ronperry/cryptoedge/csprng/bbs"
+)
+
+// chunkSize is the number of bytes generated at once
+const chunkSize = 64 << 10
+
+// command is a subcommand. run gets the arguments after the name of the subcommand
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"params": {"params [-bits n] [-step n] [-out file]", params},
+	"bytes":  {"bytes [-params file] [-at position] count", emit},
+}
+
+var (
+	errParams   = errors.New("invalid parameter file")
+	errBits     = errors.New("bits must be at least 16")
+	errNegative = errors.New("step, position and count must not be negative")
+	errStep     = errors.New("step exceeds the steps of the parameters")
+)
+
+// paramSet is the content of a parameter file
+type paramSet struct {
+	p, q, x0 *big.Int
+	step     int64
+}
+
+// marshal returns the parameter file of ps
+func (ps *paramSet) marshal() []byte {
+	return []byte(fmt.Sprintf("p %#x\nq %#x\nx0 %#x\nstep %d\n", ps.p, ps.q, ps.x0, ps.step))
+}
+
+// parseParams parses a parameter file
+func parseParams(b []byte) (*paramSet, error) {
+	ps := new(paramSet)
+	for _, line := range strings.Split(string(b), "\n") {
+		name, value, _ := strings.Cut(strings.TrimSpace(line), " ")
+		value = strings.TrimSpace(value)
+		var n **big.Int
+		switch name {
+		case "":
+			continue
+		case "p":
+			n = &ps.p
+		case "q":
+			n = &ps.q
+		case "x0":
+			n = &ps.x0
+		case "step":
+			step, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || step < 0 {
+				return nil, errParams
+			}
+			ps.step = step
+			continue
+		default:
+			return nil, errParams
+		}
+		x, ok := new(big.Int).SetString(value, 0)
+		if !ok || x.Sign() <= 0 {
+			return nil, errParams
+		}
+		*n = x
+	}
+	if ps.p == nil || ps.q == nil || ps.x0 == nil {
+		return nil, errParams
+	}
+	return ps, nil
+}
+
+// params generates and saves new parameters
+func params(args []string) error {
+	fs := flag.NewFlagSet("params", flag.ExitOnError)
+	bits := fs.Int("bits", 512, "size of the primes p and q in bits")
+	step := fs.Int64("step", 0, "step passed to bbs.Params")
+	outFile := fs.String("out", "bbs.params", "new file for the parameters")
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *bits < 16 {
+		return errBits
+	}
+	if *step < 0 {
+		return errNegative
+	}
+	ps := new(paramSet)
+	ps.p, ps.q, ps.x0, ps.step = bbs.Params(*bits, *step)
+	if ps.step >= bbs.New(ps.p, ps.q, ps.x0, 0).Step {
+		return errStep
+	}
+	f, err := os.OpenFile(*outFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(ps.marshal())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// emit writes bytes of the stream to stdout
+func emit(args []string) error {
+	fs := flag.NewFlagSet("bytes", flag.ExitOnError)
+	paramsFile := fs.String("params", "bbs.params", "parameter file written by params")
+	at := fs.Int64("at", -1, "absolute byte position to start at")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	count, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+	if err != nil {
+		return err
+	}
+	if count < 0 || *at < -1 {
+		return errNegative
+	}
+	b, err := os.ReadFile(*paramsFile)
+	if err != nil {
+		return err
+	}
+	ps, err := parseParams(b)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(os.Stdout)
+	if err := stream(w, ps, *at, count); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// stream writes count bytes of the stream of ps to w, starting at the absolute byte position at or, if at is
+// -1, where bbs.New places the generator for the step of ps
+func stream(w io.Writer, ps *paramSet, at, count int64) error {
+	g := bbs.New(ps.p, ps.q, ps.x0, ps.step)
+	if ps.step >= g.Step {
+		return errStep
+	}
+	// Each step of the generator yields the same number of bytes, the rest of a step is dropped. Chunks are
+	// whole steps so that they join seamlessly
+	perStep := int64((g.Maxbits + 7) / 8)
+	chunk := chunkSize - chunkSize%perStep
+	if at >= 0 && count > 0 {
+		skip := at % perStep
+		n := min(count, chunk-skip)
+		if _, err := w.Write(g.BytesAt(at/perStep, int(skip+n))[skip:]); err != nil {
+			return err
+		}
+		count -= n
+	}
+	for count > 0 {
+		n := min(count, chunk)
+		if _, err := w.Write(g.Bytes(int(n))); err != nil {
+			return err
+		}
+		count -= n
+	}
+	return nil
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(os.Stderr, "Usage:")
+	for _, name := range names {
+		fmt.Fprintln(os.Stderr, "\tbbsgen", commands[name].usage)
+	}
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "bbsgen %s: %s\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
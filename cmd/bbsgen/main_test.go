@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ronperry/cryptoedge/csprng/bbs"
+)
+
+// newParams returns small parameters for tests
+func newParams(step int64) *paramSet {
+	ps := new(paramSet)
+	ps.p, ps.q, ps.x0, ps.step = bbs.Params(64, step)
+	return ps
+}
+
+func Test_ParamsFile(t *testing.T) {
+	ps := newParams(42)
+	got, err := parseParams(ps.marshal())
+	if err != nil {
+		t.Fatalf("parseParams failed: %s", err)
+	}
+	if got.p.Cmp(ps.p) != 0 || got.q.Cmp(ps.q) != 0 || got.x0.Cmp(ps.x0) != 0 || got.step != ps.step {
+		t.Errorf("Round trip changed parameters: %s", got.marshal())
+	}
+	for _, bad := range []string{
+		"",
+		"p 0x17\nq 0x13\n",
+		"p 0x17\nq 0x13\nx0 0x5\nstep -1\n",
+		"p 0x17\nq 0x13\nx0 0\n",
+		"p 0x17\nq 0x13\nx0 0x5\nr 0x1\n",
+		"p xyz\nq 0x13\nx0 0x5\n",
+	} {
+		if _, err := parseParams([]byte(bad)); err != errParams {
+			t.Errorf("parseParams(%q) must fail: %v", bad, err)
+		}
+	}
+}
+
+func Test_StreamAt(t *testing.T) {
+	ps := newParams(0)
+	var seq bytes.Buffer
+	if err := stream(&seq, ps, -1, chunkSize+1000); err != nil {
+		t.Fatalf("stream failed: %s", err)
+	}
+	if seq.Len() != chunkSize+1000 {
+		t.Fatalf("Wrong length %d", seq.Len())
+	}
+	for _, tc := range []struct{ at, count int64 }{
+		{0, 100},
+		{1, 1},
+		{12345, 678},
+		{chunkSize - 10, 20},
+		{7, chunkSize + 993},
+	} {
+		var part bytes.Buffer
+		if err := stream(&part, ps, tc.at, tc.count); err != nil {
+			t.Fatalf("stream failed: %s", err)
+		}
+		if !bytes.Equal(part.Bytes(), seq.Bytes()[tc.at:tc.at+tc.count]) {
+			t.Errorf("Bytes at %d differ from the sequential stream", tc.at)
+		}
+	}
+}
+
+func Test_StreamStep(t *testing.T) {
+	ps := newParams(0)
+	ps.step = bbs.New(ps.p, ps.q, ps.x0, 0).Step
+	if err := stream(new(bytes.Buffer), ps, -1, 4); err != errStep {
+		t.Errorf("Step beyond the parameters must fail: %v", err)
+	}
+}
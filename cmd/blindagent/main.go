@@ -0,0 +1,118 @@
+// Command blindagent holds blind signer keys and signs on request of local front-ends, so that the private
+// keys never enter their processes.
+//
+// Usage:
+//
+//	blindagent [-socket path] [-ttl duration] keyfile...
+//
+// Each keyfile contains one or more PEM blocks of type "BLIND SIGNER PRIVATE KEY" as written by
+// genericblinding.EncodePrivateKeyPEM. Front-ends connect with blindagent.Dial.
+package main
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ronperry/cryptoedge/blindagent"
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+	_ "github.com/ronperry/cryptoedge/jcc" // Register schemes
+	_ "github.com/ronperry/cryptoedge/jjm"
+	_ "github.com/ronperry/cryptoedge/singhdas"
+)
+
+// SocketEnv is the environment variable holding the default socket path
+const SocketEnv = "BLIND_AGENT_SOCK"
+
+// newServer returns the BlindingServer of scheme for the key pair
+func newServer(scheme string, priv []byte, pubKey *eccutil.Point, curve elliptic.Curve) (genericblinding.BlindingServer, error) {
+	factory, err := genericblinding.LookupFactory(scheme)
+	if err != nil {
+		return nil, err
+	}
+	c := eccutil.SetCurve(func() elliptic.Curve { return curve }, rand.Reader, eccutil.Sha1Hash)
+	return factory.NewServer(priv, pubKey, c), nil
+}
+
+// addKeys adds all keys in the PEM file filename to agent
+func addKeys(agent *blindagent.Agent, filename string, stores *[]genericblinding.ParamStore, ttl time.Duration) error {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	n := 0
+	for {
+		scheme, priv, pubKey, curve, rest, err := genericblinding.DecodePrivateKeyPEM(b)
+		if err == genericblinding.ErrNoPEM && n > 0 {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %s", filename, err)
+		}
+		server, err := newServer(scheme, priv, pubKey, curve)
+		if err != nil {
+			return fmt.Errorf("%s: %s", filename, err)
+		}
+		store := genericblinding.NewMemoryParamStore(ttl)
+		err = agent.Add(scheme, server, pubKey, store)
+		if err != nil {
+			return fmt.Errorf("%s: %s", filename, err)
+		}
+		*stores = append(*stores, store)
+		log.Printf("Added %s key %x", scheme, eccutil.KeyID(pubKey))
+		b = rest
+		n++
+	}
+}
+
+func main() {
+	socket := flag.String("socket", os.Getenv(SocketEnv), "path of the Unix socket, default $"+SocketEnv)
+	ttl := flag.Duration("ttl", 5*time.Minute, "lifetime of unused signing parameters")
+	flag.Parse()
+	if *socket == "" || flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: blindagent [-socket path] [-ttl duration] keyfile...")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	agent := blindagent.NewAgent()
+	var stores []genericblinding.ParamStore
+	for _, filename := range flag.Args() {
+		err := addKeys(agent, filename, &stores, *ttl)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	l, err := blindagent.Listen(*socket)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		l.Close() // Removes the socket
+	}()
+	go func() {
+		for range time.Tick(*ttl) {
+			for _, store := range stores {
+				store.Expire()
+			}
+		}
+	}()
+	log.Printf("Listening on %s", *socket)
+	err = agent.Serve(l)
+	if err != nil && !errors.Is(err, net.ErrClosed) {
+		log.Fatal(err)
+	}
+}
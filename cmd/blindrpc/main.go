@@ -0,0 +1,21 @@
+// Command blindrpc serves the blind signature schemes as JSON-RPC 2.0 on stdin and stdout, one message per
+// line. It exits when stdin is closed and all pending requests are answered. See package blindrpc for the
+// methods.
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/ronperry/cryptoedge/blindrpc"
+	_ "github.com/ronperry/cryptoedge/jcc" // Register schemes
+	_ "github.com/ronperry/cryptoedge/jjm"
+	_ "github.com/ronperry/cryptoedge/singhdas"
+)
+
+func main() {
+	err := blindrpc.NewServer().Serve(os.Stdin, os.Stdout)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+)
+
+var (
+	errTwoStdin     = errors.New("only one input can be read from stdin")
+	errWrongType    = errors.New("input has the wrong DataType")
+	errWrongScheme  = errors.New("inputs belong to different schemes")
+	errSignatureBad = errors.New("signature does not verify")
+)
+
+// params creates one-time signing parameters. The client part goes to the client, the server part stays with
+// the signer until sign
+func params(args []string) error {
+	fs := flag.NewFlagSet("params", flag.ExitOnError)
+	keyFile := fs.String("key", "signer.key", "private key of the signer")
+	stateFile := fs.String("state", "params.state", "new file for the server part of the parameters")
+	outFile := fs.String("out", "-", "file for the client part of the parameters")
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	server, _, err := loadServer(*keyFile)
+	if err != nil {
+		return err
+	}
+	bpc, bps, err := server.GetParams()
+	if err != nil {
+		return err
+	}
+	state, err := bps.Marshal()
+	if err != nil {
+		return err
+	}
+	if err := writeNew(*stateFile, state, 0600); err != nil {
+		return err
+	}
+	if err := writeData(*outFile, bpc); err != nil {
+		os.Remove(*stateFile)
+		return err
+	}
+	return nil
+}
+
+// sign signs a blind message with the parameters in the state file written by params. The state file is
+// removed before signing, so parameters are never used twice
+func sign(args []string) error {
+	fs := flag.NewFlagSet("sign", flag.ExitOnError)
+	keyFile := fs.String("key", "signer.key", "private key of the signer")
+	stateFile := fs.String("state", "params.state", "server part of the parameters, written by params")
+	inFile := fs.String("in", "-", "blind message")
+	outFile := fs.String("out", "-", "file for the blind signature")
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if err := checkStdin(*stateFile, *inFile); err != nil {
+		return err
+	}
+	server, pubKey, err := loadServer(*keyFile)
+	if err != nil {
+		return err
+	}
+	bps, err := readData(*stateFile, pubKey, genericblinding.TypeBlindingParamServer)
+	if err != nil {
+		return err
+	}
+	bm, err := readData(*inFile, pubKey, genericblinding.TypeBlindMessage)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(*stateFile); err != nil {
+		return err
+	}
+	bs, err := server.Sign(bps, bm)
+	if err != nil {
+		return err
+	}
+	return writeData(*outFile, bs)
+}
+
+// blind blinds a message with the client part of the parameters. The blinding factors are kept for unblind
+func blind(args []string) error {
+	fs := flag.NewFlagSet("blind", flag.ExitOnError)
+	pubFile := fs.String("pub", "signer.pub", "public key of the signer")
+	paramsFile := fs.String("params", "-", "client part of the parameters, written by params")
+	factorsFile := fs.String("factors", "blind.factors", "new file for the blinding factors")
+	outFile := fs.String("out", "-", "file for the blind message")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if err := checkStdin(*paramsFile, fs.Arg(0)); err != nil {
+		return err
+	}
+	pubKey, err := loadPublicKey(*pubFile)
+	if err != nil {
+		return err
+	}
+	bpc, err := readData(*paramsFile, pubKey, genericblinding.TypeBlindingParamClient)
+	if err != nil {
+		return err
+	}
+	scheme, _, _ := bpc.SchemeData()
+	client, err := loadClient(scheme, pubKey)
+	if err != nil {
+		return err
+	}
+	cm, err := readMessage(scheme, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	bf, bm, err := client.Blind(bpc, cm)
+	if err != nil {
+		return err
+	}
+	factors, err := bf.Marshal()
+	if err != nil {
+		return err
+	}
+	if err := writeNew(*factorsFile, factors, 0600); err != nil {
+		return err
+	}
+	if err := writeData(*outFile, bm); err != nil {
+		os.Remove(*factorsFile)
+		return err
+	}
+	return nil
+}
+
+// unblind turns the blind signature of a message into its clear signature
+func unblind(args []string) error {
+	fs := flag.NewFlagSet("unblind", flag.ExitOnError)
+	pubFile := fs.String("pub", "signer.pub", "public key of the signer")
+	factorsFile := fs.String("factors", "blind.factors", "blinding factors, written by blind")
+	inFile := fs.String("in", "-", "blind signature")
+	outFile := fs.String("out", "-", "file for the clear signature")
+	msgFile := fs.String("message", "", "file for the clear message the signature covers, needed by verify for jcc")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if err := checkStdin(*factorsFile, *inFile, fs.Arg(0)); err != nil {
+		return err
+	}
+	pubKey, err := loadPublicKey(*pubFile)
+	if err != nil {
+		return err
+	}
+	bf, err := readData(*factorsFile, pubKey, genericblinding.TypeBlindingFactors)
+	if err != nil {
+		return err
+	}
+	bs, err := readData(*inFile, pubKey, genericblinding.TypeBlindSignature)
+	if err != nil {
+		return err
+	}
+	scheme, _, _ := bf.SchemeData()
+	if s, _, _ := bs.SchemeData(); s != scheme {
+		return errWrongScheme
+	}
+	client, err := loadClient(scheme, pubKey)
+	if err != nil {
+		return err
+	}
+	cm, err := readMessage(scheme, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	cs, cm, err := client.Unblind(bf, cm, bs)
+	if err != nil {
+		return err
+	}
+	if *msgFile != "" {
+		if err := writeData(*msgFile, cm); err != nil {
+			return err
+		}
+	}
+	return writeData(*outFile, cs)
+}
+
+// verify checks the clear signature of a message, given as file or as clear message written by unblind. It fails
+// if the signature does not verify
+func verify(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	pubFile := fs.String("pub", "signer.pub", "public key of the signer")
+	inFile := fs.String("in", "-", "clear signature")
+	msgFile := fs.String("message", "", "clear message written by unblind, instead of the message file")
+	fs.Parse(args)
+	if (fs.NArg() == 1) == (*msgFile != "") || fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if err := checkStdin(*inFile, *msgFile, fs.Arg(0)); err != nil {
+		return err
+	}
+	pubKey, err := loadPublicKey(*pubFile)
+	if err != nil {
+		return err
+	}
+	cs, err := readData(*inFile, pubKey, genericblinding.TypeClearSignature)
+	if err != nil {
+		return err
+	}
+	scheme, _, _ := cs.SchemeData()
+	client, err := loadClient(scheme, pubKey)
+	if err != nil {
+		return err
+	}
+	var cm genericblinding.ClearMessage
+	if *msgFile != "" {
+		cm, err = readData(*msgFile, nil, genericblinding.TypeClearMessage)
+	} else {
+		cm, err = readMessage(scheme, fs.Arg(0))
+	}
+	if err != nil {
+		return err
+	}
+	if s, _, _ := cm.SchemeData(); s != scheme {
+		return errWrongScheme
+	}
+	ok, err := client.Verify(cs, cm)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errSignatureBad
+	}
+	fmt.Printf("%s signature of key %x verified\n", scheme, eccutil.KeyID(pubKey))
+	return nil
+}
+
+// loadServer returns the BlindingServer for the private key in file name
+func loadServer(name string) (genericblinding.BlindingServer, *eccutil.Point, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	scheme, priv, pubKey, curve, _, err := genericblinding.DecodePrivateKeyPEM(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	factory, err := genericblinding.LookupFactory(scheme)
+	if err != nil {
+		return nil, nil, err
+	}
+	return factory.NewServer(priv, pubKey, newCurve(curve)), pubKey, nil
+}
+
+// loadPublicKey returns the signer public key in file name
+func loadPublicKey(name string) (*eccutil.Point, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	pubKey, _, err := genericblinding.DecodePublicKeyPEM(b)
+	return pubKey, err
+}
+
+// loadClient returns the BlindingClient of scheme for pubKey
+func loadClient(scheme string, pubKey *eccutil.Point) (genericblinding.BlindingClient, error) {
+	factory, err := genericblinding.LookupFactory(scheme)
+	if err != nil {
+		return nil, err
+	}
+	curve := eccutil.CurveOfPoint(pubKey)
+	if curve == nil {
+		return nil, errUnknownCurve
+	}
+	return factory.NewClient(pubKey, newCurve(curve)), nil
+}
+
+// readMessage returns the ClearMessage of scheme for the contents of file name, or stdin if name is empty or "-"
+func readMessage(scheme, name string) (genericblinding.ClearMessage, error) {
+	factory, err := genericblinding.LookupFactory(scheme)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := readInput(name)
+	if err != nil {
+		return nil, err
+	}
+	return factory.NewClearMessage(msg), nil
+}
+
+// readData decodes the BlindingData of type dataType for signer pubKey in file name, or stdin if name is empty
+// or "-". The data may be marshalled or armored as PEM
+func readData(name string, pubKey *eccutil.Point, dataType genericblinding.DataType) (genericblinding.BlindingData, error) {
+	b, err := readInput(name)
+	if err != nil {
+		return nil, err
+	}
+	var bd genericblinding.BlindingData
+	if block, _ := pem.Decode(b); block != nil {
+		bd, _, err = genericblinding.DecodePEM(b, pubKey)
+	} else {
+		bd, err = genericblinding.Decode(b, pubKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, t, _ := bd.SchemeData(); t != dataType {
+		return nil, errWrongType
+	}
+	return bd, nil
+}
+
+// writeData writes the marshalled bd to file name, or stdout if name is empty or "-"
+func writeData(name string, bd genericblinding.BlindingData) error {
+	b, err := bd.Marshal()
+	if err != nil {
+		return err
+	}
+	if name == "" || name == "-" {
+		_, err = os.Stdout.Write(b)
+		return err
+	}
+	return os.WriteFile(name, b, 0644)
+}
+
+// checkStdin fails if more than one of the input files names is stdin
+func checkStdin(names ...string) error {
+	n := 0
+	for _, name := range names {
+		if name == "" || name == "-" {
+			n++
+		}
+	}
+	if n > 1 {
+		return errTwoStdin
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+)
+
+// inspect prints the keys and BlindingData in a file
+func inspect(args []string) error {
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	b, err := readInput(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	defer w.Flush()
+	if block, _ := pem.Decode(b); block == nil {
+		return printData(w, b)
+	}
+	for n := 0; ; n++ {
+		block, rest := pem.Decode(b)
+		if block == nil {
+			return nil
+		}
+		if n > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "PEM type:\t%s\n", block.Type)
+		switch block.Type {
+		case genericblinding.PublicKeyPEMType:
+			pubKey, _, err := genericblinding.DecodePublicKeyPEM(b)
+			if err != nil {
+				return err
+			}
+			printSigner(w, pubKey)
+		case genericblinding.PrivateKeyPEMType:
+			scheme, _, pubKey, _, _, err := genericblinding.DecodePrivateKeyPEM(b)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "Scheme:\t%s\n", scheme)
+			printSigner(w, pubKey)
+		default:
+			signer, err := genericblinding.Signer(block.Bytes)
+			if err != nil {
+				return err
+			}
+			// Checks block type and headers
+			if _, _, err := genericblinding.DecodePEM(b, signer); err != nil {
+				return err
+			}
+			if err := printData(w, block.Bytes); err != nil {
+				return err
+			}
+		}
+		b = rest
+	}
+}
+
+// printSigner prints curve and fingerprint of the signer pubKey
+func printSigner(w io.Writer, pubKey *eccutil.Point) {
+	if curve := eccutil.CurveOfPoint(pubKey); curve != nil {
+		fmt.Fprintf(w, "Curve:\t%s\n", curve.Params().Name)
+	}
+	fmt.Fprintf(w, "Key ID:\t%x\n", eccutil.KeyID(pubKey))
+}
+
+// printData prints the marshalled BlindingData b
+func printData(w io.Writer, b []byte) error {
+	e, err := genericblinding.OpenEnvelope(b)
+	if err != nil {
+		return err
+	}
+	signer, err := genericblinding.Signer(b)
+	if err != nil {
+		return err
+	}
+	bd, err := genericblinding.Decode(b, signer)
+	if err != nil {
+		return err
+	}
+	scheme, dataType, pubKey := bd.SchemeData()
+	typeName, err := genericblinding.PEMType(scheme, dataType)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Scheme:\t%s\n", scheme)
+	fmt.Fprintf(w, "DataType:\t%d (%s)\n", dataType, strings.TrimPrefix(typeName, scheme+" "))
+	fmt.Fprintf(w, "Envelope:\tversion %d\n", e.Version)
+	if pubKey != nil {
+		printSigner(w, pubKey)
+	}
+	if id := bd.UniqueID(); len(id) > 0 {
+		fmt.Fprintf(w, "UniqueID:\t%x\n", id)
+	}
+	v := reflect.ValueOf(bd)
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		if name == "SchemeName" || name == "DataType" || name == "PubKey" || !v.Type().Field(i).IsExported() {
+			continue
+		}
+		fmt.Fprintf(w, "%s:\t%s\n", name, formatField(v.Field(i).Interface()))
+	}
+	return nil
+}
+
+// formatField returns the value of a field of a BlindingData struct. Numbers and byte slices are hex encoded
+func formatField(f interface{}) string {
+	switch x := f.(type) {
+	case *big.Int:
+		if x == nil {
+			return "<nil>"
+		}
+		return fmt.Sprintf("%#x", x)
+	case eccutil.Point:
+		return "(" + formatField(x.X) + ", " + formatField(x.Y) + ")"
+	case []byte:
+		return hex.EncodeToString(x)
+	default:
+		return fmt.Sprint(x)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+)
+
+// keygen creates a signer key pair
+func keygen(args []string) error {
+	fs := flag.NewFlagSet("keygen", flag.ExitOnError)
+	schemeName := fs.String("scheme", "jjm", "scheme of the signer: jcc, jjm or singhdas")
+	curveName := fs.String("curve", "P-256", "curve of the key")
+	privFile := fs.String("priv", "signer.key", "file for the private key")
+	pubFile := fs.String("pub", "signer.pub", "file for the public key")
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	scheme, err := schemeByName(*schemeName)
+	if err != nil {
+		return err
+	}
+	curve, ok := eccutil.CurveByName(*curveName)
+	if !ok {
+		return errUnknownCurve
+	}
+	priv, pubKey, err := newCurve(curve()).GenerateKey()
+	if err != nil {
+		return err
+	}
+	privPEM, err := genericblinding.EncodePrivateKeyPEM(scheme, priv, pubKey)
+	if err != nil {
+		return err
+	}
+	pubPEM, err := genericblinding.EncodePublicKeyPEM(pubKey)
+	if err != nil {
+		return err
+	}
+	if err := writeNew(*privFile, privPEM, 0600); err != nil {
+		return err
+	}
+	if err := writeNew(*pubFile, pubPEM, 0644); err != nil {
+		os.Remove(*privFile)
+		return err
+	}
+	fmt.Printf("%s key %x\n", scheme, eccutil.KeyID(pubKey))
+	return nil
+}
@@ -0,0 +1,140 @@
+// Command blindsig creates and inspects blind signer keys and BlindingData, and runs the blind signature
+// protocol over files.
+//
+// Usage:
+//
+//	blindsig keygen [-scheme name] [-curve name] [-priv file] [-pub file]
+//	blindsig inspect [file]
+//	blindsig params [-key file] [-state file] [-out file]
+//	blindsig blind [-pub file] [-params file] [-factors file] [-out file] message
+//	blindsig sign [-key file] [-state file] [-in file] [-out file]
+//	blindsig unblind [-pub file] [-factors file] [-in file] [-out file] [-message file] message
+//	blindsig verify [-pub file] [-in file] message | -message file
+//
+// keygen creates a signer key pair and writes the private key as PEM block of type "BLIND SIGNER PRIVATE KEY"
+// and the public key as "BLIND SIGNER PUBLIC KEY". Schemes are jcc, jjm and singhdas, curves P-224, P-256,
+// P-384 and P-521. Existing files are not overwritten.
+//
+// inspect prints scheme, DataType, signer key fingerprint and fields of keys and marshalled BlindingData, in
+// PEM or DER. It reads stdin if file is missing or "-". Private scalars of keys are never printed.
+//
+// The signer runs params and sign with its private key, the client runs blind, unblind and verify with the
+// public key of the signer. The scheme is taken from the key or the input data. params writes the client part
+// of the parameters to -out and keeps the server part in the -state file, which sign removes before signing so
+// parameters are never used twice. blind keeps the blinding factors in the -factors file for unblind. message
+// is the file of the clear message. jcc signs a message derived from it, which unblind writes to the -message
+// file; verify of jcc signatures needs that file instead of message. Data is written marshalled (DER), input
+// may be DER or PEM. Files given as "-", and -in, -out and -params by default, are stdin or stdout, at most
+// one input may be stdin. verify exits with status 1 if the signature does not verify.
+package main
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+	"github.com/ronperry/cryptoedge/jcc"
+	"github.com/ronperry/cryptoedge/jjm"
+	"github.com/ronperry/cryptoedge/singhdas"
+)
+
+// command is a subcommand. run gets the arguments after the name of the subcommand
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"keygen":  {"keygen [-scheme name] [-curve name] [-priv file] [-pub file]", keygen},
+	"inspect": {"inspect [file]", inspect},
+	"params":  {"params [-key file] [-state file] [-out file]", params},
+	"blind":   {"blind [-pub file] [-params file] [-factors file] [-out file] message", blind},
+	"sign":    {"sign [-key file] [-state file] [-in file] [-out file]", sign},
+	"unblind": {"unblind [-pub file] [-factors file] [-in file] [-out file] [-message file] message", unblind},
+	"verify":  {"verify [-pub file] [-in file] message | -message file", verify},
+}
+
+// schemeAliases maps the package names of the schemes to their SchemeName
+var schemeAliases = map[string]string{
+	"jcc":      jcc.SchemeName,
+	"jjm":      jjm.SchemeName,
+	"singhdas": singhdas.SchemeName,
+}
+
+var (
+	errUnknownScheme = errors.New("unknown scheme, use jcc, jjm or singhdas")
+	errUnknownCurve  = errors.New("unknown curve, use P-224, P-256, P-384 or P-521")
+)
+
+// schemeByName returns the SchemeName of a scheme given by package name or SchemeName, in any case
+func schemeByName(name string) (string, error) {
+	if scheme, ok := schemeAliases[strings.ToLower(name)]; ok {
+		return scheme, nil
+	}
+	for _, scheme := range genericblinding.Schemes() {
+		if strings.EqualFold(scheme, name) {
+			return scheme, nil
+		}
+	}
+	return "", errUnknownScheme
+}
+
+// newCurve returns the eccutil.Curve used by the schemes for curve
+func newCurve(curve elliptic.Curve) *eccutil.Curve {
+	return eccutil.SetCurve(func() elliptic.Curve { return curve }, rand.Reader, eccutil.Sha1Hash)
+}
+
+// readInput returns the contents of file name, or of stdin if name is empty or "-"
+func readInput(name string) ([]byte, error) {
+	if name == "" || name == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(name)
+}
+
+// writeNew writes b to the new file name with mode perm. An existing file is not overwritten
+func writeNew(name string, b []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(os.Stderr, "Usage:")
+	for _, name := range names {
+		fmt.Fprintln(os.Stderr, "\tblindsig", commands[name].usage)
+	}
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "blindsig %s: %s\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// run calls the subcommand name with args and fails the test if it fails
+func run(t *testing.T, name string, args ...string) {
+	t.Helper()
+	if err := commands[name].run(args); err != nil {
+		t.Fatalf("%s %v failed: %s", name, args, err)
+	}
+}
+
+func Test_Flow(t *testing.T) {
+	for alias := range schemeAliases {
+		t.Run(alias, func(t *testing.T) {
+			dir := t.TempDir()
+			file := func(name string) string {
+				return filepath.Join(dir, name)
+			}
+			if err := os.WriteFile(file("message"), []byte("Message signed over files"), 0644); err != nil {
+				t.Fatalf("WriteFile failed: %s", err)
+			}
+			if err := os.WriteFile(file("other"), []byte("Other message"), 0644); err != nil {
+				t.Fatalf("WriteFile failed: %s", err)
+			}
+			key, pub, state := "-key="+file("signer.key"), "-pub="+file("signer.pub"), "-state="+file("params.state")
+			factors := "-factors=" + file("blind.factors")
+
+			run(t, "keygen", "-scheme="+alias, "-priv="+file("signer.key"), pub)
+			run(t, "params", key, state, "-out="+file("params"))
+			run(t, "blind", pub, "-params="+file("params"), factors, "-out="+file("blind"), file("message"))
+			run(t, "sign", key, state, "-in="+file("blind"), "-out="+file("signature"))
+			run(t, "unblind", pub, factors, "-in="+file("signature"), "-out="+file("clear"), "-message="+file("clear.message"), file("message"))
+			run(t, "verify", pub, "-in="+file("clear"), "-message="+file("clear.message"))
+			if alias != "jcc" { // jcc signs a message derived from the file
+				run(t, "verify", pub, "-in="+file("clear"), file("message"))
+				if err := verify([]string{pub, "-in=" + file("clear"), file("other")}); err == nil {
+					t.Error("verify must fail for another message")
+				}
+			}
+
+			if err := sign([]string{key, state, "-in=" + file("blind"), "-out=" + file("signature2")}); !os.IsNotExist(err) {
+				t.Errorf("sign must fail for used parameters: %v", err)
+			}
+			if err := verify([]string{pub, "-in=" + file("signature"), "-message=" + file("clear.message")}); err != errWrongType {
+				t.Errorf("verify must fail for a blind signature: %v", err)
+			}
+		})
+	}
+}
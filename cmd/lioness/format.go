@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"crypto/sha3"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"io"
+
+	"github.com/ronperry/cryptoedge/lioness"
+)
+
+// File format:
+//
+//	magic "LIONESS", uint16 length of the header, header
+//	records: uint32 length of the record, enciphered record
+//
+// All integers are big endian. The header is version, mode, cipher, hash, KDF, uint32 PBKDF2 iterations,
+// uint32 record size and salt. A record is up to record size bytes of data followed by a tag of keylen+1 zero
+// bytes, enciphered as one block with keys derived from the master key, the header, the index of the record and
+// whether it is the last one. Any change to a record, its position or the header turns the tag into noise.
+const (
+	magic         = "LIONESS"
+	formatVersion = 1
+	saltSize      = 16
+	headerSize    = 5 + 4 + 4 + saltSize
+	maxRecordSize = 64 << 20
+	maxIterations = 100000000
+	masterKeySize = 32
+)
+
+// KDFs of the master key
+const (
+	kdfKeyFile    = 0 // Contents of the key file
+	kdfPassphrase = 1 // PBKDF2-HMAC-SHA256 of the passphrase
+)
+
+// Modes by name, the byte in the header is the lioness mode
+var modes = map[string]int{
+	"iv":   lioness.ModeIV,
+	"zero": lioness.ModeZero,
+}
+
+// cipherSpec is a block cipher for lioness.Construct
+type cipherSpec struct {
+	name      string
+	newCipher func([]byte) (cipher.Block, error)
+	keylen    int
+}
+
+// hashSpec is a hash for lioness.Construct
+type hashSpec struct {
+	name string
+	hash func() hash.Hash
+}
+
+// ciphers and hashes are indexed by their byte in the header. lioness keys the block cipher with the output of
+// the HMAC, so the size of the hash must be a key size of the cipher and sets the key length of the rounds
+var (
+	ciphers = []cipherSpec{
+		1: {"aes128", aes.NewCipher, 16},
+		2: {"aes192", aes.NewCipher, 24},
+		3: {"aes256", aes.NewCipher, 32},
+	}
+	hashes = []hashSpec{
+		1: {"sha256", sha256.New},
+		2: {"sha512/256", sha512.New512_256},
+		3: {"sha3-256", func() hash.Hash { return sha3.New256() }},
+	}
+)
+
+var (
+	errFormat  = errors.New("input is not in lioness format")
+	errVersion = errors.New("unsupported format version")
+	errHeader  = errors.New("invalid header")
+	errPair    = errors.New("hash size is not a key size of the cipher")
+	errCorrupt = errors.New("wrong key, or input modified or truncated")
+)
+
+// header describes an encrypted file
+type header struct {
+	mode       byte
+	cipher     byte
+	hash       byte
+	kdf        byte
+	iterations uint32
+	recordSize uint32
+	salt       [saltSize]byte
+}
+
+// marshal returns the header without magic and length
+func (h *header) marshal() []byte {
+	b := []byte{formatVersion, h.mode, h.cipher, h.hash, h.kdf}
+	b = binary.BigEndian.AppendUint32(b, h.iterations)
+	b = binary.BigEndian.AppendUint32(b, h.recordSize)
+	return append(b, h.salt[:]...)
+}
+
+// check verifies that the fields of h are known and within limits
+func (h *header) check() error {
+	if int(h.cipher) >= len(ciphers) || ciphers[h.cipher].name == "" || int(h.hash) >= len(hashes) || hashes[h.hash].name == "" {
+		return errHeader
+	}
+	if _, err := ciphers[h.cipher].newCipher(make([]byte, hashes[h.hash].hash().Size())); err != nil {
+		return errPair
+	}
+	if h.mode != lioness.ModeIV && h.mode != lioness.ModeZero {
+		return errHeader
+	}
+	if h.recordSize == 0 || h.recordSize > maxRecordSize {
+		return errHeader
+	}
+	if h.kdf == kdfPassphrase && (h.iterations == 0 || h.iterations > maxIterations) || h.kdf > kdfPassphrase {
+		return errHeader
+	}
+	return nil
+}
+
+// writeHeader writes the framed header h to w
+func writeHeader(w io.Writer, h *header) error {
+	b := append([]byte(magic), 0, 0)
+	binary.BigEndian.PutUint16(b[len(magic):], headerSize)
+	_, err := w.Write(append(b, h.marshal()...))
+	return err
+}
+
+// readHeader reads a framed header from r
+func readHeader(r io.Reader) (*header, error) {
+	b := make([]byte, len(magic)+2)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, errFormat
+	}
+	if string(b[:len(magic)]) != magic {
+		return nil, errFormat
+	}
+	body := make([]byte, binary.BigEndian.Uint16(b[len(magic):]))
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, errFormat
+	}
+	if len(body) == 0 || body[0] != formatVersion {
+		return nil, errVersion
+	}
+	if len(body) != headerSize {
+		return nil, errHeader
+	}
+	h := new(header)
+	h.mode, h.cipher, h.hash, h.kdf = body[1], body[2], body[3], body[4]
+	h.iterations = binary.BigEndian.Uint32(body[5:9])
+	h.recordSize = binary.BigEndian.Uint32(body[9:13])
+	copy(h.salt[:], body[13:])
+	return h, h.check()
+}
+
+// masterKey returns the master key of h for the key file contents or the passphrase
+func masterKey(h *header, secret []byte) ([]byte, error) {
+	if h.kdf == kdfPassphrase {
+		return pbkdf2.Key(sha256.New, string(secret), h.salt[:], int(h.iterations), masterKeySize)
+	}
+	return secret, nil
+}
+
+// recordCipher enciphers the records of a file
+type recordCipher struct {
+	h      *header
+	info   []byte // Header, prefix of the HKDF info of each record
+	master []byte
+	tag    []byte // Zero bytes appended to the data of each record
+}
+
+// newRecordCipher returns the recordCipher of the file described by h for the key file contents or the
+// passphrase
+func newRecordCipher(h *header, secret []byte) (*recordCipher, error) {
+	master, err := masterKey(h, secret)
+	if err != nil {
+		return nil, err
+	}
+	rc := new(recordCipher)
+	rc.h = h
+	rc.info = h.marshal()
+	rc.master = master
+	rc.tag = make([]byte, ciphers[h.cipher].keylen+1)
+	return rc, nil
+}
+
+// lioness returns the lioness keyed for record index, which is the last record if final
+func (rc *recordCipher) lioness(index uint64, final bool) (*lioness.Lioness, error) {
+	cs, hs := ciphers[rc.h.cipher], hashes[rc.h.hash]
+	l, err := lioness.Construct(cs.newCipher, hs.hash, cs.keylen, nil, int(rc.h.mode))
+	if err != nil {
+		return nil, err
+	}
+	info := binary.BigEndian.AppendUint64(append([]byte("lioness record "), rc.info...), index)
+	if final {
+		info = append(info, 1)
+	} else {
+		info = append(info, 0)
+	}
+	keys, err := hkdf.Key(sha256.New, rc.master, rc.h.salt[:], string(info), 4*cs.keylen)
+	if err != nil {
+		return nil, err
+	}
+	n := cs.keylen
+	return l, l.Setkeys(keys[:n], keys[n:2*n], keys[2*n:3*n], keys[3*n:])
+}
+
+// encrypt reads r in records of the record size and writes them enciphered to w
+func (rc *recordCipher) encrypt(w io.Writer, r io.Reader) error {
+	br := bufio.NewReader(r)
+	buf := make([]byte, rc.h.recordSize, int(rc.h.recordSize)+len(rc.tag))
+	for index := uint64(0); ; index++ {
+		n, err := io.ReadFull(br, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		final := err != nil
+		if !final {
+			if _, err := br.Peek(1); err == io.EOF {
+				final = true
+			} else if err != nil {
+				return err
+			}
+		}
+		l, err := rc.lioness(index, final)
+		if err != nil {
+			return err
+		}
+		record, err := l.Encrypt(append(buf[:n], rc.tag...))
+		if err != nil {
+			return err
+		}
+		frame := binary.BigEndian.AppendUint32(make([]byte, 0, 4+len(record)), uint32(len(record)))
+		if _, err := w.Write(append(frame, record...)); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+	}
+}
+
+// decrypt reads enciphered records from r and writes their data to w. Data of records before a corrupt one
+// has already been written when decrypt fails
+func (rc *recordCipher) decrypt(w io.Writer, r io.Reader) error {
+	br := bufio.NewReader(r)
+	frame := make([]byte, 4)
+	for index := uint64(0); ; index++ {
+		if _, err := io.ReadFull(br, frame); err != nil {
+			return errCorrupt
+		}
+		size := binary.BigEndian.Uint32(frame)
+		if size < uint32(len(rc.tag)) || size > rc.h.recordSize+uint32(len(rc.tag)) {
+			return errCorrupt
+		}
+		record := make([]byte, size)
+		if _, err := io.ReadFull(br, record); err != nil {
+			return errCorrupt
+		}
+		_, err := br.Peek(1)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		final := err == io.EOF
+		l, err := rc.lioness(index, final)
+		if err != nil {
+			return err
+		}
+		data, err := l.Decrypt(record)
+		if err != nil {
+			return err
+		}
+		n := len(data) - len(rc.tag)
+		if subtle.ConstantTimeCompare(data[n:], rc.tag) != 1 {
+			return errCorrupt
+		}
+		if _, err := w.Write(data[:n]); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+	}
+}
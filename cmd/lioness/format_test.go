@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/ronperry/cryptoedge/lioness"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+// newTestHeader returns the header of a file with records of size bytes for a key file
+func newTestHeader(size uint32) *header {
+	h := new(header)
+	h.mode = lioness.ModeZero
+	h.cipher, _ = lookupCipher("aes256")
+	h.hash, _ = lookupHash("sha256")
+	h.kdf = kdfKeyFile
+	h.recordSize = size
+	copy(h.salt[:], "salt of the test")
+	return h
+}
+
+// encryptTest returns data encrypted with h and secret
+func encryptTest(t *testing.T, h *header, secret, data []byte) []byte {
+	rc, err := newRecordCipher(h, secret)
+	if err != nil {
+		t.Fatalf("newRecordCipher failed: %s", err)
+	}
+	var buf bytes.Buffer
+	if err := writeHeader(&buf, h); err != nil {
+		t.Fatalf("writeHeader failed: %s", err)
+	}
+	if err := rc.encrypt(&buf, bytes.NewReader(data)); err != nil {
+		t.Fatalf("encrypt failed: %s", err)
+	}
+	return buf.Bytes()
+}
+
+// splitFile returns the framed header and the framed records of an encrypted file
+func splitFile(b []byte) ([]byte, [][]byte) {
+	n := len(magic) + 2 + headerSize
+	head, b := b[:n], b[n:]
+	var records [][]byte
+	for len(b) > 0 {
+		n := 4 + int(binary.BigEndian.Uint32(b))
+		records = append(records, b[:n])
+		b = b[n:]
+	}
+	return head, records
+}
+
+// joinFile returns the file of a framed header and framed records
+func joinFile(head []byte, records ...[]byte) []byte {
+	return bytes.Join(append([][]byte{head}, records...), nil)
+}
+
+func Test_RoundTrip(t *testing.T) {
+	data := make([]byte, 100)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	for c := range ciphers {
+		for hs := range hashes {
+			h := newTestHeader(16)
+			h.cipher, h.hash = byte(c), byte(hs)
+			if h.check() != nil {
+				continue
+			}
+			for _, size := range []int{0, 1, 15, 16, 17, 32, 48, 100} {
+				b := encryptTest(t, h, testKey, data[:size])
+				var out bytes.Buffer
+				if err := decryptFile(&out, bytes.NewReader(b), testKey, kdfKeyFile); err != nil {
+					t.Fatalf("%s/%s, %d bytes: decrypt failed: %s", ciphers[c].name, hashes[hs].name, size, err)
+				}
+				if !bytes.Equal(out.Bytes(), data[:size]) {
+					t.Errorf("%s/%s, %d bytes: wrong data", ciphers[c].name, hashes[hs].name, size)
+				}
+				if _, records := splitFile(b); len(records) != max(1, (size+15)/16) {
+					t.Errorf("%d bytes: %d records", size, len(records))
+				}
+			}
+		}
+	}
+}
+
+func Test_Passphrase(t *testing.T) {
+	h := newTestHeader(16)
+	h.kdf = kdfPassphrase
+	h.iterations = 1
+	pass := []byte("passphrase")
+	b := encryptTest(t, h, pass, []byte("Secret message of two records"))
+	var out bytes.Buffer
+	if err := decryptFile(&out, bytes.NewReader(b), pass, kdfPassphrase); err != nil {
+		t.Fatalf("decrypt failed: %s", err)
+	}
+	if out.String() != "Secret message of two records" {
+		t.Errorf("Wrong data: %q", out.String())
+	}
+	if err := decryptFile(new(bytes.Buffer), bytes.NewReader(b), []byte("other"), kdfPassphrase); err != errCorrupt {
+		t.Errorf("Wrong passphrase: %v", err)
+	}
+	if err := decryptFile(new(bytes.Buffer), bytes.NewReader(b), pass, kdfKeyFile); err != errCorrupt {
+		t.Errorf("Passphrase used as key file: %v", err)
+	}
+}
+
+func Test_Tamper(t *testing.T) {
+	h := newTestHeader(16)
+	data := []byte("Three records of data, 16 bytes each")
+	b := encryptTest(t, h, testKey, data)
+	head, records := splitFile(b)
+	if len(records) != 3 {
+		t.Fatalf("%d records", len(records))
+	}
+	decrypt := func(b []byte) error {
+		return decryptFile(new(bytes.Buffer), bytes.NewReader(b), testKey, kdfKeyFile)
+	}
+
+	for i := range b {
+		flipped := append([]byte(nil), b...)
+		flipped[i] ^= 0x10
+		if err := decrypt(flipped); err == nil {
+			t.Errorf("Bit flip in byte %d not detected", i)
+		}
+	}
+	for name, file := range map[string][]byte{
+		"Reordered":      joinFile(head, records[1], records[0], records[2]),
+		"FirstDropped":   joinFile(head, records[1], records[2]),
+		"MiddleDropped":  joinFile(head, records[0], records[2]),
+		"LastDropped":    joinFile(head, records[0], records[1]),
+		"AllDropped":     joinFile(head),
+		"Duplicated":     joinFile(head, records[0], records[1], records[1], records[2]),
+		"TruncatedFrame": joinFile(head, records[0], records[1], records[2][:10]),
+	} {
+		if err := decrypt(file); err != errCorrupt {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	other := *h
+	other.salt[0] ^= 1
+	empty := encryptTest(t, &other, testKey, nil)
+	emptyHead, emptyRecords := splitFile(empty)
+	if err := decrypt(joinFile(emptyHead)); err != errCorrupt {
+		t.Errorf("Empty file without records: %v", err)
+	}
+	if err := decrypt(joinFile(head, emptyRecords[0])); err != errCorrupt {
+		t.Errorf("Record of another file: %v", err)
+	}
+
+	if err := decryptFile(new(bytes.Buffer), bytes.NewReader(b), []byte("another key of 32 bytes length.."), kdfKeyFile); err != errCorrupt {
+		t.Errorf("Wrong key: %v", err)
+	}
+	if err := decryptFile(new(bytes.Buffer), bytes.NewReader(b), testKey, kdfPassphrase); err != errCorrupt {
+		t.Errorf("Key file used as passphrase: %v", err)
+	}
+	// Header claiming a passphrase, the key stretched with PBKDF2 differs from the key file
+	h2 := *h
+	h2.kdf = kdfPassphrase
+	h2.iterations = 1
+	var buf bytes.Buffer
+	writeHeader(&buf, &h2)
+	if err := decryptFile(new(bytes.Buffer), bytes.NewReader(joinFile(buf.Bytes(), records...)), testKey, kdfPassphrase); err != errCorrupt {
+		t.Errorf("Mismatched KDF in header: %v", err)
+	}
+}
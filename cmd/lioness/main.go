@@ -0,0 +1,286 @@
+// Command lioness encrypts and decrypts files with the LIONESS wide-block cipher.
+//
+// Usage:
+//
+//	lioness encrypt [-key file | -pass file] [-mode name] [-cipher name] [-hash name] [-record size] [-iter n] [-out file] [file]
+//	lioness decrypt [-key file | -pass file] [-out file] [file]
+//
+// The key is the contents of the -key file, or a passphrase read from the first line of the -pass file or from
+// $LIONESS_PASSPHRASE. Passphrases are stretched with PBKDF2-HMAC-SHA256 over -iter iterations. Modes are iv
+// and zero (lioness.ModeIV and ModeZero), ciphers aes128, aes192 and aes256, hashes sha256, sha512/256 and
+// sha3-256. lioness keys the block cipher with the full output of the hash, so the cipher only selects the
+// block cipher and its key length is that of the hash: aes128 with sha256 runs AES-256 in the rounds.
+//
+// The input is split into records of -record bytes that are enciphered independently, so files of any size
+// are processed in constant memory. Each file has a random salt, its records are bound to the header, their
+// position and the end of the file. decrypt takes all parameters from the header and fails if the key is wrong
+// or the input has been modified, reordered or truncated.
+//
+// Input is read from stdin if file is missing or "-", output written to stdout if -out is missing or "-".
+// Existing output files are not overwritten, output files are removed if the command fails. Output written to
+// stdout before decrypt detects a modified record must not be used.
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// PassphraseEnv is the environment variable holding the passphrase if neither -key nor -pass is given
+const PassphraseEnv = "LIONESS_PASSPHRASE"
+
+// Defaults of encrypt
+const (
+	DefaultRecordSize = 1 << 20
+	DefaultIterations = 600000
+	minKeyFileSize    = 16
+)
+
+// command is a subcommand. run gets the arguments after the name of the subcommand
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"encrypt": {"encrypt [-key file | -pass file] [-mode name] [-cipher name] [-hash name] [-record size] [-iter n] [-out file] [file]", encrypt},
+	"decrypt": {"decrypt [-key file | -pass file] [-out file] [file]", decrypt},
+}
+
+var (
+	errNoKey       = errors.New("no key, use -key, -pass or $" + PassphraseEnv)
+	errTwoKeys     = errors.New("use either -key or -pass")
+	errShortKey    = errors.New("key file is shorter than 16 bytes")
+	errEmptyPass   = errors.New("passphrase is empty")
+	errUnknownMode = errors.New("unknown mode, use iv or zero")
+	errUnknownAlgo = errors.New("unknown cipher or hash")
+	errRecordSize  = errors.New("record size out of range")
+	errIterations  = errors.New("iterations out of range")
+)
+
+// keyFlags are the flags that select the key
+type keyFlags struct {
+	keyFile  *string
+	passFile *string
+}
+
+func addKeyFlags(fs *flag.FlagSet) keyFlags {
+	return keyFlags{
+		keyFile:  fs.String("key", "", "file containing the key"),
+		passFile: fs.String("pass", "", "file containing the passphrase in its first line"),
+	}
+}
+
+// secret returns the key file contents or the passphrase, and the KDF for it
+func (kf keyFlags) secret() ([]byte, byte, error) {
+	if *kf.keyFile != "" && *kf.passFile != "" {
+		return nil, 0, errTwoKeys
+	}
+	if *kf.keyFile != "" {
+		key, err := os.ReadFile(*kf.keyFile)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(key) < minKeyFileSize {
+			return nil, 0, errShortKey
+		}
+		return key, kdfKeyFile, nil
+	}
+	var pass []byte
+	if *kf.passFile != "" {
+		b, err := os.ReadFile(*kf.passFile)
+		if err != nil {
+			return nil, 0, err
+		}
+		pass, _, _ = bytes.Cut(b, []byte("\n"))
+		pass = bytes.TrimSuffix(pass, []byte("\r"))
+	} else {
+		env, ok := os.LookupEnv(PassphraseEnv)
+		if !ok {
+			return nil, 0, errNoKey
+		}
+		pass = []byte(env)
+	}
+	if len(pass) == 0 {
+		return nil, 0, errEmptyPass
+	}
+	return pass, kdfPassphrase, nil
+}
+
+// encrypt enciphers a file
+func encrypt(args []string) error {
+	fs := flag.NewFlagSet("encrypt", flag.ExitOnError)
+	kf := addKeyFlags(fs)
+	modeName := fs.String("mode", "zero", "mode: iv or zero")
+	cipherName := fs.String("cipher", "aes256", "block cipher: aes128, aes192 or aes256, keyed with the full hash output (aes128 with a 32 byte hash runs AES-256)")
+	hashName := fs.String("hash", "sha256", "hash: sha256, sha512/256 or sha3-256")
+	recordSize := fs.Int("record", DefaultRecordSize, "size of records in bytes")
+	iterations := fs.Int("iter", DefaultIterations, "PBKDF2 iterations for passphrases")
+	outFile := fs.String("out", "-", "output file")
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	h := new(header)
+	mode, ok := modes[strings.ToLower(*modeName)]
+	if !ok {
+		return errUnknownMode
+	}
+	h.mode = byte(mode)
+	if h.cipher, ok = lookupCipher(*cipherName); !ok {
+		return errUnknownAlgo
+	}
+	if h.hash, ok = lookupHash(*hashName); !ok {
+		return errUnknownAlgo
+	}
+	if *recordSize < 1 || *recordSize > maxRecordSize {
+		return errRecordSize
+	}
+	h.recordSize = uint32(*recordSize)
+	secret, kdf, err := kf.secret()
+	if err != nil {
+		return err
+	}
+	h.kdf = kdf
+	if kdf == kdfPassphrase {
+		if *iterations < 1 || *iterations > maxIterations {
+			return errIterations
+		}
+		h.iterations = uint32(*iterations)
+	}
+	if err := h.check(); err != nil {
+		return err
+	}
+	if _, err := rand.Read(h.salt[:]); err != nil {
+		return err
+	}
+	rc, err := newRecordCipher(h, secret)
+	if err != nil {
+		return err
+	}
+	return run(fs.Arg(0), *outFile, func(w io.Writer, r io.Reader) error {
+		if err := writeHeader(w, h); err != nil {
+			return err
+		}
+		return rc.encrypt(w, r)
+	})
+}
+
+// decrypt deciphers a file
+func decrypt(args []string) error {
+	fs := flag.NewFlagSet("decrypt", flag.ExitOnError)
+	kf := addKeyFlags(fs)
+	outFile := fs.String("out", "-", "output file")
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	secret, kdf, err := kf.secret()
+	if err != nil {
+		return err
+	}
+	return run(fs.Arg(0), *outFile, func(w io.Writer, r io.Reader) error {
+		return decryptFile(w, r, secret, kdf)
+	})
+}
+
+// decryptFile deciphers the file r with the key file contents or passphrase secret, of KDF kdf, to w
+func decryptFile(w io.Writer, r io.Reader, secret []byte, kdf byte) error {
+	h, err := readHeader(r)
+	if err != nil {
+		return err
+	}
+	if h.kdf != kdf {
+		return errCorrupt
+	}
+	rc, err := newRecordCipher(h, secret)
+	if err != nil {
+		return err
+	}
+	return rc.decrypt(w, r)
+}
+
+// lookupCipher returns the header byte of the cipher name
+func lookupCipher(name string) (byte, bool) {
+	for i, c := range ciphers {
+		if c.name != "" && strings.EqualFold(c.name, name) {
+			return byte(i), true
+		}
+	}
+	return 0, false
+}
+
+// lookupHash returns the header byte of the hash name
+func lookupHash(name string) (byte, bool) {
+	for i, h := range hashes {
+		if h.name != "" && strings.EqualFold(h.name, name) {
+			return byte(i), true
+		}
+	}
+	return 0, false
+}
+
+// run calls fn with the output and input files, stdout and stdin for "" and "-". A new output file is removed
+// if fn fails
+func run(inFile, outFile string, fn func(w io.Writer, r io.Reader) error) error {
+	r := os.Stdin
+	if inFile != "" && inFile != "-" {
+		f, err := os.Open(inFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	if outFile == "" || outFile == "-" {
+		return fn(os.Stdout, r)
+	}
+	f, err := os.OpenFile(outFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	err = fn(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(outFile)
+	}
+	return err
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(os.Stderr, "Usage:")
+	for _, name := range names {
+		fmt.Fprintln(os.Stderr, "\tlioness", commands[name].usage)
+	}
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "lioness %s: %s\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
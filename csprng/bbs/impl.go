@@ -126,10 +126,15 @@ func New(p, q, x *big.Int, step int64) *BBS {
 	if mbits > 0 {
 		mbytes++
 	}
-	bbs.Step = int64(
-		math.Pow(float64(p.BitLen()), 2)*
-			math.Pow(float64(q.BitLen()), 2)*
-			math.Pow(float64(x.BitLen()), 2)) / int64((mbytes*8*2)/bbs.Maxbits)
+	steps := math.Pow(float64(p.BitLen()), 2) *
+		math.Pow(float64(q.BitLen()), 2) *
+		math.Pow(float64(x.BitLen()), 2)
+	if steps >= math.MaxInt64 {
+		// Large parameters exceed int64, converting would make Step negative
+		bbs.Step = math.MaxInt64 / int64((mbytes*8*2)/bbs.Maxbits)
+	} else {
+		bbs.Step = int64(steps) / int64((mbytes*8*2)/bbs.Maxbits)
+	}
 
 	bbs.rlock = new(sync.Mutex)
 	if step > 0 {
@@ -2,6 +2,7 @@ package bbs
 
 import (
 	"bytes"
+	"math/big"
 	"testing"
 )
 
@@ -58,3 +59,17 @@ func TestParams(t *testing.T) {
 		t.Error("RNG produces repetition")
 	}
 }
+
+func TestLargeParams(t *testing.T) {
+	// Only the size of the parameters matters for Step
+	p := new(big.Int).Lsh(one, 2047)
+	p.Add(p, three)
+	x := new(big.Int).Lsh(one, 2046)
+	bbs := New(p, p, x, 3)
+	if bbs.Step <= 3 {
+		t.Errorf("Step overflows: %d", bbs.Step)
+	}
+	if len(bbs.Bytes(4)) != 4 {
+		t.Error("Bytes failed")
+	}
+}
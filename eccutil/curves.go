@@ -0,0 +1,75 @@
+package eccutil
+
+import (
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/asn1"
+)
+
+// namedCurve maps a curve to its object identifier (RFC 5480)
+type namedCurve struct {
+	curve func() elliptic.Curve
+	oid   asn1.ObjectIdentifier
+}
+
+var namedCurves = []namedCurve{
+	{elliptic.P224, asn1.ObjectIdentifier{1, 3, 132, 0, 33}},
+	{elliptic.P256, asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}},
+	{elliptic.P384, asn1.ObjectIdentifier{1, 3, 132, 0, 34}},
+	{elliptic.P521, asn1.ObjectIdentifier{1, 3, 132, 0, 35}},
+}
+
+// CurveOID returns the object identifier of a named curve. ok is false for unknown curves
+func CurveOID(curve elliptic.Curve) (oid asn1.ObjectIdentifier, ok bool) {
+	for _, c := range namedCurves {
+		if c.curve().Params().Name == curve.Params().Name {
+			return c.oid, true
+		}
+	}
+	return nil, false
+}
+
+// CurveByOID returns the named curve for an object identifier. ok is false for unknown identifiers
+func CurveByOID(oid asn1.ObjectIdentifier) (curve elliptic.Curve, ok bool) {
+	for _, c := range namedCurves {
+		if c.oid.Equal(oid) {
+			return c.curve(), true
+		}
+	}
+	return nil, false
+}
+
+// CurveByName returns the named curve with the name of its parameters, e.g. "P-256". ok is false for unknown
+// names
+func CurveByName(name string) (curve func() elliptic.Curve, ok bool) {
+	for _, c := range namedCurves {
+		if c.curve().Params().Name == name {
+			return c.curve, true
+		}
+	}
+	return nil, false
+}
+
+// CurveOfPoint returns the first named curve that p lies on, or nil if there is none
+func CurveOfPoint(p *Point) elliptic.Curve {
+	if p == nil || p.X == nil || p.Y == nil {
+		return nil
+	}
+	for _, c := range namedCurves {
+		curve := c.curve()
+		if curve.IsOnCurve(p.X, p.Y) {
+			return curve
+		}
+	}
+	return nil
+}
+
+// KeyID returns a 32 byte identifier for a public key: the SHA256 of the DER encoded point
+func KeyID(p *Point) []byte {
+	d, err := asn1.Marshal(*p)
+	if err != nil { // Only for points with missing coordinates
+		return nil
+	}
+	x := sha256.Sum256(d)
+	return x[:]
+}
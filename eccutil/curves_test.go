@@ -0,0 +1,65 @@
+package eccutil
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+)
+
+func Test_CurveOID(t *testing.T) {
+	for _, curve := range []elliptic.Curve{elliptic.P224(), elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		oid, ok := CurveOID(curve)
+		if !ok {
+			t.Fatalf("No OID for %s", curve.Params().Name)
+		}
+		c, ok := CurveByOID(oid)
+		if !ok || c.Params().Name != curve.Params().Name {
+			t.Errorf("OID does not map back to %s", curve.Params().Name)
+		}
+		f, ok := CurveByName(curve.Params().Name)
+		if !ok || f().Params().Name != curve.Params().Name {
+			t.Errorf("Name does not map back to %s", curve.Params().Name)
+		}
+	}
+	_, ok := CurveByOID(asn1.ObjectIdentifier{1, 2, 3})
+	if ok {
+		t.Error("Unknown OID must not map to a curve")
+	}
+	_, ok = CurveByName("P-192")
+	if ok {
+		t.Error("Unknown name must not map to a curve")
+	}
+}
+
+func Test_CurveOfPoint(t *testing.T) {
+	c := SetCurve(elliptic.P384, rand.Reader, Sha1Hash)
+	_, pub, err := c.GenerateKey()
+	if err != nil {
+		t.Fatalf("Generate key failed: %s", err)
+	}
+	curve := CurveOfPoint(pub)
+	if curve == nil || curve.Params().Name != "P-384" {
+		t.Error("Curve of point not detected")
+	}
+	if CurveOfPoint(ZeroPoint()) != nil {
+		t.Error("Zero point must not be on a curve")
+	}
+}
+
+func Test_KeyID(t *testing.T) {
+	a := KeyID(NewPoint(big.NewInt(1), big.NewInt(2)))
+	b := KeyID(NewPoint(big.NewInt(1), big.NewInt(2)))
+	c := KeyID(NewPoint(big.NewInt(2), big.NewInt(1)))
+	if len(a) != 32 {
+		t.Fatalf("KeyID has wrong size: %d", len(a))
+	}
+	if !bytes.Equal(a, b) {
+		t.Error("KeyID not deterministic")
+	}
+	if bytes.Equal(a, c) {
+		t.Error("KeyID collision")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"io"
 	"math/big"
+	"sync"
 )
 
 // MaxLoopCount is the maximum number of tries we do for parameter search
@@ -47,6 +48,8 @@ var (
 	ErrHashDif = errors.New("singhdas: Hash does not match signature")
 	// ErrSigWrong is returned if the signature does not verify for the message and public key of signer
 	ErrSigWrong = errors.New("singhdas: Signature does not verify")
+	// ErrLengthMismatch is returned if the number of points and scalars of a linear combination differ
+	ErrLengthMismatch = errors.New("eccutil: Number of points and scalars differ")
 )
 
 var (
@@ -306,6 +309,12 @@ func (curve Curve) WithinRange(i *big.Int) bool {
 	return true
 }
 
+// IsScalar tests if i is a canonical scalar of the curve, 0 < i < N. Signatures must only accept canonical
+// scalars, otherwise i+N yields a second valid signature
+func (curve Curve) IsScalar(i *big.Int) bool {
+	return i != nil && i.Sign() > 0 && i.Cmp(curve.Params.N) < 0
+}
+
 // GenHash returns the hash of msg as Int
 func (curve Curve) GenHash(msg []byte) *big.Int {
 	// Make dependent on BitSize
@@ -369,3 +378,87 @@ func PointEqual(a, b *Point) bool {
 	}
 	return false
 }
+
+// IsOnCurve returns true if the point p lies on the curve
+func (curve Curve) IsOnCurve(p *Point) bool {
+	if p == nil || p.X == nil || p.Y == nil {
+		return false
+	}
+	return curve.Curve.IsOnCurve(p.X, p.Y)
+}
+
+// NegPoint returns the inverse of the point p, (x, P-y)
+func (curve Curve) NegPoint(p *Point) *Point {
+	y := new(big.Int)
+	y = y.Sub(curve.Params.P, p.Y)
+	y = y.Mod(y, curve.Params.P)
+	return NewPoint(new(big.Int).Set(p.X), y)
+}
+
+// LinearCombination returns the sum of k[i] x p[i]. Scalars are reduced modulo N. Each product is computed
+// with ScalarMult, so that the optimized implementation of the curve is used, and the products are added up.
+// It costs as much as len(p) ScalarMults, a combined batch verification only pays off for schemes that need
+// more ScalarMults to verify a single signature than points they add to the combination
+func (curve Curve) LinearCombination(p []*Point, k []*big.Int) (*Point, error) {
+	if len(p) != len(k) {
+		return nil, ErrLengthMismatch
+	}
+	r := ZeroPoint()
+	for i := range p {
+		ki := curve.Mod(k[i])
+		if ki.Sign() == 0 {
+			continue
+		}
+		r = curve.AddPoints(r, curve.ScalarMult(p[i], ki.Bytes()))
+	}
+	return r, nil
+}
+
+// BatchCoefficientBits is the size of the coefficients returned by RandomCoefficients. A batch containing an
+// invalid item passes a randomized check with probability 2^-BatchCoefficientBits
+const BatchCoefficientBits = 128
+
+// RandomCoefficients returns n random non-zero coefficients of BatchCoefficientBits for randomized batch
+// verification
+func (curve Curve) RandomCoefficients(n int) ([]*big.Int, error) {
+	max := new(big.Int).Lsh(TestOne, BatchCoefficientBits)
+	a := make([]*big.Int, n)
+	for i := range a {
+		for a[i] == nil || a[i].Sign() == 0 {
+			ai, err := rand.Int(curve.Rand, max)
+			if err != nil {
+				return nil, err
+			}
+			a[i] = ai
+		}
+	}
+	return a, nil
+}
+
+// DefaultUniqueTokens is the number of tokens remembered by the uniqueness tests of the registered factories
+const DefaultUniqueTokens = 1 << 16
+
+// NewUniqueTest returns a uniqueness test that rejects tokens it has seen among the last max tokens. Older
+// tokens are forgotten, so memory stays bounded. Use a persistent test if tokens must never repeat
+func NewUniqueTest(max int) func([32]byte) bool {
+	var lock sync.Mutex
+	seen := make(map[[32]byte]bool, max)
+	order := make([][32]byte, 0, max)
+	next := 0
+	return func(x [32]byte) bool {
+		lock.Lock()
+		defer lock.Unlock()
+		if seen[x] {
+			return false
+		}
+		if len(order) < max {
+			order = append(order, x)
+		} else {
+			delete(seen, order[next])
+			order[next] = x
+			next = (next + 1) % max
+		}
+		seen[x] = true
+		return true
+	}
+}
@@ -1 +1,62 @@
 package eccutil
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestNegPoint(t *testing.T) {
+	c := SetCurve(elliptic.P256, rand.Reader, Sha1Hash)
+	_, pub, err := c.GenerateKey()
+	if err != nil {
+		t.Fatalf("Error creating keys: %s", err)
+	}
+	neg := c.NegPoint(pub)
+	if !c.IsOnCurve(neg) {
+		t.Fatal("Negated point not on curve")
+	}
+	sum := c.AddPoints(pub, neg)
+	if sum.X.Sign() != 0 || sum.Y.Sign() != 0 {
+		t.Error("P + -P is not the point at infinity")
+	}
+	if c.IsOnCurve(NewPoint(pub.X, new(big.Int).Neg(pub.Y))) {
+		t.Error("Negative coordinate accepted as point on curve")
+	}
+	if c.IsOnCurve(nil) {
+		t.Error("nil accepted as point on curve")
+	}
+}
+
+func TestLinearCombination(t *testing.T) {
+	c := SetCurve(elliptic.P256, rand.Reader, Sha1Hash)
+	_, p1, err := c.GenerateKey()
+	if err != nil {
+		t.Fatalf("Error creating keys: %s", err)
+	}
+	_, p2, err := c.GenerateKey()
+	if err != nil {
+		t.Fatalf("Error creating keys: %s", err)
+	}
+	k, err := c.RandomCoefficients(2)
+	if err != nil {
+		t.Fatalf("Error creating coefficients: %s", err)
+	}
+	expect := c.AddPoints(c.ScalarMult(p1, k[0].Bytes()), c.ScalarMult(p2, k[1].Bytes()))
+	if r, err := c.LinearCombination([]*Point{p1, p2}, k); err != nil || !PointEqual(r, expect) {
+		t.Errorf("LinearCombination differs from sum of scalar multiplications: %v", err)
+	}
+	// k[0] + N is the same scalar
+	kn := []*big.Int{new(big.Int).Add(k[0], c.Params.N), k[1]}
+	if r, err := c.LinearCombination([]*Point{p1, p2}, kn); err != nil || !PointEqual(r, expect) {
+		t.Errorf("LinearCombination does not reduce scalars: %v", err)
+	}
+	zero, err := c.LinearCombination([]*Point{p1, c.NegPoint(p1)}, []*big.Int{k[0], k[0]})
+	if err != nil || zero.X.Sign() != 0 || zero.Y.Sign() != 0 {
+		t.Errorf("k x P + k x -P is not the point at infinity: %v", err)
+	}
+	if _, err := c.LinearCombination([]*Point{p1, p2}, k[:1]); err != ErrLengthMismatch {
+		t.Errorf("Different number of points and scalars: %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package genericblinding
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrBatchLength is returned if the slices given to a batch operation differ in length
+	ErrBatchLength = errors.New("blinding: Batch slices differ in length")
+	// ErrBadSignature is reported for batch items whose signature does not verify
+	ErrBadSignature = errors.New("blinding: Signature does not verify")
+)
+
+// BatchError reports the failed items of a batch operation. It has one entry per item of the batch, nil for
+// items that did not fail
+type BatchError []error
+
+// Error returns the number of failed items and the first error
+func (be BatchError) Error() string {
+	failed, first := 0, -1
+	for i, err := range be {
+		if err != nil {
+			failed++
+			if first < 0 {
+				first = i
+			}
+		}
+	}
+	if failed == 0 {
+		return "blinding: No batch item failed"
+	}
+	return fmt.Sprintf("blinding: %d of %d batch items failed, item %d: %s", failed, len(be), first, be[first])
+}
+
+// Err returns be if any item failed and nil otherwise
+func (be BatchError) Err() error {
+	for _, err := range be {
+		if err != nil {
+			return be
+		}
+	}
+	return nil
+}
+
+// BlindingServerBatch is a BlindingServer that issues signatures in batches
+type BlindingServerBatch interface {
+	// GetParamsBatch generates n one-time BlindingParam
+	GetParamsBatch(n int) ([]BlindingParamClient, []BlindingParamServer, error)
+	// SignBatch signs bms[i] using bps[i]. All inputs are validated before any is signed. If validation fails,
+	// nothing is signed and a BatchError is returned. If signing fails for some items, the signatures of the
+	// others are returned with a BatchError for the failed ones
+	SignBatch(bps []BlindingParamServer, bms []BlindMessage) ([]BlindSignature, error)
+}
+
+// BlindingClientBatch is a BlindingClient that verifies signatures in batches
+type BlindingClientBatch interface {
+	// VerifyBatch verifies that css[i] is a signature of cms[i]. It returns true if all signatures verify.
+	// Otherwise it returns a BatchError that reports each failed item, ErrBadSignature for signatures that do
+	// not verify
+	VerifyBatch(css []ClearSignature, cms []ClearMessage) (bool, error)
+}
+
+// ServerWithBatch returns server as BlindingServerBatch. Servers that do not implement BlindingServerBatch are
+// wrapped in an adapter that calls GetParams and Sign for each item. The adapter cannot validate the inputs
+// before signing
+func ServerWithBatch(server BlindingServer) BlindingServerBatch {
+	if s, ok := server.(BlindingServerBatch); ok {
+		return s
+	}
+	return batchServerAdapter{server}
+}
+
+// ClientWithBatch returns client as BlindingClientBatch. Clients that do not implement BlindingClientBatch are
+// wrapped in an adapter that calls Verify for each item
+func ClientWithBatch(client BlindingClient) BlindingClientBatch {
+	if c, ok := client.(BlindingClientBatch); ok {
+		return c
+	}
+	return batchClientAdapter{client}
+}
+
+// GetParamsBatch calls getParams n times
+func GetParamsBatch(getParams func() (BlindingParamClient, BlindingParamServer, error), n int) ([]BlindingParamClient, []BlindingParamServer, error) {
+	if n < 0 {
+		return nil, nil, ErrBatchLength
+	}
+	bpcs := make([]BlindingParamClient, n)
+	bpss := make([]BlindingParamServer, n)
+	for i := 0; i < n; i++ {
+		bpc, bps, err := getParams()
+		if err != nil {
+			return nil, nil, err
+		}
+		bpcs[i], bpss[i] = bpc, bps
+	}
+	return bpcs, bpss, nil
+}
+
+// VerifyEach verifies the items of a batch one by one with verify. It returns like VerifyBatch
+func VerifyEach(verify func(ClearSignature, ClearMessage) (bool, error), css []ClearSignature, cms []ClearMessage) (bool, error) {
+	if len(css) != len(cms) {
+		return false, ErrBatchLength
+	}
+	errs := make(BatchError, len(css))
+	for i := range css {
+		errs[i] = VerifyItem(verify, css[i], cms[i])
+	}
+	if err := errs.Err(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// VerifyItem verifies a single batch item with verify. It returns ErrBadSignature if the signature does not
+// verify without an error
+func VerifyItem(verify func(ClearSignature, ClearMessage) (bool, error), cs ClearSignature, cm ClearMessage) error {
+	ok, err := verify(cs, cm)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// batchServerAdapter implements BlindingServerBatch for a BlindingServer
+type batchServerAdapter struct {
+	server BlindingServer
+}
+
+func (sa batchServerAdapter) GetParamsBatch(n int) ([]BlindingParamClient, []BlindingParamServer, error) {
+	return GetParamsBatch(sa.server.GetParams, n)
+}
+
+func (sa batchServerAdapter) SignBatch(bps []BlindingParamServer, bms []BlindMessage) ([]BlindSignature, error) {
+	if len(bps) != len(bms) {
+		return nil, ErrBatchLength
+	}
+	sigs := make([]BlindSignature, len(bms))
+	errs := make(BatchError, len(bms))
+	for i := range bms {
+		sigs[i], errs[i] = sa.server.Sign(bps[i], bms[i])
+	}
+	return sigs, errs.Err()
+}
+
+// batchClientAdapter implements BlindingClientBatch for a BlindingClient
+type batchClientAdapter struct {
+	client BlindingClient
+}
+
+func (ca batchClientAdapter) VerifyBatch(css []ClearSignature, cms []ClearMessage) (bool, error) {
+	return VerifyEach(ca.client.Verify, css, cms)
+}
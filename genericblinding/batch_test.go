@@ -0,0 +1,66 @@
+package genericblinding
+
+import (
+	"errors"
+	"testing"
+)
+
+// testClient is a BlindingClient without batch support that accepts signatures equal to true
+type testClient struct{}
+
+func (tc testClient) Blind(bpc BlindingParamClient, cm ClearMessage) (BlindingFactors, BlindMessage, error) {
+	return nil, nil, nil
+}
+
+func (tc testClient) Unblind(bf BlindingFactors, cm ClearMessage, bs BlindSignature) (ClearSignature, ClearMessage, error) {
+	return nil, nil, nil
+}
+
+func (tc testClient) Verify(cs ClearSignature, cm ClearMessage) (bool, error) {
+	if cs == nil {
+		return false, ErrBadType
+	}
+	return true, nil
+}
+
+func Test_BatchError(t *testing.T) {
+	errBad := errors.New("bad")
+	be := BatchError{nil, errBad, nil, ErrBadSignature}
+	if be.Err() == nil {
+		t.Error("Err is nil for failed items")
+	}
+	if be.Error() != "blinding: 2 of 4 batch items failed, item 1: bad" {
+		t.Errorf("Unexpected error string: %s", be.Error())
+	}
+	if (BatchError{nil, nil}).Err() != nil {
+		t.Error("Err is not nil without failed items")
+	}
+}
+
+func Test_BatchAdapter(t *testing.T) {
+	ts := new(testServer)
+	s := ServerWithBatch(ts)
+	bpcs, bpss, err := s.GetParamsBatch(3)
+	if err != nil || len(bpcs) != 3 || len(bpss) != 3 || ts.calls != 3 {
+		t.Errorf("GetParamsBatch did not call GetParams 3 times: %v", err)
+	}
+	sigs, err := s.SignBatch(bpss, make([]BlindMessage, 3))
+	if err != nil || len(sigs) != 3 || ts.calls != 6 {
+		t.Errorf("SignBatch did not call Sign 3 times: %v", err)
+	}
+	_, err = s.SignBatch(bpss, nil)
+	if err != ErrBatchLength {
+		t.Errorf("SignBatch must fail with ErrBatchLength: %v", err)
+	}
+
+	c := ClientWithBatch(testClient{})
+	ok, err := c.VerifyBatch([]ClearSignature{testData{}, testData{}}, make([]ClearMessage, 2))
+	if !ok || err != nil {
+		t.Errorf("VerifyBatch failed: %v", err)
+	}
+	ok, err = c.VerifyBatch([]ClearSignature{testData{}, nil}, make([]ClearMessage, 2))
+	be, isBatch := err.(BatchError)
+	if ok || !isBatch || be[0] != nil || be[1] != ErrBadType {
+		t.Errorf("VerifyBatch did not report failed item: %v", err)
+	}
+}
@@ -0,0 +1,479 @@
+package genericblinding
+
+// CBOR encoding of BlindingData structs (RFC 8949, core deterministic encoding): Each struct is a single array
+// [scheme tag, DataType, curve tag, fields...] holding the remaining exported fields in declaration order. Schemes
+// are identified by the integer tag given to RegisterTag, curves by their index in cborCurves (0 for unknown).
+// Points on the curve of the signer are compressed SEC1, other points are encoded like EncodePoint. Big integers
+// are unsigned or negative integers if they fit into 64 bits and tagged bignums otherwise. All heads use the
+// shortest form and indefinite lengths are not used, so every value has exactly one encoding. UnmarshalCBOR
+// rejects any other encoding.
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"reflect"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+)
+
+var (
+	// ErrCBOR is returned if CBOR data is malformed or does not fit the BlindingData struct
+	ErrCBOR = errors.New("blinding: Malformed CBOR")
+	// ErrCBORNonCanonical is returned if CBOR data is well-formed but not deterministically encoded
+	ErrCBORNonCanonical = errors.New("blinding: CBOR encoding is not deterministic")
+	// ErrCBORKind is returned if a BlindingData struct contains a field that has no CBOR encoding
+	ErrCBORKind = errors.New("blinding: Field cannot be encoded as CBOR")
+	// ErrNoTag is returned if no CBOR tag has been registered for a scheme
+	ErrNoTag = errors.New("blinding: No CBOR tag registered for scheme")
+)
+
+// CBOR major types
+const (
+	cborUint     = 0
+	cborNegative = 1
+	cborBytes    = 2
+	cborText     = 3
+	cborArray    = 4
+	cborTag      = 6
+	cborSimple   = 7
+)
+
+// CBOR tags and simple values
+const (
+	cborTagBignum    = 2
+	cborTagNegBignum = 3
+	cborFalse        = 20
+	cborTrue         = 21
+)
+
+// cborCurves maps curve tags to curves. The order is part of the encoding and must not change
+var cborCurves = []elliptic.Curve{nil, elliptic.P224(), elliptic.P256(), elliptic.P384(), elliptic.P521()}
+
+var (
+	schemeTags = make(map[string]uint64)
+	tagSchemes = make(map[uint64]string)
+)
+
+// RegisterTag assigns the integer tag used in CBOR encodings to scheme. Schemes call this from init
+func RegisterTag(scheme string, tag uint64) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	schemeTags[scheme] = tag
+	tagSchemes[tag] = scheme
+}
+
+// schemeTag returns the CBOR tag of scheme
+func schemeTag(scheme string) (uint64, error) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	tag, ok := schemeTags[scheme]
+	if !ok {
+		return 0, ErrNoTag
+	}
+	return tag, nil
+}
+
+// tagScheme returns the scheme for a CBOR tag
+func tagScheme(tag uint64) (string, error) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	scheme, ok := tagSchemes[tag]
+	if !ok {
+		return "", ErrUnknownScheme
+	}
+	return scheme, nil
+}
+
+// curveTag returns the CBOR tag of curve, 0 if it is unknown
+func curveTag(curve elliptic.Curve) uint64 {
+	if curve == nil {
+		return 0
+	}
+	for i, c := range cborCurves[1:] {
+		if c.Params().Name == curve.Params().Name {
+			return uint64(i + 1)
+		}
+	}
+	return 0
+}
+
+// cborHead appends the shortest head for major type major and argument n to buf
+func cborHead(buf []byte, major byte, n uint64) []byte {
+	major <<= 5
+	switch {
+	case n < 24:
+		return append(buf, major|byte(n))
+	case n <= 0xff:
+		return append(buf, major|24, byte(n))
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(buf, major|25), uint16(n))
+	case n <= 0xffffffff:
+		return binary.BigEndian.AppendUint32(append(buf, major|26), uint32(n))
+	}
+	return binary.BigEndian.AppendUint64(append(buf, major|27), n)
+}
+
+// cborBigInt appends the encoding of i to buf
+func cborBigInt(buf []byte, i *big.Int) []byte {
+	major, tag, m := byte(cborUint), uint64(cborTagBignum), i
+	if i.Sign() < 0 {
+		major, tag = cborNegative, cborTagNegBignum
+		m = new(big.Int).Neg(i)
+		m.Sub(m, big.NewInt(1))
+	}
+	if m.IsUint64() {
+		return cborHead(buf, major, m.Uint64())
+	}
+	d := m.Bytes()
+	buf = cborHead(buf, cborTag, tag)
+	buf = cborHead(buf, cborBytes, uint64(len(d)))
+	return append(buf, d...)
+}
+
+// cborPoint returns the encoding of p, compressed if it lies on curve
+func cborPoint(p *eccutil.Point, curve elliptic.Curve) []byte {
+	if curve != nil && curve.IsOnCurve(p.X, p.Y) {
+		return elliptic.MarshalCompressed(curve, p.X, p.Y)
+	}
+	return EncodePoint(p)
+}
+
+// cborValue appends the encoding of a single field to buf
+func cborValue(buf []byte, v reflect.Value, curve elliptic.Curve) ([]byte, error) {
+	switch v.Type() {
+	case typeBigInt:
+		if v.IsNil() {
+			return nil, ErrCBORKind
+		}
+		return cborBigInt(buf, v.Interface().(*big.Int)), nil
+	case typePoint:
+		p := v.Interface().(eccutil.Point)
+		if p.X == nil || p.Y == nil {
+			return nil, ErrCBORKind
+		}
+		d := cborPoint(&p, curve)
+		return append(cborHead(buf, cborBytes, uint64(len(d))), d...), nil
+	case typeBytes:
+		return append(cborHead(buf, cborBytes, uint64(v.Len())), v.Bytes()...), nil
+	case typeDataType:
+		return cborHead(buf, cborUint, uint64(v.Int())), nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return append(cborHead(buf, cborText, uint64(v.Len())), v.String()...), nil
+	case reflect.Bool:
+		if v.Bool() {
+			return cborHead(buf, cborSimple, cborTrue), nil
+		}
+		return cborHead(buf, cborSimple, cborFalse), nil
+	}
+	return nil, ErrCBORKind
+}
+
+// cborFields returns the indices of the fields of struct type t that are encoded after the header
+func cborFields(t reflect.Type) []int {
+	fields := make([]int, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" || f.Name == "SchemeName" || f.Name == "DataType" {
+			continue
+		}
+		fields = append(fields, i)
+	}
+	return fields
+}
+
+// MarshalCBOR returns the deterministic CBOR encoding of bd, which must be a BlindingData struct
+func MarshalCBOR(bd BlindingData) ([]byte, error) {
+	v := reflect.Indirect(reflect.ValueOf(bd))
+	if v.Kind() != reflect.Struct {
+		return nil, ErrCBORKind
+	}
+	scheme, dataType, pubKey := bd.SchemeData()
+	tag, err := schemeTag(scheme)
+	if err != nil {
+		return nil, err
+	}
+	var curve elliptic.Curve
+	if pubKey != nil {
+		curve = eccutil.CurveOfPoint(pubKey)
+	}
+	fields := cborFields(v.Type())
+	buf := cborHead(nil, cborArray, uint64(3+len(fields)))
+	buf = cborHead(buf, cborUint, tag)
+	buf = cborHead(buf, cborUint, uint64(dataType))
+	buf = cborHead(buf, cborUint, curveTag(curve))
+	for _, i := range fields {
+		buf, err = cborValue(buf, v.Field(i), curve)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+// cborDecoder reads CBOR items from b
+type cborDecoder struct {
+	b []byte
+}
+
+// head reads the head of the next item. Indefinite lengths are rejected
+func (d *cborDecoder) head() (major byte, n uint64, err error) {
+	if len(d.b) < 1 {
+		return 0, 0, ErrCBOR
+	}
+	major, info := d.b[0]>>5, d.b[0]&0x1f
+	d.b = d.b[1:]
+	if info < 24 {
+		return major, uint64(info), nil
+	}
+	if info > 27 {
+		return 0, 0, ErrCBOR
+	}
+	size := 1 << (info - 24)
+	if len(d.b) < size {
+		return 0, 0, ErrCBOR
+	}
+	for _, c := range d.b[:size] {
+		n = n<<8 | uint64(c)
+	}
+	d.b = d.b[size:]
+	return major, n, nil
+}
+
+// expect reads a head of major type major
+func (d *cborDecoder) expect(major byte) (uint64, error) {
+	m, n, err := d.head()
+	if err != nil {
+		return 0, err
+	}
+	if m != major {
+		return 0, ErrCBOR
+	}
+	return n, nil
+}
+
+// bytes reads a byte or text string of major type major
+func (d *cborDecoder) bytes(major byte) ([]byte, error) {
+	n, err := d.expect(major)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(d.b)) < n {
+		return nil, ErrCBOR
+	}
+	s := make([]byte, n)
+	copy(s, d.b)
+	d.b = d.b[n:]
+	return s, nil
+}
+
+// bigInt reads an integer or bignum
+func (d *cborDecoder) bigInt() (*big.Int, error) {
+	major, n, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+	switch major {
+	case cborUint:
+		return new(big.Int).SetUint64(n), nil
+	case cborNegative:
+		i := new(big.Int).SetUint64(n)
+		return i.Neg(i.Add(i, big.NewInt(1))), nil
+	case cborTag:
+		if n != cborTagBignum && n != cborTagNegBignum {
+			return nil, ErrCBOR
+		}
+		s, err := d.bytes(cborBytes)
+		if err != nil {
+			return nil, err
+		}
+		i := new(big.Int).SetBytes(s)
+		if n == cborTagNegBignum {
+			i.Neg(i.Add(i, big.NewInt(1)))
+		}
+		return i, nil
+	}
+	return nil, ErrCBOR
+}
+
+// point reads a point encoded by cborPoint
+func (d *cborDecoder) point(curve elliptic.Curve) (*eccutil.Point, error) {
+	s, err := d.bytes(cborBytes)
+	if err != nil {
+		return nil, err
+	}
+	if len(s) > 0 && (s[0] == 2 || s[0] == 3) {
+		if curve == nil {
+			return nil, ErrCBOR
+		}
+		x, y := elliptic.UnmarshalCompressed(curve, s)
+		if x == nil {
+			return nil, ErrCBOR
+		}
+		return eccutil.NewPoint(x, y), nil
+	}
+	p, err := DecodePoint(s)
+	if err != nil {
+		return nil, ErrCBOR
+	}
+	return p, nil
+}
+
+// setValue reads a single field encoded by cborValue into v
+func (d *cborDecoder) setValue(v reflect.Value, curve elliptic.Curve) error {
+	switch v.Type() {
+	case typeBigInt:
+		i, err := d.bigInt()
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(i))
+		return nil
+	case typePoint:
+		p, err := d.point(curve)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(*p))
+		return nil
+	case typeBytes:
+		s, err := d.bytes(cborBytes)
+		if err != nil {
+			return err
+		}
+		v.SetBytes(s)
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		s, err := d.bytes(cborText)
+		if err != nil {
+			return err
+		}
+		v.SetString(string(s))
+		return nil
+	case reflect.Bool:
+		n, err := d.expect(cborSimple)
+		if err != nil {
+			return err
+		}
+		if n != cborFalse && n != cborTrue {
+			return ErrCBOR
+		}
+		v.SetBool(n == cborTrue)
+		return nil
+	}
+	return ErrCBORKind
+}
+
+// cborHeader reads the array head, scheme, DataType and curve of a CBOR encoded BlindingData struct
+func (d *cborDecoder) cborHeader() (length uint64, scheme string, dataType DataType, curve elliptic.Curve, err error) {
+	length, err = d.expect(cborArray)
+	if err != nil {
+		return
+	}
+	if length < 3 {
+		err = ErrCBOR
+		return
+	}
+	tag, err := d.expect(cborUint)
+	if err != nil {
+		return
+	}
+	scheme, err = tagScheme(tag)
+	if err != nil {
+		return
+	}
+	t, err := d.expect(cborUint)
+	if err != nil {
+		return
+	}
+	dataType = DataType(t)
+	c, err := d.expect(cborUint)
+	if err != nil {
+		return
+	}
+	if c >= uint64(len(cborCurves)) {
+		err = ErrCBOR
+		return
+	}
+	curve = cborCurves[c]
+	return
+}
+
+// UnmarshalCBOR parses CBOR produced by MarshalCBOR into v, a pointer to a BlindingData struct. Scheme,
+// DataType and signer of the result must match template
+func UnmarshalCBOR(b []byte, v BlindingData, template BlindingData) error {
+	pv := reflect.ValueOf(v)
+	if pv.Kind() != reflect.Ptr || pv.Elem().Kind() != reflect.Struct {
+		return ErrCBORKind
+	}
+	scheme, dataType, pubKey := template.SchemeData()
+	d := &cborDecoder{b: b}
+	length, s, t, curve, err := d.cborHeader()
+	if err != nil {
+		return err
+	}
+	if s != scheme {
+		return ErrBadScheme
+	}
+	if t != dataType {
+		return ErrBadType
+	}
+	sv := pv.Elem()
+	fields := cborFields(sv.Type())
+	if length != uint64(3+len(fields)) {
+		return ErrCBOR
+	}
+	sv.FieldByName("SchemeName").SetString(s)
+	sv.FieldByName("DataType").SetInt(int64(t))
+	for _, i := range fields {
+		err = d.setValue(sv.Field(i), curve)
+		if err != nil {
+			return err
+		}
+	}
+	if len(d.b) > 0 {
+		return ErrCBOR
+	}
+	_, err = MatchMessage(v, scheme, dataType, pubKey)
+	if err != nil {
+		return err
+	}
+	canonical, err := MarshalCBOR(v)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(canonical, b) {
+		return ErrCBORNonCanonical
+	}
+	return nil
+}
+
+// DecodeCBOR parses CBOR encoded BlindingData of any registered scheme. pubKey is the expected signer and may
+// only be nil for types that do not carry a signer (ClearMessage)
+func DecodeCBOR(b []byte, pubKey *eccutil.Point) (BlindingData, error) {
+	d := &cborDecoder{b: b}
+	_, scheme, dataType, _, err := d.cborHeader()
+	if err != nil {
+		return nil, err
+	}
+	constructor, err := lookup(scheme, dataType)
+	if err != nil {
+		return nil, err
+	}
+	if pubKey == nil && dataType != TypeClearMessage {
+		return nil, ErrBadSigner
+	}
+	template := constructor(pubKey)
+	n := reflect.New(reflect.TypeOf(template))
+	err = UnmarshalCBOR(b, n.Interface().(BlindingData), template)
+	if err != nil {
+		return nil, err
+	}
+	return n.Elem().Interface().(BlindingData), nil
+}
@@ -0,0 +1,87 @@
+package genericblinding
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+)
+
+func init() {
+	RegisterTag("TST", 0xfff0)
+}
+
+func Test_CBORBigInt(t *testing.T) {
+	// Examples from RFC 8949, Appendix A
+	bignum, _ := new(big.Int).SetString("18446744073709551616", 10)
+	for _, v := range []struct {
+		i   *big.Int
+		hex string
+	}{
+		{big.NewInt(0), "00"},
+		{big.NewInt(23), "17"},
+		{big.NewInt(24), "1818"},
+		{big.NewInt(1000000), "1a000f4240"},
+		{new(big.Int).SetUint64(18446744073709551615), "1bffffffffffffffff"},
+		{bignum, "c249010000000000000000"},
+		{big.NewInt(-1), "20"},
+		{big.NewInt(-1000), "3903e7"},
+		{new(big.Int).Neg(bignum), "3bffffffffffffffff"},
+		{new(big.Int).Sub(new(big.Int).Neg(bignum), big.NewInt(1)), "c349010000000000000000"},
+	} {
+		b := cborBigInt(nil, v.i)
+		if hex.EncodeToString(b) != v.hex {
+			t.Errorf("Encoding of %s is %x instead of %s", v.i, b, v.hex)
+		}
+		d := &cborDecoder{b: b}
+		j, err := d.bigInt()
+		if err != nil {
+			t.Fatalf("Decoding %s failed: %s", v.i, err)
+		}
+		if j.Cmp(v.i) != 0 {
+			t.Errorf("Round trip of %s returned %s", v.i, j)
+		}
+	}
+}
+
+func Test_CBOR(t *testing.T) {
+	pubkey := testKey(t)
+	td := newTestData(pubkey)
+	b, err := MarshalCBOR(td)
+	if err != nil {
+		t.Fatalf("CBOR marshalling failed: %s", err)
+	}
+	// array(5), tag, BlindMessage, P256, compressed point, "test value"
+	if b[0] != 0x85 || b[1] != 0x19 || b[4] != 0x04 || b[5] != 0x02 || b[6] != 0x58 || b[7] != 33 {
+		t.Errorf("Unexpected CBOR encoding: %x", b)
+	}
+	n := new(testData)
+	err = UnmarshalCBOR(b, n, td)
+	if err != nil {
+		t.Fatalf("CBOR unmarshalling failed: %s", err)
+	}
+	if string(n.Value) != string(td.Value) || !eccutil.PointEqual(&n.PubKey, pubkey) {
+		t.Error("CBOR round trip failed")
+	}
+	// Same value, but with a non-minimal head for the length of Value
+	nc := append(append([]byte{}, b[:len(b)-len(td.Value)-1]...), 0x58, byte(len(td.Value)))
+	nc = append(nc, td.Value...)
+	err = UnmarshalCBOR(nc, n, td)
+	if err != ErrCBORNonCanonical {
+		t.Errorf("CBOR unmarshalling must fail for non-canonical encoding: %v", err)
+	}
+	err = UnmarshalCBOR(b[:len(b)-1], n, td)
+	if err != ErrCBOR {
+		t.Errorf("CBOR unmarshalling must fail for truncated data: %v", err)
+	}
+	_, err = DecodeCBOR(b, pubkey)
+	if err != ErrUnknownScheme {
+		t.Errorf("DecodeCBOR must fail for unregistered scheme: %v", err)
+	}
+	td.SchemeName = "Untagged"
+	_, err = MarshalCBOR(td)
+	if err != ErrNoTag {
+		t.Errorf("CBOR marshalling must fail for scheme without tag: %v", err)
+	}
+}
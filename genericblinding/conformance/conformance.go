@@ -0,0 +1,881 @@
+// Package conformance is a test suite for implementations of the genericblinding interfaces. A scheme passes
+// if its BlindingClient and BlindingServer complete a full round trip, reject data of foreign signers, schemes
+// and types, refuse to reuse one-time parameters, sign with parameters kept in a ParamStore, stop at a
+// cancelled context, marshal all seven DataTypes losslessly in DER, JSON, CBOR and PEM, do not accept tampered
+// data and report failed batch items. Implementations must return errors instead of panicking on malformed
+// input.
+//
+// Usage, from a _test.go file of the scheme:
+//
+//	func Test_Conformance(t *testing.T) {
+//		conformance.Run(t, conformance.Suite{NewPair: newPair, NewClearMessage: newClearMessage, OneTimeParams: true})
+//	}
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+)
+
+// Suite describes the implementation under test
+type Suite struct {
+	// NewPair returns a server with a fresh key pair, a client for that server and the server's public key
+	NewPair func() (genericblinding.BlindingServer, genericblinding.BlindingClient, *eccutil.Point, error)
+	// NewClearMessage returns a ClearMessage of the scheme containing msg
+	NewClearMessage func(msg []byte) genericblinding.ClearMessage
+	// OneTimeParams is true if the scheme uses per-signature parameters that must not be used twice. Sign and
+	// SignBatch of the server must reject parameters they have signed with before, at least among the recent
+	// ones, and a StoredServer must reject them with genericblinding.ErrParamUsed
+	OneTimeParams bool
+}
+
+// ForeignScheme is the SchemeName of the data used to test rejection of foreign schemes
+const ForeignScheme = "CONFORMANCE"
+
+// foreignData is BlindingData of a scheme that is not under test
+type foreignData struct {
+	SchemeName string
+	DataType   genericblinding.DataType
+	PubKey     eccutil.Point
+}
+
+func newForeignData(dataType genericblinding.DataType, pubKey *eccutil.Point) foreignData {
+	return foreignData{SchemeName: ForeignScheme, DataType: dataType, PubKey: *pubKey}
+}
+
+func (fd foreignData) Marshal() ([]byte, error) {
+	return genericblinding.MarshalEnvelope(fd)
+}
+
+func (fd foreignData) Unmarshal(b []byte) (genericblinding.BlindingData, error) {
+	n := new(foreignData)
+	err := genericblinding.UnmarshalEnvelope(b, n)
+	if err != nil {
+		return nil, err
+	}
+	return *n, nil
+}
+
+func (fd foreignData) UniqueID() []byte {
+	return eccutil.KeyID(&fd.PubKey)
+}
+
+func (fd foreignData) SchemeData() (string, genericblinding.DataType, *eccutil.Point) {
+	return fd.SchemeName, fd.DataType, &fd.PubKey
+}
+
+// panicError is a panic recovered by protect
+type panicError struct {
+	value interface{}
+}
+
+func (p panicError) Error() string {
+	return fmt.Sprintf("panic: %v", p.value)
+}
+
+// protect calls f and returns a panic in f as panicError
+func protect(f func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = panicError{r}
+		}
+	}()
+	return f()
+}
+
+// session holds all data of one blind signature
+type session struct {
+	server  genericblinding.BlindingServer
+	client  genericblinding.BlindingClient
+	pubKey  *eccutil.Point
+	cm      genericblinding.ClearMessage
+	bpc     genericblinding.BlindingParamClient
+	bps     genericblinding.BlindingParamServer
+	bf      genericblinding.BlindingFactors
+	bm      genericblinding.BlindMessage
+	bs      genericblinding.BlindSignature
+	cs      genericblinding.ClearSignature
+	cmOut   genericblinding.ClearMessage
+	message []byte
+}
+
+// newSession runs a full blind signature with a new key pair
+func (s Suite) newSession(t *testing.T) *session {
+	server, client, pubKey, err := s.NewPair()
+	if err != nil {
+		t.Fatalf("NewPair failed: %s", err)
+	}
+	return s.sign(t, server, client, pubKey)
+}
+
+// sign runs a full blind signature of a random message
+func (s Suite) sign(t *testing.T, server genericblinding.BlindingServer, client genericblinding.BlindingClient, pubKey *eccutil.Point) *session {
+	ss := &session{server: server, client: client, pubKey: pubKey, message: make([]byte, 32)}
+	_, err := rand.Read(ss.message)
+	if err != nil {
+		t.Fatalf("Random message failed: %s", err)
+	}
+	ss.cm = s.NewClearMessage(ss.message)
+	err = protect(func() (err error) {
+		ss.bpc, ss.bps, err = server.GetParams()
+		if err != nil {
+			return fmt.Errorf("GetParams: %s", err)
+		}
+		ss.bf, ss.bm, err = client.Blind(ss.bpc, ss.cm)
+		if err != nil {
+			return fmt.Errorf("Blind: %s", err)
+		}
+		ss.bs, err = server.Sign(ss.bps, ss.bm)
+		if err != nil {
+			return fmt.Errorf("Sign: %s", err)
+		}
+		ss.cs, ss.cmOut, err = client.Unblind(ss.bf, ss.cm, ss.bs)
+		if err != nil {
+			return fmt.Errorf("Unblind: %s", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Round trip failed: %s", err)
+	}
+	return ss
+}
+
+// verify returns true if client accepts cs for cm. Errors count as rejection, panics fail the test
+func verify(t *testing.T, client genericblinding.BlindingClient, cs genericblinding.ClearSignature, cm genericblinding.ClearMessage) bool {
+	var ok bool
+	err := protect(func() (err error) {
+		ok, err = client.Verify(cs, cm)
+		return err
+	})
+	if err != nil && !ok {
+		if _, ok := err.(panicError); ok {
+			t.Errorf("Verify: %s", err)
+		}
+		return false
+	}
+	return ok && err == nil
+}
+
+// Run runs all tests of the suite as subtests of t
+func Run(t *testing.T, s Suite) {
+	t.Run("RoundTrip", s.testRoundTrip)
+	t.Run("WrongSigner", s.testWrongSigner)
+	t.Run("WrongScheme", s.testWrongScheme)
+	t.Run("WrongType", s.testWrongType)
+	t.Run("ParamReuse", s.testParamReuse)
+	t.Run("StoredServer", s.testStoredServer)
+	t.Run("Context", s.testContext)
+	t.Run("Marshal", s.testMarshal)
+	t.Run("Decode", s.testDecode)
+	t.Run("JSON", s.testJSON)
+	t.Run("CBOR", s.testCBOR)
+	t.Run("PEM", s.testPEM)
+	t.Run("Tamper", s.testTamper)
+	t.Run("Batch", s.testBatch)
+}
+
+func (s Suite) testRoundTrip(t *testing.T) {
+	ss := s.newSession(t)
+	if !verify(t, ss.client, ss.cs, ss.cmOut) {
+		t.Error("Signature does not verify")
+	}
+	for i := 0; i < 3; i++ {
+		next := s.sign(t, ss.server, ss.client, ss.pubKey)
+		if !verify(t, next.client, next.cs, next.cmOut) {
+			t.Errorf("Signature %d of the same server does not verify", i+2)
+		}
+		if bytes.Equal(next.cs.UniqueID(), ss.cs.UniqueID()) {
+			t.Error("Signatures of different messages have the same UniqueID")
+		}
+	}
+}
+
+func (s Suite) testWrongSigner(t *testing.T) {
+	ss := s.newSession(t)
+	other := s.newSession(t)
+	err := protect(func() error {
+		_, _, err := other.client.Blind(ss.bpc, ss.cm)
+		return err
+	})
+	if err == nil {
+		t.Error("Blind must fail for parameters of foreign signer")
+	}
+	_, bps, err := other.server.GetParams()
+	if err != nil {
+		t.Fatalf("GetParams failed: %s", err)
+	}
+	err = protect(func() error {
+		_, err := other.server.Sign(bps, ss.bm)
+		return err
+	})
+	if err == nil {
+		t.Error("Sign must fail for blind message of foreign signer")
+	}
+	err = protect(func() error {
+		_, _, err := other.client.Unblind(ss.bf, ss.cm, ss.bs)
+		return err
+	})
+	if err == nil {
+		t.Error("Unblind must fail for data of foreign signer")
+	}
+	if verify(t, other.client, ss.cs, ss.cmOut) {
+		t.Error("Signature of foreign signer verifies")
+	}
+}
+
+func (s Suite) testWrongScheme(t *testing.T) {
+	ss := s.newSession(t)
+	foreign := func(dataType genericblinding.DataType) foreignData {
+		return newForeignData(dataType, ss.pubKey)
+	}
+	for _, f := range []struct {
+		name string
+		call func() error
+	}{
+		{"Blind", func() error {
+			_, _, err := ss.client.Blind(ss.bpc, foreign(genericblinding.TypeClearMessage))
+			return err
+		}},
+		{"Sign", func() error {
+			_, err := ss.server.Sign(ss.bps, foreign(genericblinding.TypeBlindMessage))
+			return err
+		}},
+		{"Unblind", func() error {
+			_, _, err := ss.client.Unblind(foreign(genericblinding.TypeBlindingFactors), ss.cm, ss.bs)
+			return err
+		}},
+		{"Unblind", func() error {
+			_, _, err := ss.client.Unblind(ss.bf, ss.cm, foreign(genericblinding.TypeBlindSignature))
+			return err
+		}},
+		{"Verify", func() error {
+			_, err := ss.client.Verify(foreign(genericblinding.TypeClearSignature), ss.cmOut)
+			return err
+		}},
+	} {
+		err := protect(f.call)
+		if err != genericblinding.ErrBadScheme {
+			t.Errorf("%s must fail with ErrBadScheme for foreign scheme: %v", f.name, err)
+		}
+	}
+}
+
+func (s Suite) testWrongType(t *testing.T) {
+	ss := s.newSession(t)
+	for _, f := range []struct {
+		name string
+		call func() error
+	}{
+		{"Blind", func() error {
+			_, _, err := ss.client.Blind(ss.bpc, ss.bm)
+			return err
+		}},
+		{"Sign", func() error {
+			_, err := ss.server.Sign(ss.bps, ss.cs)
+			return err
+		}},
+		{"Unblind", func() error {
+			_, _, err := ss.client.Unblind(ss.bs, ss.cm, ss.bf)
+			return err
+		}},
+		{"Verify", func() error {
+			_, err := ss.client.Verify(ss.bs, ss.cmOut)
+			return err
+		}},
+	} {
+		err := protect(f.call)
+		if err != genericblinding.ErrBadType {
+			t.Errorf("%s must fail with ErrBadType for wrong type: %v", f.name, err)
+		}
+	}
+}
+
+func (s Suite) testParamReuse(t *testing.T) {
+	if !s.OneTimeParams {
+		t.Skip("Scheme has no one-time parameters")
+	}
+	server, client, _, err := s.NewPair()
+	if err != nil {
+		t.Fatalf("NewPair failed: %s", err)
+	}
+	// A ParamStore tells parameters apart by UniqueID
+	var ids [2][]byte
+	for i := range ids {
+		err = protect(func() error {
+			_, bps, err := server.GetParams()
+			if err != nil {
+				return err
+			}
+			b, err := bps.Marshal()
+			if err != nil {
+				return err
+			}
+			n, err := bps.Unmarshal(b)
+			if err != nil {
+				return err
+			}
+			ids[i] = bps.UniqueID()
+			if !bytes.Equal(n.UniqueID(), ids[i]) {
+				return fmt.Errorf("UniqueID changes in marshalling")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("GetParams: %s", err)
+		}
+	}
+	if bytes.Equal(ids[0], ids[1]) {
+		t.Error("Parameters of different GetParams have the same UniqueID")
+	}
+	bpc, bps, err := server.GetParams()
+	if err != nil {
+		t.Fatalf("GetParams failed: %s", err)
+	}
+	_, bm, err := client.Blind(bpc, s.NewClearMessage([]byte("Message")))
+	if err != nil {
+		t.Fatalf("Blind failed: %s", err)
+	}
+	if _, err := server.Sign(bps, bm); err != nil {
+		t.Fatalf("Sign failed: %s", err)
+	}
+	if _, err := server.Sign(bps, bm); err == nil {
+		t.Error("Sign must fail for reused parameters")
+	}
+	ss := genericblinding.NewStoredServer(server, genericblinding.NewMemoryParamStore(time.Minute))
+	id, bpc, err := ss.GetParams()
+	if err != nil {
+		t.Fatalf("GetParams failed: %s", err)
+	}
+	_, bm, err = client.Blind(bpc, s.NewClearMessage([]byte("Message")))
+	if err != nil {
+		t.Fatalf("Blind failed: %s", err)
+	}
+	if _, err := ss.Sign(id, bm); err != nil {
+		t.Fatalf("Sign failed: %s", err)
+	}
+	if _, err := ss.Sign(id, bm); err != genericblinding.ErrParamUsed {
+		t.Errorf("Sign must fail for reused parameters: %v", err)
+	}
+}
+
+func (s Suite) testStoredServer(t *testing.T) {
+	ss := s.newSession(t)
+	store, err := genericblinding.NewFileParamStore(t.TempDir(), time.Minute, ss.bps)
+	if err != nil {
+		t.Fatalf("NewFileParamStore failed: %s", err)
+	}
+	server := genericblinding.NewStoredServer(ss.server, store)
+	var id []byte
+	var bm genericblinding.BlindMessage
+	var cs genericblinding.ClearSignature
+	var cmOut genericblinding.ClearMessage
+	err = protect(func() error {
+		var bpc genericblinding.BlindingParamClient
+		var bf genericblinding.BlindingFactors
+		var bs genericblinding.BlindSignature
+		var err error
+		if id, bpc, err = server.GetParams(); err != nil {
+			return fmt.Errorf("GetParams: %s", err)
+		}
+		if bf, bm, err = ss.client.Blind(bpc, ss.cm); err != nil {
+			return fmt.Errorf("Blind: %s", err)
+		}
+		if bs, err = server.Sign(id, bm); err != nil {
+			return fmt.Errorf("Sign: %s", err)
+		}
+		if cs, cmOut, err = ss.client.Unblind(bf, ss.cm, bs); err != nil {
+			return fmt.Errorf("Unblind: %s", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Round trip with stored parameters failed: %s", err)
+	}
+	if !verify(t, ss.client, cs, cmOut) {
+		t.Error("Signature with stored parameters does not verify")
+	}
+	if _, err := server.Sign(id, bm); err != genericblinding.ErrParamUsed {
+		t.Errorf("Sign must fail for used parameters: %v", err)
+	}
+}
+
+func (s Suite) testContext(t *testing.T) {
+	server, client, _, err := s.NewPair()
+	if err != nil {
+		t.Fatalf("NewPair failed: %s", err)
+	}
+	cserver, cclient := genericblinding.ServerWithContext(server), genericblinding.ClientWithContext(client)
+	ctx, cancel := context.WithCancel(context.Background())
+	bpc, bps, err := cserver.GetParamsContext(ctx)
+	if err != nil {
+		t.Fatalf("GetParams failed: %s", err)
+	}
+	cm := s.NewClearMessage([]byte("Message blinded with context"))
+	_, bm, err := cclient.BlindContext(ctx, bpc, cm)
+	if err != nil {
+		t.Fatalf("Blind failed: %s", err)
+	}
+	cancel()
+	for _, f := range []struct {
+		name string
+		call func() error
+	}{
+		{"GetParams", func() error { _, _, err := cserver.GetParamsContext(ctx); return err }},
+		{"Blind", func() error { _, _, err := cclient.BlindContext(ctx, bpc, cm); return err }},
+		{"Sign", func() error { _, err := cserver.SignContext(ctx, bps, bm); return err }},
+	} {
+		if err := protect(f.call); err != context.Canceled {
+			t.Errorf("%s must fail for cancelled context: %v", f.name, err)
+		}
+	}
+	err = protect(func() error {
+		_, err := cserver.SignContext(context.Background(), bps, bm)
+		return err
+	})
+	if err != nil {
+		t.Errorf("Sign must not consume parameters for cancelled context: %v", err)
+	}
+}
+
+func (s Suite) testMarshal(t *testing.T) {
+	ss := s.newSession(t)
+	for _, bd := range []genericblinding.BlindingData{ss.bpc, ss.cm, ss.bf, ss.bm, ss.bs, ss.cs, ss.bps} {
+		scheme, dataType, pubKey := bd.SchemeData()
+		b, err := bd.Marshal()
+		if err != nil {
+			t.Errorf("Marshal of %s type %d failed: %s", scheme, dataType, err)
+			continue
+		}
+		var n genericblinding.BlindingData
+		err = protect(func() (err error) {
+			n, err = bd.Unmarshal(b)
+			return err
+		})
+		if err != nil {
+			t.Errorf("Unmarshal of %s type %d failed: %s", scheme, dataType, err)
+			continue
+		}
+		if reflect.TypeOf(n) != reflect.TypeOf(bd) {
+			t.Errorf("Unmarshal of %s type %d returned %T instead of %T", scheme, dataType, n, bd)
+		}
+		nScheme, nDataType, nPubKey := n.SchemeData()
+		if nScheme != scheme || nDataType != dataType || (pubKey == nil) != (nPubKey == nil) || (pubKey != nil && !eccutil.PointEqual(pubKey, nPubKey)) {
+			t.Errorf("Unmarshal of %s type %d changed SchemeData", scheme, dataType)
+		}
+		if !bytes.Equal(n.UniqueID(), bd.UniqueID()) {
+			t.Errorf("Unmarshal of %s type %d changed UniqueID", scheme, dataType)
+		}
+		b2, err := n.Marshal()
+		if err != nil || !bytes.Equal(b, b2) {
+			t.Errorf("Marshal round trip of %s type %d is lossy", scheme, dataType)
+		}
+		signer, err := genericblinding.Signer(b)
+		if err != nil || (pubKey == nil) != (signer == nil) || (pubKey != nil && !eccutil.PointEqual(pubKey, signer)) {
+			t.Errorf("Signer of %s type %d failed: %v", scheme, dataType, err)
+		}
+	}
+	if !verify(t, ss.client, ss.cs, ss.cmOut) {
+		t.Error("Signature does not verify after marshalling")
+	}
+}
+
+// all returns the BlindingData of ss that Decode, DecodeJSON, DecodeCBOR and DecodePEM must recognize
+func (ss *session) all() []genericblinding.BlindingData {
+	return []genericblinding.BlindingData{ss.bpc, ss.bps, ss.cm, ss.bf, ss.bm, ss.bs, ss.cs}
+}
+
+// unmarshalInto calls unmarshal with a pointer to a copy of template, or to the zero value of its type if
+// zero is set
+func unmarshalInto(template genericblinding.BlindingData, zero bool, unmarshal func(v interface{}) error) error {
+	v := reflect.New(reflect.TypeOf(template))
+	if !zero {
+		v.Elem().Set(reflect.ValueOf(template))
+	}
+	return protect(func() error {
+		return unmarshal(v.Interface())
+	})
+}
+
+func (s Suite) testDecode(t *testing.T) {
+	ss := s.newSession(t)
+	for _, bd := range ss.all() {
+		b, err := bd.Marshal()
+		if err != nil {
+			t.Fatalf("Marshal failed: %s", err)
+		}
+		d, err := genericblinding.Decode(b, ss.pubKey)
+		if err != nil {
+			t.Fatalf("Decode of %T failed: %s", bd, err)
+		}
+		if reflect.TypeOf(d) != reflect.TypeOf(bd) {
+			t.Errorf("Decode returned %T instead of %T", d, bd)
+		}
+	}
+	b, err := ss.bm.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal failed: %s", err)
+	}
+	d, err := genericblinding.Decode(b, ss.pubKey)
+	if err != nil {
+		t.Fatalf("Decode failed: %s", err)
+	}
+	_, bps, err := ss.server.GetParams()
+	if err != nil {
+		t.Fatalf("GetParams failed: %s", err)
+	}
+	if _, err := ss.server.Sign(bps, d); err != nil {
+		t.Errorf("Sign of decoded BlindMessage failed: %s", err)
+	}
+	if _, err := genericblinding.Decode(b, s.newSession(t).pubKey); err != genericblinding.ErrBadSigner {
+		t.Errorf("Decode must fail for foreign signer: %v", err)
+	}
+	if _, err := genericblinding.Decode(b, nil); err != genericblinding.ErrBadSigner {
+		t.Errorf("Decode must fail without signer: %v", err)
+	}
+}
+
+func (s Suite) testJSON(t *testing.T) {
+	ss := s.newSession(t)
+	for _, bd := range ss.all() {
+		j, err := json.Marshal(bd)
+		if err != nil {
+			t.Fatalf("JSON marshalling of %T failed: %s", bd, err)
+		}
+		d, err := genericblinding.DecodeJSON(j, ss.pubKey)
+		if err != nil {
+			t.Fatalf("DecodeJSON of %T failed: %s", bd, err)
+		}
+		if reflect.TypeOf(d) != reflect.TypeOf(bd) {
+			t.Errorf("DecodeJSON returned %T instead of %T", d, bd)
+		}
+		b1, _ := bd.Marshal()
+		b2, _ := d.Marshal()
+		if !bytes.Equal(b1, b2) {
+			t.Errorf("JSON round trip of %T is lossy", bd)
+		}
+	}
+	j, err := json.Marshal(ss.bm)
+	if err != nil {
+		t.Fatalf("JSON marshalling failed: %s", err)
+	}
+	unmarshal := func(v interface{}) error {
+		return json.Unmarshal(j, v)
+	}
+	if err := unmarshalInto(ss.bm, false, unmarshal); err != nil {
+		t.Errorf("JSON unmarshalling failed: %s", err)
+	}
+	if err := unmarshalInto(s.newSession(t).bm, false, unmarshal); err != genericblinding.ErrBadSigner {
+		t.Errorf("JSON unmarshalling must fail for foreign signer: %v", err)
+	}
+	if err := unmarshalInto(ss.bs, false, unmarshal); err != genericblinding.ErrBadType {
+		t.Errorf("JSON unmarshalling must fail for wrong type: %v", err)
+	}
+	if err := unmarshalInto(ss.bm, true, unmarshal); err != genericblinding.ErrBadScheme {
+		t.Errorf("JSON unmarshalling must fail without template: %v", err)
+	}
+}
+
+func (s Suite) testCBOR(t *testing.T) {
+	ss := s.newSession(t)
+	for _, bd := range ss.all() {
+		e, err := genericblinding.MarshalCBOR(bd)
+		if err != nil {
+			t.Fatalf("CBOR marshalling of %T failed: %s", bd, err)
+		}
+		d, err := genericblinding.DecodeCBOR(e, ss.pubKey)
+		if err != nil {
+			t.Fatalf("DecodeCBOR of %T failed: %s", bd, err)
+		}
+		if reflect.TypeOf(d) != reflect.TypeOf(bd) {
+			t.Errorf("DecodeCBOR returned %T instead of %T", d, bd)
+		}
+		b1, _ := bd.Marshal()
+		b2, _ := d.Marshal()
+		if !bytes.Equal(b1, b2) {
+			t.Errorf("CBOR round trip of %T is lossy", bd)
+		}
+		if len(e) >= len(b1) {
+			t.Errorf("CBOR encoding of %T is not smaller than DER: %d >= %d", bd, len(e), len(b1))
+		}
+	}
+	e, err := genericblinding.MarshalCBOR(ss.bm)
+	if err != nil {
+		t.Fatalf("CBOR marshalling failed: %s", err)
+	}
+	unmarshal := func(e []byte) func(v interface{}) error {
+		return func(v interface{}) error {
+			u, ok := v.(interface{ UnmarshalCBOR([]byte) error })
+			if !ok {
+				return fmt.Errorf("%T has no UnmarshalCBOR", v)
+			}
+			return u.UnmarshalCBOR(e)
+		}
+	}
+	if err := unmarshalInto(ss.bm, false, unmarshal(e)); err != nil {
+		t.Errorf("CBOR unmarshalling failed: %s", err)
+	}
+	if err := unmarshalInto(s.newSession(t).bm, false, unmarshal(e)); err != genericblinding.ErrBadSigner {
+		t.Errorf("CBOR unmarshalling must fail for foreign signer: %v", err)
+	}
+	if err := unmarshalInto(ss.bs, false, unmarshal(e)); err != genericblinding.ErrBadType {
+		t.Errorf("CBOR unmarshalling must fail for wrong type: %v", err)
+	}
+	if err := unmarshalInto(ss.bm, true, unmarshal(e)); err != genericblinding.ErrBadScheme {
+		t.Errorf("CBOR unmarshalling must fail without template: %v", err)
+	}
+	if err := unmarshalInto(ss.bm, false, unmarshal(append(e, 0))); err != genericblinding.ErrCBOR {
+		t.Errorf("CBOR unmarshalling must fail for trailing data: %v", err)
+	}
+}
+
+func (s Suite) testPEM(t *testing.T) {
+	ss := s.newSession(t)
+	for _, bd := range ss.all() {
+		b, err := genericblinding.EncodePEM(bd)
+		if err != nil {
+			t.Fatalf("PEM encoding of %T failed: %s", bd, err)
+		}
+		d, _, err := genericblinding.DecodePEM(b, ss.pubKey)
+		if err != nil {
+			t.Fatalf("DecodePEM of %T failed: %s", bd, err)
+		}
+		if reflect.TypeOf(d) != reflect.TypeOf(bd) {
+			t.Errorf("DecodePEM returned %T instead of %T", d, bd)
+		}
+	}
+	scheme, _, _ := ss.bs.SchemeData()
+	blindType, err := genericblinding.PEMType(scheme, genericblinding.TypeBlindSignature)
+	if err != nil {
+		t.Fatalf("PEMType failed: %s", err)
+	}
+	clearType, err := genericblinding.PEMType(scheme, genericblinding.TypeClearSignature)
+	if err != nil {
+		t.Fatalf("PEMType failed: %s", err)
+	}
+	b, err := genericblinding.EncodePEM(ss.bs)
+	if err != nil {
+		t.Fatalf("PEM encoding failed: %s", err)
+	}
+	block, _ := pem.Decode(b)
+	if block == nil || block.Type != blindType {
+		t.Fatalf("Wrong PEM block type: %s", b)
+	}
+	block.Type = clearType
+	if _, _, err := genericblinding.DecodePEM(pem.EncodeToMemory(block), ss.pubKey); err != genericblinding.ErrPEMType {
+		t.Errorf("PEM decoding must fail for wrong block type: %v", err)
+	}
+	block.Type = blindType
+	block.Headers[genericblinding.PEMHeaderScheme] = ForeignScheme
+	if _, _, err := genericblinding.DecodePEM(pem.EncodeToMemory(block), ss.pubKey); err != genericblinding.ErrPEMHeader {
+		t.Errorf("PEM decoding must fail for wrong scheme header: %v", err)
+	}
+	block.Headers[genericblinding.PEMHeaderScheme] = scheme
+	delete(block.Headers, genericblinding.PEMHeaderKeyID)
+	if _, _, err := genericblinding.DecodePEM(pem.EncodeToMemory(block), ss.pubKey); err != genericblinding.ErrPEMHeader {
+		t.Errorf("PEM decoding must fail without Key-ID header: %v", err)
+	}
+}
+
+// tamper returns copies of bd, a BlindingData struct, that each differ in one exported field. Scalars are
+// incremented, points replaced by alt and by an invalid point and byte slices get their last bit flipped.
+// Header fields are left alone since MatchMessage covers them
+func tamper(bd genericblinding.BlindingData, alt *eccutil.Point) map[string]genericblinding.BlindingData {
+	v := reflect.ValueOf(bd)
+	tampered := make(map[string]genericblinding.BlindingData)
+	if v.Kind() != reflect.Struct {
+		return tampered
+	}
+	set := func(i int, name string, x interface{}) {
+		n := reflect.New(v.Type()).Elem()
+		n.Set(v)
+		n.Field(i).Set(reflect.ValueOf(x))
+		tampered[name] = n.Interface().(genericblinding.BlindingData)
+	}
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		if f.PkgPath != "" || f.Name == "SchemeName" || f.Name == "DataType" || f.Name == "PubKey" {
+			continue
+		}
+		switch x := v.Field(i).Interface().(type) {
+		case *big.Int:
+			if x != nil {
+				set(i, f.Name, new(big.Int).Add(x, big.NewInt(1)))
+			}
+		case eccutil.Point:
+			if x.X == nil || x.Y == nil {
+				continue
+			}
+			if !eccutil.PointEqual(&x, alt) {
+				set(i, f.Name+" (other point)", *alt)
+			}
+			set(i, f.Name+" (invalid point)", eccutil.Point{X: x.X, Y: new(big.Int).Add(x.Y, big.NewInt(1))})
+		case []byte:
+			if len(x) > 0 {
+				y := append([]byte{}, x...)
+				y[len(y)-1] ^= 1
+				set(i, f.Name, y)
+			}
+		}
+	}
+	return tampered
+}
+
+func (s Suite) testTamper(t *testing.T) {
+	ss := s.newSession(t)
+	if !verify(t, ss.client, ss.cs, ss.cmOut) {
+		t.Fatal("Signature does not verify")
+	}
+	other := s.NewClearMessage(append(ss.message, 0))
+	if verify(t, ss.client, ss.cs, other) {
+		t.Error("Signature verifies for other message")
+	}
+	alt := s.newSession(t).pubKey
+	for name, cs := range tamper(ss.cs, alt) {
+		if verify(t, ss.client, cs, ss.cmOut) {
+			t.Errorf("Signature with tampered %s verifies", name)
+		}
+	}
+	for name, bs := range tamper(ss.bs, alt) {
+		var cs genericblinding.ClearSignature
+		var cm genericblinding.ClearMessage
+		err := protect(func() (err error) {
+			cs, cm, err = ss.client.Unblind(ss.bf, ss.cm, bs)
+			return err
+		})
+		if err != nil {
+			if _, ok := err.(panicError); ok {
+				t.Errorf("Unblind of blind signature with tampered %s: %s", name, err)
+			}
+			continue
+		}
+		if verify(t, ss.client, cs, cm) {
+			t.Errorf("Signature from blind signature with tampered %s verifies", name)
+		}
+	}
+}
+
+// batchFailed checks that err is a BatchError for n items in which exactly the items in failed have failed
+func batchFailed(t *testing.T, name string, err error, n int, failed ...int) {
+	be, ok := err.(genericblinding.BatchError)
+	if !ok || len(be) != n {
+		t.Errorf("%s must return BatchError for %d items: %v", name, n, err)
+		return
+	}
+	for i, err := range be {
+		expect := false
+		for _, f := range failed {
+			expect = expect || f == i
+		}
+		if expect && err == nil {
+			t.Errorf("%s did not report failed item %d", name, i)
+		}
+		if !expect && err != nil {
+			t.Errorf("%s reported valid item %d: %s", name, i, err)
+		}
+	}
+}
+
+func (s Suite) testBatch(t *testing.T) {
+	const n = 4
+	server, client, pubKey, err := s.NewPair()
+	if err != nil {
+		t.Fatalf("NewPair failed: %s", err)
+	}
+	bserver := genericblinding.ServerWithBatch(server)
+	bclient := genericblinding.ClientWithBatch(client)
+	cms := make([]genericblinding.ClearMessage, n)
+	bfs := make([]genericblinding.BlindingFactors, n)
+	bms := make([]genericblinding.BlindMessage, n)
+	err = protect(func() error {
+		bpcs, bpss, err := bserver.GetParamsBatch(n)
+		if err != nil {
+			return fmt.Errorf("GetParamsBatch: %s", err)
+		}
+		if len(bpcs) != n || len(bpss) != n {
+			return fmt.Errorf("GetParamsBatch returned %d/%d parameters instead of %d", len(bpcs), len(bpss), n)
+		}
+		for i := range cms {
+			message := make([]byte, 32)
+			_, err = rand.Read(message)
+			if err != nil {
+				return err
+			}
+			cms[i] = s.NewClearMessage(message)
+			bfs[i], bms[i], err = client.Blind(bpcs[i], cms[i])
+			if err != nil {
+				return fmt.Errorf("Blind: %s", err)
+			}
+		}
+
+		bad := append([]genericblinding.BlindMessage{}, bms...)
+		bad[1] = newForeignData(genericblinding.TypeBlindMessage, pubKey)
+		sigs, err := bserver.SignBatch(bpss, bad)
+		if sigs != nil {
+			t.Error("SignBatch returned signatures although validation failed")
+		}
+		batchFailed(t, "SignBatch", err, n, 1)
+		_, err = bserver.SignBatch(bpss[1:], bms)
+		if err != genericblinding.ErrBatchLength {
+			t.Errorf("SignBatch must fail with ErrBatchLength: %v", err)
+		}
+
+		if s.OneTimeParams {
+			dup := append([]genericblinding.BlindingParamServer{}, bpss...)
+			dup[3] = dup[2]
+			sigs, err = bserver.SignBatch(dup, bms)
+			if sigs != nil {
+				t.Error("SignBatch returned signatures although parameters are reused")
+			}
+			batchFailed(t, "SignBatch with reused parameters", err, n, 3)
+		}
+		bss, err := bserver.SignBatch(bpss, bms)
+		if err != nil {
+			return fmt.Errorf("SignBatch after failed validation: %s", err)
+		}
+		if s.OneTimeParams {
+			sigs, err = bserver.SignBatch(bpss, bms)
+			if sigs != nil || err == nil {
+				t.Error("SignBatch must fail for parameters it has signed with")
+			}
+		}
+
+		css := make([]genericblinding.ClearSignature, n)
+		cmOuts := make([]genericblinding.ClearMessage, n)
+		for i := range bss {
+			css[i], cmOuts[i], err = client.Unblind(bfs[i], cms[i], bss[i])
+			if err != nil {
+				return fmt.Errorf("Unblind: %s", err)
+			}
+		}
+		ok, err := bclient.VerifyBatch(css, cmOuts)
+		if !ok || err != nil {
+			t.Errorf("VerifyBatch failed for valid signatures: %v", err)
+		}
+		cmOuts[2], cmOuts[3] = cmOuts[3], cmOuts[2]
+		ok, err = bclient.VerifyBatch(css, cmOuts)
+		if ok {
+			t.Error("VerifyBatch accepted signatures of swapped messages")
+		}
+		batchFailed(t, "VerifyBatch", err, n, 2, 3)
+		_, err = bclient.VerifyBatch(css, cmOuts[1:])
+		if err != genericblinding.ErrBatchLength {
+			t.Errorf("VerifyBatch must fail with ErrBatchLength: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Batch failed: %s", err)
+	}
+}
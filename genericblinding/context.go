@@ -0,0 +1,125 @@
+package genericblinding
+
+import (
+	"context"
+	"time"
+)
+
+// BlindingClientContext is a BlindingClient whose operations can be cancelled through a context
+type BlindingClientContext interface {
+	// BlindContext blinds a ClearMessage with server-supplied BlindingParamClient
+	BlindContext(context.Context, BlindingParamClient, ClearMessage) (BlindingFactors, BlindMessage, error)
+	// UnblindContext unblinds a BlindSignature of ClearMessage using BlindingFactors
+	UnblindContext(context.Context, BlindingFactors, ClearMessage, BlindSignature) (ClearSignature, ClearMessage, error)
+	// VerifyContext verifies that ClearSignature is a signature of ClearMessage
+	VerifyContext(context.Context, ClearSignature, ClearMessage) (bool, error)
+}
+
+// BlindingServerContext is a BlindingServer whose operations can be cancelled through a context
+type BlindingServerContext interface {
+	// GetParamsContext generates one-time BlindingParam
+	GetParamsContext(context.Context) (BlindingParamClient, BlindingParamServer, error)
+	// SignContext signs a BlindMessage usign BlindingParam
+	SignContext(context.Context, BlindingParamServer, BlindMessage) (BlindSignature, error)
+}
+
+// ClientWithContext returns client as BlindingClientContext. Clients that do not implement BlindingClientContext
+// are wrapped in an adapter that checks the context before each call but cannot interrupt a call once started
+func ClientWithContext(client BlindingClient) BlindingClientContext {
+	if c, ok := client.(BlindingClientContext); ok {
+		return c
+	}
+	return clientAdapter{client}
+}
+
+// ServerWithContext returns server as BlindingServerContext. Servers that do not implement BlindingServerContext
+// are wrapped in an adapter that checks the context before each call but cannot interrupt a call once started
+func ServerWithContext(server BlindingServer) BlindingServerContext {
+	if s, ok := server.(BlindingServerContext); ok {
+		return s
+	}
+	return serverAdapter{server}
+}
+
+// clientAdapter implements BlindingClientContext for a BlindingClient
+type clientAdapter struct {
+	client BlindingClient
+}
+
+func (ca clientAdapter) BlindContext(ctx context.Context, bpc BlindingParamClient, cm ClearMessage) (BlindingFactors, BlindMessage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	return ca.client.Blind(bpc, cm)
+}
+
+func (ca clientAdapter) UnblindContext(ctx context.Context, bf BlindingFactors, cm ClearMessage, bs BlindSignature) (ClearSignature, ClearMessage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	return ca.client.Unblind(bf, cm, bs)
+}
+
+func (ca clientAdapter) VerifyContext(ctx context.Context, cs ClearSignature, cm ClearMessage) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return ca.client.Verify(cs, cm)
+}
+
+// serverAdapter implements BlindingServerContext for a BlindingServer
+type serverAdapter struct {
+	server BlindingServer
+}
+
+func (sa serverAdapter) GetParamsContext(ctx context.Context) (BlindingParamClient, BlindingParamServer, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	return sa.server.GetParams()
+}
+
+func (sa serverAdapter) SignContext(ctx context.Context, bps BlindingParamServer, bm BlindMessage) (BlindSignature, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return sa.server.Sign(bps, bm)
+}
+
+// GetParamsContext is GetParams with a context
+func (ss *StoredServer) GetParamsContext(ctx context.Context) (id []byte, bpc BlindingParamClient, err error) {
+	bpc, bps, err := ServerWithContext(ss.Server).GetParamsContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	start := time.Now()
+	id, err = ss.Store.Put(bps)
+	if err != nil {
+		ss.observe(bps, OpGetParams, start, err)
+		return nil, nil, err
+	}
+	return id, bpc, nil
+}
+
+// SignContext is Sign with a context. The parameters are not consumed if ctx is done before signing starts
+func (ss *StoredServer) SignContext(ctx context.Context, id []byte, bm BlindMessage) (BlindSignature, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	bps, err := ss.Store.Take(id)
+	if err != nil {
+		ss.observe(bm, OpSign, start, err)
+		return nil, err
+	}
+	return ServerWithContext(ss.Server).SignContext(ctx, bps, bm)
+}
+
+// observe reports a failure of the ParamStore in op, that started at start, to the Observer under the scheme of bd
+func (ss *StoredServer) observe(bd BlindingData, op string, start time.Time, err error) {
+	if ss.Observer == nil || bd == nil {
+		return
+	}
+	scheme, _, _ := bd.SchemeData()
+	Observe(ss.Observer, scheme, op, start, err)
+}
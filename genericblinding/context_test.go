@@ -0,0 +1,43 @@
+package genericblinding
+
+import (
+	"context"
+	"testing"
+)
+
+// testServer is a BlindingServer without context support
+type testServer struct {
+	calls int
+}
+
+func (ts *testServer) GetParams() (BlindingParamClient, BlindingParamServer, error) {
+	ts.calls++
+	return nil, nil, nil
+}
+
+func (ts *testServer) Sign(bps BlindingParamServer, bm BlindMessage) (BlindSignature, error) {
+	ts.calls++
+	return nil, nil
+}
+
+func Test_ServerWithContext(t *testing.T) {
+	ts := new(testServer)
+	s := ServerWithContext(ts)
+	_, _, err := s.GetParamsContext(context.Background())
+	if err != nil || ts.calls != 1 {
+		t.Errorf("Adapter did not call server: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = s.GetParamsContext(ctx)
+	if err != context.Canceled {
+		t.Errorf("Adapter must fail for cancelled context: %v", err)
+	}
+	_, err = s.SignContext(ctx, nil, nil)
+	if err != context.Canceled {
+		t.Errorf("Adapter must fail for cancelled context: %v", err)
+	}
+	if ts.calls != 1 {
+		t.Error("Adapter called server with cancelled context")
+	}
+}
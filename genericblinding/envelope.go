@@ -0,0 +1,141 @@
+package genericblinding
+
+// All BlindingData is marshalled inside an Envelope that carries a format version, the scheme, the DataType,
+// the curve and an identifier of the signer key. The scheme is identified by its registered SchemeName since
+// no object identifiers have been assigned to the schemes.
+//
+// Upgrading: Data written before the Envelope existed is a bare ASN.1 SEQUENCE starting with the SchemeName.
+// UnmarshalEnvelope detects and accepts it, so stored artifacts remain readable. Upgrade re-encodes such data
+// in the current format. Support for bare encodings will be dropped in a later version.
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"errors"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+)
+
+// EnvelopeVersion is the version of the Envelope format written by MarshalEnvelope
+const EnvelopeVersion = 1
+
+var (
+	// ErrEnvelopeVersion is returned when unmarshalling an Envelope of unknown version
+	ErrEnvelopeVersion = errors.New("blinding: Unsupported envelope version")
+	// ErrBadEnvelope is returned if the Envelope does not match its payload
+	ErrBadEnvelope = errors.New("blinding: Envelope does not match payload")
+)
+
+// Envelope is the outer encoding of all marshalled BlindingData
+type Envelope struct {
+	Version  int
+	Scheme   string
+	DataType DataType
+	Curve    asn1.ObjectIdentifier `asn1:"optional"` // Missing if the curve of the signer is unknown
+	KeyID    []byte                // eccutil.KeyID of the signer. Empty for data without signer
+	Payload  []byte                // ASN.1 DER encoding of the BlindingData
+}
+
+// MarshalEnvelope returns the ASN.1 DER encoding of bd wrapped in an Envelope
+func MarshalEnvelope(bd BlindingData) ([]byte, error) {
+	payload, err := asn1.Marshal(bd)
+	if err != nil {
+		return nil, err
+	}
+	scheme, dataType, pubKey := bd.SchemeData()
+	e := Envelope{
+		Version:  EnvelopeVersion,
+		Scheme:   scheme,
+		DataType: dataType,
+		KeyID:    []byte{},
+		Payload:  payload,
+	}
+	if pubKey != nil {
+		e.KeyID = eccutil.KeyID(pubKey)
+		if curve := eccutil.CurveOfPoint(pubKey); curve != nil {
+			e.Curve, _ = eccutil.CurveOID(curve)
+		}
+	}
+	return asn1.Marshal(e)
+}
+
+// OpenEnvelope parses an Envelope without looking at the payload. Bare encodings from before the
+// Envelope are returned as an Envelope of Version 0 that only has Scheme, DataType and Payload set
+func OpenEnvelope(b []byte) (*Envelope, error) {
+	outer := new(asn1.RawValue)
+	rest, err := asn1.Unmarshal(b, outer)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, asn1.SyntaxError{Msg: "trailing data"}
+	}
+	first := new(asn1.RawValue)
+	_, err = asn1.Unmarshal(outer.Bytes, first)
+	if err != nil {
+		return nil, err
+	}
+	e := new(Envelope)
+	if first.Tag != asn1.TagInteger {
+		h := new(header)
+		_, err = asn1.Unmarshal(b, h)
+		if err != nil {
+			return nil, err
+		}
+		e.Scheme, e.DataType, e.Payload = h.SchemeName, h.DataType, b
+		return e, nil
+	}
+	var version int
+	_, err = asn1.Unmarshal(first.FullBytes, &version)
+	if err != nil {
+		return nil, err
+	}
+	if version != EnvelopeVersion {
+		return nil, ErrEnvelopeVersion
+	}
+	_, err = asn1.Unmarshal(b, e)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// UnmarshalEnvelope opens the Envelope in b and unmarshals its payload into v, which must be a pointer to
+// a BlindingData struct. The Envelope must agree with the payload
+func UnmarshalEnvelope(b []byte, v BlindingData) error {
+	e, err := OpenEnvelope(b)
+	if err != nil {
+		return err
+	}
+	_, err = asn1.Unmarshal(e.Payload, v)
+	if err != nil {
+		return err
+	}
+	scheme, dataType, pubKey := v.SchemeData()
+	if e.Scheme != scheme || e.DataType != dataType {
+		return ErrBadEnvelope
+	}
+	if e.Version == 0 || pubKey == nil {
+		return nil
+	}
+	if !bytes.Equal(e.KeyID, eccutil.KeyID(pubKey)) {
+		return ErrBadEnvelope
+	}
+	if curve := eccutil.CurveOfPoint(pubKey); curve != nil {
+		oid, _ := eccutil.CurveOID(curve)
+		if !oid.Equal(e.Curve) {
+			return ErrBadEnvelope
+		}
+	}
+	return nil
+}
+
+// Upgrade converts marshalled BlindingData of a registered scheme, in bare or Envelope encoding, to the
+// current Envelope format
+func Upgrade(b []byte, pubKey *eccutil.Point) ([]byte, error) {
+	bd, err := Decode(b, pubKey)
+	if err != nil {
+		return nil, err
+	}
+	return bd.Marshal()
+}
@@ -0,0 +1,143 @@
+package genericblinding
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/asn1"
+	"testing"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+)
+
+// testData is a minimal BlindingData for testing
+type testData struct {
+	SchemeName string
+	DataType   DataType
+	PubKey     eccutil.Point
+	Value      []byte
+}
+
+func newTestData(pubKey *eccutil.Point) testData {
+	return testData{SchemeName: "TST", DataType: TypeBlindMessage, PubKey: *pubKey, Value: []byte("test value")}
+}
+
+func (td testData) SchemeData() (string, DataType, *eccutil.Point) {
+	return td.SchemeName, td.DataType, &td.PubKey
+}
+
+func (td testData) Marshal() ([]byte, error) {
+	return MarshalEnvelope(td)
+}
+
+func (td testData) Unmarshal(b []byte) (BlindingData, error) {
+	n := new(testData)
+	err := UnmarshalEnvelope(b, n)
+	if err != nil {
+		return nil, err
+	}
+	_, err = MatchMessage(n, td.SchemeName, td.DataType, &td.PubKey)
+	if err != nil {
+		return nil, err
+	}
+	return *n, nil
+}
+
+func (td testData) UniqueID() []byte {
+	return append(eccutil.KeyID(&td.PubKey), td.Value...)
+}
+
+func testKey(t *testing.T) *eccutil.Point {
+	c := eccutil.SetCurve(elliptic.P256, rand.Reader, eccutil.Sha1Hash)
+	_, pubkey, err := c.GenerateKey()
+	if err != nil {
+		t.Fatalf("Error creating keys: %s", err)
+	}
+	return pubkey
+}
+
+func Test_Envelope(t *testing.T) {
+	pubkey := testKey(t)
+	td := newTestData(pubkey)
+	b, err := td.Marshal()
+	if err != nil {
+		t.Fatalf("Marshalling failed: %s", err)
+	}
+	e, err := OpenEnvelope(b)
+	if err != nil {
+		t.Fatalf("OpenEnvelope failed: %s", err)
+	}
+	if e.Version != EnvelopeVersion || e.Scheme != "TST" || e.DataType != TypeBlindMessage {
+		t.Errorf("Envelope header wrong: %d %s %d", e.Version, e.Scheme, e.DataType)
+	}
+	oid, _ := eccutil.CurveOID(elliptic.P256())
+	if !e.Curve.Equal(oid) {
+		t.Errorf("Envelope curve wrong: %s", e.Curve)
+	}
+	_, err = td.Unmarshal(b)
+	if err != nil {
+		t.Fatalf("UnMarshalling failed: %s", err)
+	}
+	_, err = newTestData(testKey(t)).Unmarshal(b)
+	if err != ErrBadSigner {
+		t.Errorf("UnMarshalling must fail for foreign signer: %v", err)
+	}
+}
+
+func Test_EnvelopeLegacy(t *testing.T) {
+	td := newTestData(testKey(t))
+	b, err := asn1.Marshal(td)
+	if err != nil {
+		t.Fatalf("Marshalling failed: %s", err)
+	}
+	_, err = td.Unmarshal(b)
+	if err != nil {
+		t.Fatalf("UnMarshalling of bare encoding failed: %s", err)
+	}
+	e, err := OpenEnvelope(b)
+	if err != nil {
+		t.Fatalf("OpenEnvelope failed: %s", err)
+	}
+	if e.Version != 0 || e.Scheme != "TST" {
+		t.Errorf("Bare encoding not detected: %d %s", e.Version, e.Scheme)
+	}
+}
+
+func Test_EnvelopeVersion(t *testing.T) {
+	td := newTestData(testKey(t))
+	payload, err := asn1.Marshal(td)
+	if err != nil {
+		t.Fatalf("Marshalling failed: %s", err)
+	}
+	b, err := asn1.Marshal(Envelope{Version: EnvelopeVersion + 1, Scheme: "TST", DataType: TypeBlindMessage, KeyID: eccutil.KeyID(&td.PubKey), Payload: payload})
+	if err != nil {
+		t.Fatalf("Marshalling failed: %s", err)
+	}
+	_, err = td.Unmarshal(b)
+	if err != ErrEnvelopeVersion {
+		t.Errorf("UnMarshalling must fail for unknown version: %v", err)
+	}
+}
+
+func Test_EnvelopeMismatch(t *testing.T) {
+	td := newTestData(testKey(t))
+	payload, err := asn1.Marshal(td)
+	if err != nil {
+		t.Fatalf("Marshalling failed: %s", err)
+	}
+	oid, _ := eccutil.CurveOID(elliptic.P256())
+	for _, e := range []Envelope{
+		{Version: EnvelopeVersion, Scheme: "XXX", DataType: TypeBlindMessage, Curve: oid, KeyID: eccutil.KeyID(&td.PubKey), Payload: payload},
+		{Version: EnvelopeVersion, Scheme: "TST", DataType: TypeBlindSignature, Curve: oid, KeyID: eccutil.KeyID(&td.PubKey), Payload: payload},
+		{Version: EnvelopeVersion, Scheme: "TST", DataType: TypeBlindMessage, Curve: oid, KeyID: make([]byte, 32), Payload: payload},
+		{Version: EnvelopeVersion, Scheme: "TST", DataType: TypeBlindMessage, KeyID: eccutil.KeyID(&td.PubKey), Payload: payload},
+	} {
+		b, err := asn1.Marshal(e)
+		if err != nil {
+			t.Fatalf("Marshalling failed: %s", err)
+		}
+		_, err = td.Unmarshal(b)
+		if err != ErrBadEnvelope {
+			t.Errorf("UnMarshalling must fail for mismatching envelope: %v", err)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package genericblinding
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// File name suffixes of FileParamStore
+const (
+	paramSuffix = ".param"
+	usedSuffix  = ".used"
+	tempPrefix  = "tmp-"
+)
+
+// FileParamStore is a ParamStore that keeps each parameter set in its own file in a directory. Parameters are
+// taken by renaming their file, which is atomic even between processes sharing the directory. The modification
+// time of the file is the time the parameters were stored
+type FileParamStore struct {
+	dir      string
+	ttl      time.Duration
+	template BlindingParamServer
+	now      func() time.Time
+}
+
+// NewFileParamStore returns a FileParamStore in dir for parameters that expire after ttl. Stored parameters are
+// unmarshalled with template, which is usually created by the NewBlindingParamServer function of the scheme
+func NewFileParamStore(dir string, ttl time.Duration, template BlindingParamServer) (*FileParamStore, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+	ps := new(FileParamStore)
+	ps.dir = dir
+	ps.ttl = ttl
+	ps.template = template
+	ps.now = time.Now
+	return ps, nil
+}
+
+// path returns the file name for id with suffix
+func (ps *FileParamStore) path(id []byte, suffix string) string {
+	return filepath.Join(ps.dir, hex.EncodeToString(id)+suffix)
+}
+
+// Put stores bps and returns a new random ID for it
+func (ps *FileParamStore) Put(bps BlindingParamServer) ([]byte, error) {
+	err := checkParams(bps)
+	if err != nil {
+		return nil, err
+	}
+	b, err := bps.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	id, err := newParamID()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.CreateTemp(ps.dir, tempPrefix)
+	if err != nil {
+		return nil, err
+	}
+	_, err = f.Write(b)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		now := ps.now()
+		err = os.Chtimes(f.Name(), now, now)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), ps.path(id, paramSuffix))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return nil, err
+	}
+	return id, nil
+}
+
+// Take returns the parameters stored under id and marks them used
+func (ps *FileParamStore) Take(id []byte) (BlindingParamServer, error) {
+	if len(id) != ParamIDSize {
+		return nil, ErrParamUnknown
+	}
+	used := ps.path(id, usedSuffix)
+	err := os.Rename(ps.path(id, paramSuffix), used)
+	if os.IsNotExist(err) {
+		if _, err := os.Stat(used); err == nil {
+			return nil, ErrParamUsed
+		}
+		return nil, ErrParamUnknown
+	}
+	if err != nil {
+		return nil, err
+	}
+	fi, err := os.Stat(used)
+	if err != nil {
+		return nil, err
+	}
+	if ps.now().After(fi.ModTime().Add(ps.ttl)) {
+		return nil, ErrParamExpired
+	}
+	b, err := os.ReadFile(used)
+	if err != nil {
+		return nil, err
+	}
+	return ps.template.Unmarshal(b)
+}
+
+// Expire removes the files of all expired parameters, used or not, and temporary files left by failed Puts
+func (ps *FileParamStore) Expire() error {
+	entries, err := os.ReadDir(ps.dir)
+	if err != nil {
+		return err
+	}
+	now := ps.now()
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasSuffix(name, paramSuffix) && !strings.HasSuffix(name, usedSuffix) && !strings.HasPrefix(name, tempPrefix) {
+			continue
+		}
+		fi, err := e.Info()
+		if os.IsNotExist(err) { // Taken or expired concurrently
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if now.After(fi.ModTime().Add(ps.ttl)) {
+			err = os.Remove(filepath.Join(ps.dir, name))
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package genericblinding
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"reflect"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+)
+
+// JSON encoding of BlindingData structs: Every exported field is encoded under its Go name. Byte slices and big
+// integers are unpadded base64url (big integers big-endian, negative ones prefixed with "~"), points are SEC1
+// encoded and then base64url. The point (0,0) is encoded as the SEC1 point at infinity. All fields are required.
+
+var (
+	// ErrJSONField is returned if a JSON field is missing or malformed
+	ErrJSONField = errors.New("blinding: Missing or malformed JSON field")
+	// ErrJSONKind is returned if a BlindingData struct contains a field that has no JSON encoding
+	ErrJSONKind = errors.New("blinding: Field cannot be encoded as JSON")
+)
+
+var (
+	typeBigInt   = reflect.TypeOf((*big.Int)(nil))
+	typePoint    = reflect.TypeOf(eccutil.Point{})
+	typeBytes    = reflect.TypeOf([]byte(nil))
+	typeDataType = reflect.TypeOf(DataType(0))
+)
+
+var b64 = base64.RawURLEncoding
+
+// encodeBigInt returns the base64url encoding of i
+func encodeBigInt(i *big.Int) string {
+	if i.Sign() < 0 {
+		return "~" + b64.EncodeToString(new(big.Int).Neg(i).Bytes())
+	}
+	return b64.EncodeToString(i.Bytes())
+}
+
+// decodeBigInt parses a big integer produced by encodeBigInt
+func decodeBigInt(s string) (*big.Int, error) {
+	neg := len(s) > 0 && s[0] == '~'
+	if neg {
+		s = s[1:]
+	}
+	d, err := b64.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	i := new(big.Int).SetBytes(d)
+	if neg {
+		i = i.Neg(i)
+	}
+	return i, nil
+}
+
+// EncodePoint returns the uncompressed SEC1 encoding of p. Coordinates are padded to the size of the curve
+// of p if it is known. (0,0) is returned as point at infinity
+func EncodePoint(p *eccutil.Point) []byte {
+	if p.X.Sign() == 0 && p.Y.Sign() == 0 {
+		return []byte{0}
+	}
+	x, y := p.X.Bytes(), p.Y.Bytes()
+	size := len(x)
+	if len(y) > size {
+		size = len(y)
+	}
+	if curve := eccutil.CurveOfPoint(p); curve != nil {
+		size = (curve.Params().BitSize + 7) / 8
+	}
+	d := make([]byte, 1+2*size)
+	d[0] = 4
+	copy(d[1+size-len(x):], x)
+	copy(d[1+2*size-len(y):], y)
+	return d
+}
+
+// DecodePoint parses a point produced by EncodePoint
+func DecodePoint(d []byte) (*eccutil.Point, error) {
+	if len(d) == 1 && d[0] == 0 {
+		return eccutil.ZeroPoint(), nil
+	}
+	if len(d) < 3 || len(d)%2 != 1 || d[0] != 4 {
+		return nil, eccutil.ErrBadCoordinate
+	}
+	size := (len(d) - 1) / 2
+	return eccutil.NewPoint(new(big.Int).SetBytes(d[1:1+size]), new(big.Int).SetBytes(d[1+size:])), nil
+}
+
+// jsonValue returns the JSON representation of a single field
+func jsonValue(v reflect.Value) (interface{}, error) {
+	switch v.Type() {
+	case typeBigInt:
+		if v.IsNil() {
+			return nil, ErrJSONField
+		}
+		return encodeBigInt(v.Interface().(*big.Int)), nil
+	case typePoint:
+		p := v.Interface().(eccutil.Point)
+		if p.X == nil || p.Y == nil {
+			return nil, ErrJSONField
+		}
+		return b64.EncodeToString(EncodePoint(&p)), nil
+	case typeBytes:
+		return b64.EncodeToString(v.Bytes()), nil
+	case typeDataType:
+		return v.Int(), nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	}
+	return nil, ErrJSONKind
+}
+
+// setJSONValue parses a single field produced by jsonValue into v
+func setJSONValue(v reflect.Value, raw json.RawMessage) error {
+	var s string
+	switch v.Type() {
+	case typeBigInt, typePoint, typeBytes:
+		if err := json.Unmarshal(raw, &s); err != nil || string(raw) == "null" {
+			return ErrJSONField
+		}
+	}
+	switch v.Type() {
+	case typeBigInt:
+		i, err := decodeBigInt(s)
+		if err != nil {
+			return ErrJSONField
+		}
+		v.Set(reflect.ValueOf(i))
+		return nil
+	case typePoint:
+		d, err := b64.DecodeString(s)
+		if err != nil {
+			return ErrJSONField
+		}
+		p, err := DecodePoint(d)
+		if err != nil {
+			return ErrJSONField
+		}
+		v.Set(reflect.ValueOf(*p))
+		return nil
+	case typeBytes:
+		d, err := b64.DecodeString(s)
+		if err != nil {
+			return ErrJSONField
+		}
+		v.SetBytes(d)
+		return nil
+	case typeDataType:
+		var i DataType
+		if err := json.Unmarshal(raw, &i); err != nil {
+			return ErrJSONField
+		}
+		v.SetInt(int64(i))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String, reflect.Bool:
+		if err := json.Unmarshal(raw, v.Addr().Interface()); err != nil {
+			return ErrJSONField
+		}
+		return nil
+	}
+	return ErrJSONKind
+}
+
+// MarshalJSON returns the JSON encoding of bd, which must be a BlindingData struct
+func MarshalJSON(bd BlindingData) ([]byte, error) {
+	v := reflect.Indirect(reflect.ValueOf(bd))
+	if v.Kind() != reflect.Struct {
+		return nil, ErrJSONKind
+	}
+	m := make(map[string]interface{}, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		if f.PkgPath != "" { // unexported
+			continue
+		}
+		x, err := jsonValue(v.Field(i))
+		if err != nil {
+			return nil, err
+		}
+		m[f.Name] = x
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON parses JSON produced by MarshalJSON into v, a pointer to a BlindingData struct. Scheme,
+// DataType and signer of the result must match template
+func UnmarshalJSON(b []byte, v BlindingData, template BlindingData) error {
+	pv := reflect.ValueOf(v)
+	if pv.Kind() != reflect.Ptr || pv.Elem().Kind() != reflect.Struct {
+		return ErrJSONKind
+	}
+	scheme, dataType, pubKey := template.SchemeData()
+	h := new(header)
+	err := json.Unmarshal(b, h)
+	if err != nil {
+		return err
+	}
+	if h.SchemeName != scheme {
+		return ErrBadScheme
+	}
+	if h.DataType != dataType {
+		return ErrBadType
+	}
+	m := make(map[string]json.RawMessage)
+	err = json.Unmarshal(b, &m)
+	if err != nil {
+		return err
+	}
+	sv := pv.Elem()
+	for i := 0; i < sv.NumField(); i++ {
+		f := sv.Type().Field(i)
+		if f.PkgPath != "" { // unexported
+			continue
+		}
+		raw, ok := m[f.Name]
+		if !ok {
+			return ErrJSONField
+		}
+		err = setJSONValue(sv.Field(i), raw)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = MatchMessage(v, scheme, dataType, pubKey)
+	return err
+}
+
+// DecodeJSON parses JSON encoded BlindingData of any registered scheme. pubKey is the expected signer and may
+// only be nil for types that do not carry a signer (ClearMessage)
+func DecodeJSON(b []byte, pubKey *eccutil.Point) (BlindingData, error) {
+	h := new(header)
+	err := json.Unmarshal(b, h)
+	if err != nil {
+		return nil, err
+	}
+	constructor, err := lookup(h.SchemeName, h.DataType)
+	if err != nil {
+		return nil, err
+	}
+	if pubKey == nil && h.DataType != TypeClearMessage {
+		return nil, ErrBadSigner
+	}
+	template := constructor(pubKey)
+	n := reflect.New(reflect.TypeOf(template))
+	err = UnmarshalJSON(b, n.Interface().(BlindingData), template)
+	if err != nil {
+		return nil, err
+	}
+	return n.Elem().Interface().(BlindingData), nil
+}
@@ -0,0 +1,66 @@
+package genericblinding
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+)
+
+func Test_JSONBigInt(t *testing.T) {
+	for _, i := range []*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(-1), new(big.Int).Lsh(big.NewInt(-0xf8), 248)} {
+		j, err := decodeBigInt(encodeBigInt(i))
+		if err != nil {
+			t.Fatalf("Decoding %s failed: %s", i, err)
+		}
+		if j.Cmp(i) != 0 {
+			t.Errorf("Round trip of %s returned %s", i, j)
+		}
+	}
+}
+
+func Test_JSONPoint(t *testing.T) {
+	pubkey := testKey(t)
+	for _, p := range []*eccutil.Point{pubkey, eccutil.ZeroPoint(), eccutil.NewPoint(big.NewInt(1), big.NewInt(0x1234))} {
+		d := EncodePoint(p)
+		q, err := DecodePoint(d)
+		if err != nil {
+			t.Fatalf("Decoding point failed: %s", err)
+		}
+		if !eccutil.PointEqual(p, q) {
+			t.Errorf("Round trip of point failed")
+		}
+	}
+	if len(EncodePoint(pubkey)) != 65 {
+		t.Error("SEC1 encoding not padded to curve size")
+	}
+	_, err := DecodePoint([]byte{4, 1})
+	if err == nil {
+		t.Error("Malformed point must not decode")
+	}
+}
+
+func Test_JSON(t *testing.T) {
+	td := newTestData(testKey(t))
+	j, err := MarshalJSON(td)
+	if err != nil {
+		t.Fatalf("JSON marshalling failed: %s", err)
+	}
+	n := new(testData)
+	err = UnmarshalJSON(j, n, td)
+	if err != nil {
+		t.Fatalf("JSON unmarshalling failed: %s", err)
+	}
+	if string(n.Value) != string(td.Value) {
+		t.Error("JSON round trip failed")
+	}
+	m := make(map[string]interface{})
+	json.Unmarshal(j, &m)
+	delete(m, "Value")
+	j, _ = json.Marshal(m)
+	err = UnmarshalJSON(j, n, td)
+	if err != ErrJSONField {
+		t.Errorf("JSON unmarshalling must fail for missing field: %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package genericblinding
+
+import (
+	"time"
+)
+
+// Operations reported to an Observer
+const (
+	OpGetParams = "getParams"
+	OpSign      = "sign"
+	OpBlind     = "blind"
+	OpUnblind   = "unblind"
+	OpVerify    = "verify"
+)
+
+// Observer receives measurements from the generic servers and clients of the schemes. Implementations must be
+// safe for concurrent use and must not block
+type Observer interface {
+	// Observe is called when op of scheme has finished after d with err, which is nil on success
+	Observe(scheme, op string, d time.Duration, err error)
+	// Retry is called each time the parameter search of op of scheme discards a candidate and tries again
+	Retry(scheme, op string)
+}
+
+// Observe reports op of scheme that started at start and ended with err to o. o may be nil
+func Observe(o Observer, scheme, op string, start time.Time, err error) {
+	if o != nil {
+		o.Observe(scheme, op, time.Since(start), err)
+	}
+}
+
+// ObserveRetry reports a retry of op of scheme to o. o may be nil
+func ObserveRetry(o Observer, scheme, op string) {
+	if o != nil {
+		o.Retry(scheme, op)
+	}
+}
+
+// ObserveVerify reports a verification of scheme that started at start and ended with ok and err to o. A
+// signature that does not verify is reported as ErrBadSignature. o may be nil
+func ObserveVerify(o Observer, scheme string, start time.Time, ok bool, err error) {
+	if err == nil && !ok {
+		err = ErrBadSignature
+	}
+	Observe(o, scheme, OpVerify, start, err)
+}
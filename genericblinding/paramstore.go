@@ -0,0 +1,197 @@
+package genericblinding
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+)
+
+// ParamIDSize is the size of the IDs returned by ParamStore.Put
+const ParamIDSize = 16
+
+var (
+	// ErrParamUnknown is returned if no parameters are stored under an ID
+	ErrParamUnknown = errors.New("blinding: Unknown parameter ID")
+	// ErrParamExpired is returned if parameters were not used before their expiry
+	ErrParamExpired = errors.New("blinding: Parameters expired")
+	// ErrParamUsed is returned if parameters have already been taken from the store
+	ErrParamUsed = errors.New("blinding: Parameters already used")
+)
+
+// ParamStore keeps the server half of blinding parameters between GetParams and Sign. Parameters can be taken
+// only once and expire after the time-to-live of the store
+type ParamStore interface {
+	// Put stores bps and returns a new random ID for it
+	Put(bps BlindingParamServer) (id []byte, err error)
+	// Take returns the parameters stored under id and marks them used. Only one caller can take an id
+	Take(id []byte) (BlindingParamServer, error)
+	// Expire removes all expired parameters from the store. It should be called periodically
+	Expire() error
+}
+
+// newParamID returns a random parameter ID
+func newParamID() ([]byte, error) {
+	id := make([]byte, ParamIDSize)
+	_, err := rand.Read(id)
+	if err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// checkParams verifies that bps is a BlindingParamServer
+func checkParams(bps BlindingParamServer) error {
+	if bps == nil {
+		return ErrBadType
+	}
+	_, dataType, _ := bps.SchemeData()
+	if dataType != TypeBlindingParamServer {
+		return ErrBadType
+	}
+	return nil
+}
+
+// memoryParam is an entry of a MemoryParamStore. bps is nil once the parameters have been taken
+type memoryParam struct {
+	bps     BlindingParamServer
+	expires time.Time
+}
+
+// MemoryParamStore is a ParamStore that keeps parameters in memory
+type MemoryParamStore struct {
+	ttl    time.Duration
+	lock   sync.Mutex
+	params map[string]*memoryParam
+	now    func() time.Time
+}
+
+// NewMemoryParamStore returns a MemoryParamStore for parameters that expire after ttl
+func NewMemoryParamStore(ttl time.Duration) *MemoryParamStore {
+	ps := new(MemoryParamStore)
+	ps.ttl = ttl
+	ps.params = make(map[string]*memoryParam)
+	ps.now = time.Now
+	return ps
+}
+
+// Put stores bps and returns a new random ID for it
+func (ps *MemoryParamStore) Put(bps BlindingParamServer) ([]byte, error) {
+	err := checkParams(bps)
+	if err != nil {
+		return nil, err
+	}
+	id, err := newParamID()
+	if err != nil {
+		return nil, err
+	}
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	ps.params[string(id)] = &memoryParam{bps: bps, expires: ps.now().Add(ps.ttl)}
+	return id, nil
+}
+
+// Take returns the parameters stored under id and marks them used
+func (ps *MemoryParamStore) Take(id []byte) (BlindingParamServer, error) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	p, ok := ps.params[string(id)]
+	if !ok {
+		return nil, ErrParamUnknown
+	}
+	if p.bps == nil {
+		return nil, ErrParamUsed
+	}
+	bps := p.bps
+	p.bps = nil
+	if ps.now().After(p.expires) {
+		return nil, ErrParamExpired
+	}
+	return bps, nil
+}
+
+// Expire removes all expired parameters, used or not
+func (ps *MemoryParamStore) Expire() error {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	now := ps.now()
+	for id, p := range ps.params {
+		if now.After(p.expires) {
+			delete(ps.params, id)
+		}
+	}
+	return nil
+}
+
+// StoredServer wraps a BlindingServer so that the server half of the blinding parameters never leaves the
+// server. Clients get the client half and an ID to refer to the parameters when requesting a signature
+type StoredServer struct {
+	Server   BlindingServer
+	Store    ParamStore
+	Observer Observer // Receives the failures of Store, Server reports everything else. May be nil
+}
+
+// NewStoredServer returns a StoredServer that keeps the parameters of server in store
+func NewStoredServer(server BlindingServer, store ParamStore) *StoredServer {
+	ss := new(StoredServer)
+	ss.Server = server
+	ss.Store = store
+	return ss
+}
+
+// GetParams generates one-time blinding parameters. It returns the client half and the ID of the server half
+func (ss *StoredServer) GetParams() (id []byte, bpc BlindingParamClient, err error) {
+	return ss.GetParamsContext(context.Background())
+}
+
+// Sign a BlindMessage using the parameters stored under id. The parameters are consumed even if signing fails
+func (ss *StoredServer) Sign(id []byte, bm BlindMessage) (BlindSignature, error) {
+	return ss.SignContext(context.Background(), id, bm)
+}
+
+// ParamRef is a BlindingParamServer that only refers to parameters kept in the ParamStore of a remote
+// StoredServer. Remote clients return it from GetParams. ParamRef is not registered for Decode, use its
+// Unmarshal method directly
+type ParamRef struct {
+	SchemeName string
+	DataType   DataType
+	PubKey     eccutil.Point
+	ID         []byte
+}
+
+// NewParamRef returns a ParamRef to the parameters id of the signer pubKey
+func NewParamRef(scheme string, pubKey *eccutil.Point, id []byte) ParamRef {
+	return ParamRef{SchemeName: scheme, DataType: TypeBlindingParamServer, PubKey: *pubKey, ID: id}
+}
+
+// Marshal a ParamRef
+func (pr ParamRef) Marshal() ([]byte, error) {
+	return MarshalEnvelope(pr)
+}
+
+// Unmarshal a ParamRef
+func (pr ParamRef) Unmarshal(b []byte) (BlindingData, error) {
+	n := new(ParamRef)
+	err := UnmarshalEnvelope(b, n)
+	if err != nil {
+		return nil, err
+	}
+	_, err = MatchMessage(n, pr.SchemeName, TypeBlindingParamServer, &pr.PubKey)
+	if err != nil {
+		return nil, err
+	}
+	return *n, nil
+}
+
+// UniqueID returns the ID of the parameters
+func (pr ParamRef) UniqueID() []byte {
+	return pr.ID
+}
+
+// SchemeData returns scheme, DataType and signer of the ParamRef
+func (pr ParamRef) SchemeData() (string, DataType, *eccutil.Point) {
+	return pr.SchemeName, pr.DataType, &pr.PubKey
+}
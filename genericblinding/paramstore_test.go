@@ -0,0 +1,115 @@
+package genericblinding
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testParams returns parameters for storing in a ParamStore
+func testParams(t *testing.T) testData {
+	td := newTestData(testKey(t))
+	td.DataType = TypeBlindingParamServer
+	return td
+}
+
+// testParamStore runs the tests common to all ParamStore implementations with parameters td. advance moves
+// the clock of the store
+func testParamStore(t *testing.T, ps ParamStore, td testData, advance func(time.Duration)) {
+	_, err := ps.Put(newTestData(&td.PubKey))
+	if err != ErrBadType {
+		t.Errorf("Put must fail for wrong type: %v", err)
+	}
+	id, err := ps.Put(td)
+	if err != nil {
+		t.Fatalf("Put failed: %s", err)
+	}
+	id2, err := ps.Put(td)
+	if err != nil {
+		t.Fatalf("Put failed: %s", err)
+	}
+	if bytes.Equal(id, id2) {
+		t.Error("Put returned the same ID twice")
+	}
+	bps, err := ps.Take(id)
+	if err != nil {
+		t.Fatalf("Take failed: %s", err)
+	}
+	if !bytes.Equal(bps.UniqueID(), td.UniqueID()) {
+		t.Error("Take returned different parameters")
+	}
+	_, err = ps.Take(id)
+	if err != ErrParamUsed {
+		t.Errorf("Take must fail for used parameters: %v", err)
+	}
+	_, err = ps.Take(make([]byte, ParamIDSize))
+	if err != ErrParamUnknown {
+		t.Errorf("Take must fail for unknown ID: %v", err)
+	}
+	advance(2 * time.Minute)
+	_, err = ps.Take(id2)
+	if err != ErrParamExpired {
+		t.Errorf("Take must fail for expired parameters: %v", err)
+	}
+	id3, err := ps.Put(td)
+	if err != nil {
+		t.Fatalf("Put failed: %s", err)
+	}
+	advance(2 * time.Minute)
+	err = ps.Expire()
+	if err != nil {
+		t.Fatalf("Expire failed: %s", err)
+	}
+	_, err = ps.Take(id3)
+	if err != ErrParamUnknown {
+		t.Errorf("Expire must remove expired parameters: %v", err)
+	}
+	id4, err := ps.Put(td)
+	if err != nil {
+		t.Fatalf("Put failed: %s", err)
+	}
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	taken := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := ps.Take(id4); err == nil {
+				lock.Lock()
+				taken++
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if taken != 1 {
+		t.Errorf("Parameters taken %d times", taken)
+	}
+}
+
+func Test_MemoryParamStore(t *testing.T) {
+	ps := NewMemoryParamStore(time.Minute)
+	now := time.Now()
+	ps.now = func() time.Time { return now }
+	testParamStore(t, ps, testParams(t), func(d time.Duration) { now = now.Add(d) })
+}
+
+func Test_FileParamStore(t *testing.T) {
+	td := testParams(t)
+	dir := t.TempDir()
+	ps, err := NewFileParamStore(filepath.Join(dir, "params"), time.Minute, td)
+	if err != nil {
+		t.Fatalf("NewFileParamStore failed: %s", err)
+	}
+	now := time.Now()
+	ps.now = func() time.Time { return now }
+	testParamStore(t, ps, td, func(d time.Duration) { now = now.Add(d) })
+	_, err = os.Stat(filepath.Join(dir, "params"))
+	if err != nil {
+		t.Errorf("Store directory missing: %s", err)
+	}
+}
@@ -0,0 +1,226 @@
+package genericblinding
+
+// PEM armor for marshalled BlindingData and signer keys. The block type of BlindingData is the scheme
+// followed by the name of the DataType, e.g. "JCC BLIND SIGNATURE". Headers repeat scheme, curve and key ID so
+// that a block can be identified without decoding it. They are informational only, but DecodePEM refuses a
+// block whose type or headers disagree with the embedded data.
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"math/big"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+)
+
+// PEM block types of signer keys
+const (
+	PublicKeyPEMType  = "BLIND SIGNER PUBLIC KEY"
+	PrivateKeyPEMType = "BLIND SIGNER PRIVATE KEY"
+)
+
+// PEM header names
+const (
+	PEMHeaderScheme = "Scheme"
+	PEMHeaderCurve  = "Curve"
+	PEMHeaderKeyID  = "Key-ID"
+)
+
+var (
+	// ErrNoPEM is returned if no PEM block was found
+	ErrNoPEM = errors.New("blinding: No PEM block found")
+	// ErrPEMType is returned if the PEM block type does not match the data
+	ErrPEMType = errors.New("blinding: PEM block type does not match data")
+	// ErrPEMHeader is returned if a PEM header is missing or does not match the data
+	ErrPEMHeader = errors.New("blinding: PEM header does not match data")
+	// ErrBadPublicKey is returned if a public key is not an elliptic curve key on a named curve
+	ErrBadPublicKey = errors.New("blinding: Unsupported public key")
+	// ErrBadPrivateKey is returned if a private key does not belong to its public key
+	ErrBadPrivateKey = errors.New("blinding: Private key does not match public key")
+)
+
+// pemTypeNames are the names of DataTypes used in PEM block types
+var pemTypeNames = map[DataType]string{
+	TypeBlindingParamClient: "BLINDING PARAM CLIENT",
+	TypeClearMessage:        "CLEAR MESSAGE",
+	TypeBlindingFactors:     "BLINDING FACTORS",
+	TypeBlindMessage:        "BLIND MESSAGE",
+	TypeBlindSignature:      "BLIND SIGNATURE",
+	TypeClearSignature:      "CLEAR SIGNATURE",
+	TypeBlindingParamServer: "BLINDING PARAM SERVER",
+}
+
+// PEMType returns the PEM block type for dataType of scheme
+func PEMType(scheme string, dataType DataType) (string, error) {
+	name, ok := pemTypeNames[dataType]
+	if !ok {
+		return "", ErrUnknownType
+	}
+	return scheme + " " + name, nil
+}
+
+// pemHeaders returns the headers describing scheme and signer pubKey. Scheme is omitted if empty
+func pemHeaders(scheme string, pubKey *eccutil.Point) map[string]string {
+	headers := make(map[string]string)
+	if scheme != "" {
+		headers[PEMHeaderScheme] = scheme
+	}
+	if pubKey == nil {
+		return headers
+	}
+	if curve := eccutil.CurveOfPoint(pubKey); curve != nil {
+		headers[PEMHeaderCurve] = curve.Params().Name
+	}
+	headers[PEMHeaderKeyID] = hex.EncodeToString(eccutil.KeyID(pubKey))
+	return headers
+}
+
+// checkPEMHeaders verifies that headers describe scheme and signer pubKey
+func checkPEMHeaders(headers map[string]string, scheme string, pubKey *eccutil.Point) error {
+	want := pemHeaders(scheme, pubKey)
+	for _, name := range []string{PEMHeaderScheme, PEMHeaderCurve, PEMHeaderKeyID} {
+		if headers[name] != want[name] {
+			return ErrPEMHeader
+		}
+	}
+	return nil
+}
+
+// EncodePEM returns the PEM encoding of bd
+func EncodePEM(bd BlindingData) ([]byte, error) {
+	scheme, dataType, pubKey := bd.SchemeData()
+	blockType, err := PEMType(scheme, dataType)
+	if err != nil {
+		return nil, err
+	}
+	b, err := bd.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Headers: pemHeaders(scheme, pubKey), Bytes: b}), nil
+}
+
+// DecodePEM decodes the first PEM block in b, which must contain BlindingData of a registered scheme. pubKey is
+// the expected signer and may only be nil for types that do not carry a signer (ClearMessage). The remainder
+// of b after the block is returned
+func DecodePEM(b []byte, pubKey *eccutil.Point) (BlindingData, []byte, error) {
+	block, rest := pem.Decode(b)
+	if block == nil {
+		return nil, b, ErrNoPEM
+	}
+	bd, err := Decode(block.Bytes, pubKey)
+	if err != nil {
+		return nil, rest, err
+	}
+	scheme, dataType, signer := bd.SchemeData()
+	blockType, err := PEMType(scheme, dataType)
+	if err != nil {
+		return nil, rest, err
+	}
+	if block.Type != blockType {
+		return nil, rest, ErrPEMType
+	}
+	err = checkPEMHeaders(block.Headers, scheme, signer)
+	if err != nil {
+		return nil, rest, err
+	}
+	return bd, rest, nil
+}
+
+// EncodePublicKeyPEM returns the PEM encoding of the signer public key pubKey. The key must be on a named curve
+func EncodePublicKeyPEM(pubKey *eccutil.Point) ([]byte, error) {
+	curve := eccutil.CurveOfPoint(pubKey)
+	if curve == nil {
+		return nil, ErrBadPublicKey
+	}
+	der, err := x509.MarshalPKIXPublicKey(&ecdsa.PublicKey{Curve: curve, X: pubKey.X, Y: pubKey.Y})
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: PublicKeyPEMType, Headers: pemHeaders("", pubKey), Bytes: der}), nil
+}
+
+// DecodePublicKeyPEM decodes the first PEM block in b, which must contain a signer public key. The remainder
+// of b after the block is returned
+func DecodePublicKeyPEM(b []byte) (*eccutil.Point, []byte, error) {
+	block, rest := pem.Decode(b)
+	if block == nil {
+		return nil, b, ErrNoPEM
+	}
+	if block.Type != PublicKeyPEMType {
+		return nil, rest, ErrPEMType
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, rest, err
+	}
+	ecKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, rest, ErrBadPublicKey
+	}
+	pubKey := eccutil.NewPoint(ecKey.X, ecKey.Y)
+	if eccutil.CurveOfPoint(pubKey) == nil {
+		return nil, rest, ErrBadPublicKey
+	}
+	err = checkPEMHeaders(block.Headers, "", pubKey)
+	if err != nil {
+		return nil, rest, err
+	}
+	return pubKey, rest, nil
+}
+
+// EncodePrivateKeyPEM returns the PEM encoding of priv, the private key of the signer pubKey for scheme. The
+// block contains a SEC 1 EC private key and carries the same headers as BlindingData of the signer
+func EncodePrivateKeyPEM(scheme string, priv []byte, pubKey *eccutil.Point) ([]byte, error) {
+	curve := eccutil.CurveOfPoint(pubKey)
+	if curve == nil {
+		return nil, ErrBadPublicKey
+	}
+	x, y := curve.ScalarBaseMult(priv)
+	if x.Cmp(pubKey.X) != 0 || y.Cmp(pubKey.Y) != 0 {
+		return nil, ErrBadPrivateKey
+	}
+	key := &ecdsa.PrivateKey{PublicKey: ecdsa.PublicKey{Curve: curve, X: pubKey.X, Y: pubKey.Y}}
+	key.D = new(big.Int).SetBytes(priv)
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: PrivateKeyPEMType, Headers: pemHeaders(scheme, pubKey), Bytes: der}), nil
+}
+
+// DecodePrivateKeyPEM decodes the first PEM block in b, which must contain a signer private key. It returns
+// the scheme of the key, the private key in the form taken by the scheme constructors, the public key and its
+// curve. The remainder of b after the block is returned
+func DecodePrivateKeyPEM(b []byte) (scheme string, priv []byte, pubKey *eccutil.Point, curve elliptic.Curve, rest []byte, err error) {
+	block, rest := pem.Decode(b)
+	if block == nil {
+		return "", nil, nil, nil, b, ErrNoPEM
+	}
+	if block.Type != PrivateKeyPEMType {
+		return "", nil, nil, nil, rest, ErrPEMType
+	}
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return "", nil, nil, nil, rest, err
+	}
+	pubKey = eccutil.NewPoint(key.X, key.Y)
+	curve = eccutil.CurveOfPoint(pubKey)
+	if curve == nil {
+		return "", nil, nil, nil, rest, ErrBadPublicKey
+	}
+	scheme = block.Headers[PEMHeaderScheme]
+	if scheme == "" {
+		return "", nil, nil, nil, rest, ErrPEMHeader
+	}
+	err = checkPEMHeaders(block.Headers, scheme, pubKey)
+	if err != nil {
+		return "", nil, nil, nil, rest, err
+	}
+	priv = key.D.FillBytes(make([]byte, (curve.Params().N.BitLen()+7)/8))
+	return scheme, priv, pubKey, curve, rest, nil
+}
@@ -0,0 +1,87 @@
+package genericblinding
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/pem"
+	"testing"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+)
+
+func Test_PublicKeyPEM(t *testing.T) {
+	pubkey := testKey(t)
+	b, err := EncodePublicKeyPEM(pubkey)
+	if err != nil {
+		t.Fatalf("PEM encoding failed: %s", err)
+	}
+	if !bytes.HasPrefix(b, []byte("-----BEGIN "+PublicKeyPEMType+"-----")) {
+		t.Errorf("Wrong PEM block type: %s", b)
+	}
+	p, rest, err := DecodePublicKeyPEM(append(b, b...))
+	if err != nil {
+		t.Fatalf("PEM decoding failed: %s", err)
+	}
+	if !eccutil.PointEqual(p, pubkey) {
+		t.Error("PEM round trip failed")
+	}
+	if !bytes.Equal(rest, b) {
+		t.Error("PEM decoding returned wrong remainder")
+	}
+	block, _ := pem.Decode(b)
+	block.Headers[PEMHeaderKeyID] = "00"
+	_, _, err = DecodePublicKeyPEM(pem.EncodeToMemory(block))
+	if err != ErrPEMHeader {
+		t.Errorf("PEM decoding must fail for wrong Key-ID: %v", err)
+	}
+	block.Type = "PUBLIC KEY"
+	_, _, err = DecodePublicKeyPEM(pem.EncodeToMemory(block))
+	if err != ErrPEMType {
+		t.Errorf("PEM decoding must fail for wrong type: %v", err)
+	}
+	_, _, err = DecodePublicKeyPEM([]byte("no pem here"))
+	if err != ErrNoPEM {
+		t.Errorf("PEM decoding must fail without block: %v", err)
+	}
+	_, err = EncodePublicKeyPEM(eccutil.ZeroPoint())
+	if err != ErrBadPublicKey {
+		t.Errorf("PEM encoding must fail for point on no curve: %v", err)
+	}
+}
+
+func Test_PrivateKeyPEM(t *testing.T) {
+	c := eccutil.SetCurve(elliptic.P256, rand.Reader, eccutil.Sha1Hash)
+	privkey, pubkey, err := c.GenerateKey()
+	if err != nil {
+		t.Fatalf("Error creating keys: %s", err)
+	}
+	b, err := EncodePrivateKeyPEM("TEST", privkey, pubkey)
+	if err != nil {
+		t.Fatalf("PEM encoding failed: %s", err)
+	}
+	scheme, priv, pub, curve, rest, err := DecodePrivateKeyPEM(append(b, b...))
+	if err != nil {
+		t.Fatalf("PEM decoding failed: %s", err)
+	}
+	if scheme != "TEST" || !bytes.Equal(priv, privkey) || !eccutil.PointEqual(pub, pubkey) || curve.Params().Name != "P-256" {
+		t.Error("PEM round trip failed")
+	}
+	if !bytes.Equal(rest, b) {
+		t.Error("PEM decoding returned wrong remainder")
+	}
+	block, _ := pem.Decode(b)
+	delete(block.Headers, PEMHeaderScheme)
+	_, _, _, _, _, err = DecodePrivateKeyPEM(pem.EncodeToMemory(block))
+	if err != ErrPEMHeader {
+		t.Errorf("PEM decoding must fail without scheme: %v", err)
+	}
+	_, other, err := c.GenerateKey()
+	if err != nil {
+		t.Fatalf("Error creating keys: %s", err)
+	}
+	_, err = EncodePrivateKeyPEM("TEST", privkey, other)
+	if err != ErrBadPrivateKey {
+		t.Errorf("PEM encoding must fail for key of other signer: %v", err)
+	}
+}
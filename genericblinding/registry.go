@@ -0,0 +1,145 @@
+package genericblinding
+
+import (
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+)
+
+var (
+	// ErrUnknownScheme is returned if no implementation is registered for a scheme
+	ErrUnknownScheme = errors.New("blinding: Scheme not registered")
+	// ErrUnknownType is returned if a scheme has no constructor for a DataType
+	ErrUnknownType = errors.New("blinding: Type not registered for scheme")
+)
+
+// Constructor returns an empty BlindingData for the signer pubKey. The result is used as template for Unmarshal
+type Constructor func(pubKey *eccutil.Point) BlindingData
+
+// Factory creates the servers, clients and clear messages of a scheme, so that callers can use a scheme
+// chosen at runtime
+type Factory struct {
+	NewServer       func(privKey []byte, pubKey *eccutil.Point, curve *eccutil.Curve) BlindingServer
+	NewClient       func(pubKey *eccutil.Point, curve *eccutil.Curve) BlindingClient
+	NewClearMessage func(msg []byte) ClearMessage
+}
+
+var (
+	registryLock sync.RWMutex
+	registry     = make(map[string]map[DataType]Constructor)
+	factories    = make(map[string]Factory)
+)
+
+// Register makes the Constructor for dataType of scheme known to Decode. Schemes call this from init
+func Register(scheme string, dataType DataType, constructor Constructor) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if registry[scheme] == nil {
+		registry[scheme] = make(map[DataType]Constructor)
+	}
+	registry[scheme][dataType] = constructor
+}
+
+// RegisterFactory makes the Factory of scheme known to LookupFactory. Schemes call this from init
+func RegisterFactory(scheme string, factory Factory) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	factories[scheme] = factory
+}
+
+// LookupFactory returns the Factory of scheme
+func LookupFactory(scheme string) (Factory, error) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	factory, ok := factories[scheme]
+	if !ok {
+		return Factory{}, ErrUnknownScheme
+	}
+	return factory, nil
+}
+
+// Schemes returns the names of all registered schemes, sorted
+func Schemes() []string {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	s := make([]string, 0, len(registry))
+	for name := range registry {
+		s = append(s, name)
+	}
+	sort.Strings(s)
+	return s
+}
+
+// lookup returns the Constructor for scheme and dataType
+func lookup(scheme string, dataType DataType) (Constructor, error) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	types, ok := registry[scheme]
+	if !ok {
+		return nil, ErrUnknownScheme
+	}
+	constructor, ok := types[dataType]
+	if !ok {
+		return nil, ErrUnknownType
+	}
+	return constructor, nil
+}
+
+// header is the common start of all BlindingData structs
+type header struct {
+	SchemeName string
+	DataType   DataType
+}
+
+// Peek returns the scheme and DataType of marshalled BlindingData without decoding it
+func Peek(b []byte) (string, DataType, error) {
+	e, err := OpenEnvelope(b)
+	if err != nil {
+		return "", 0, err
+	}
+	return e.Scheme, e.DataType, nil
+}
+
+// Decode unmarshals BlindingData of any registered scheme. pubKey is the expected signer and may only be nil
+// for types that do not carry a signer (ClearMessage)
+func Decode(b []byte, pubKey *eccutil.Point) (BlindingData, error) {
+	scheme, dataType, err := Peek(b)
+	if err != nil {
+		return nil, err
+	}
+	constructor, err := lookup(scheme, dataType)
+	if err != nil {
+		return nil, err
+	}
+	if pubKey == nil && dataType != TypeClearMessage {
+		return nil, ErrBadSigner
+	}
+	return constructor(pubKey).Unmarshal(b)
+}
+
+// Signer returns the signer public key embedded in marshalled BlindingData of a registered scheme, without
+// checking it against anything. It is nil for types that do not carry a signer. Use it to inspect data of
+// unknown origin, then pass the key to Decode
+func Signer(b []byte) (*eccutil.Point, error) {
+	e, err := OpenEnvelope(b)
+	if err != nil {
+		return nil, err
+	}
+	constructor, err := lookup(e.Scheme, e.DataType)
+	if err != nil {
+		return nil, err
+	}
+	template := constructor(eccutil.NewPoint(new(big.Int), new(big.Int)))
+	v := reflect.New(reflect.TypeOf(template))
+	_, err = asn1.Unmarshal(e.Payload, v.Interface())
+	if err != nil {
+		return nil, err
+	}
+	_, _, pubKey := v.Elem().Interface().(BlindingData).SchemeData()
+	return pubKey, nil
+}
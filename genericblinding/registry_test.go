@@ -0,0 +1,59 @@
+package genericblinding
+
+import (
+	"encoding/asn1"
+	"math/big"
+	"testing"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+)
+
+// registerTest registers constructor for dataType of scheme until the end of the test
+func registerTest(t *testing.T, scheme string, dataType DataType, constructor Constructor) {
+	Register(scheme, dataType, constructor)
+	t.Cleanup(func() {
+		registryLock.Lock()
+		defer registryLock.Unlock()
+		delete(registry, scheme)
+	})
+}
+
+func Test_DecodeUnknown(t *testing.T) {
+	p := eccutil.NewPoint(big.NewInt(1), big.NewInt(2))
+	b, err := asn1.Marshal(header{SchemeName: "Unregistered", DataType: TypeClearMessage})
+	if err != nil {
+		t.Fatalf("Marshalling failed: %s", err)
+	}
+	scheme, dataType, err := Peek(b)
+	if err != nil {
+		t.Fatalf("Peek failed: %s", err)
+	}
+	if scheme != "Unregistered" || dataType != TypeClearMessage {
+		t.Errorf("Peek returned wrong data: %s %d", scheme, dataType)
+	}
+	_, err = Decode(b, p)
+	if err != ErrUnknownScheme {
+		t.Errorf("Decode must fail for unknown scheme: %v", err)
+	}
+	registerTest(t, "Partial", TypeBlindMessage, nil)
+	b, err = asn1.Marshal(header{SchemeName: "Partial", DataType: TypeClearMessage})
+	if err != nil {
+		t.Fatalf("Marshalling failed: %s", err)
+	}
+	_, err = Decode(b, p)
+	if err != ErrUnknownType {
+		t.Errorf("Decode must fail for unknown type: %v", err)
+	}
+	_, err = Decode([]byte("garbage"), p)
+	if err == nil {
+		t.Error("Decode must fail for garbage")
+	}
+	t.Run("Cleanup", func(t *testing.T) {
+		registerTest(t, "Temporary", TypeBlindMessage, nil)
+	})
+	for _, scheme := range Schemes() {
+		if scheme == "Temporary" {
+			t.Error("Scheme registered by a test is left in the registry")
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package genericblinding_test
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+	"github.com/ronperry/cryptoedge/jcc"
+	"github.com/ronperry/cryptoedge/jjm"
+	"github.com/ronperry/cryptoedge/singhdas"
+)
+
+// issue runs a blind signature with the factory of scheme and returns the data of all seven DataTypes and the
+// public key of the signer
+func issue(t *testing.T, scheme string) ([]genericblinding.BlindingData, *eccutil.Point) {
+	factory, err := genericblinding.LookupFactory(scheme)
+	if err != nil {
+		t.Fatalf("LookupFactory failed: %s", err)
+	}
+	c := eccutil.SetCurve(elliptic.P256, rand.Reader, eccutil.Sha1Hash)
+	privkey, pubkey, err := c.GenerateKey()
+	if err != nil {
+		t.Fatalf("Error creating keys: %s", err)
+	}
+	server := factory.NewServer(privkey, pubkey, c)
+	client := factory.NewClient(pubkey, c)
+	cm := factory.NewClearMessage([]byte("Message of a known signer"))
+	bpc, bps, err := server.GetParams()
+	if err != nil {
+		t.Fatalf("GetParams failed: %s", err)
+	}
+	bf, bm, err := client.Blind(bpc, cm)
+	if err != nil {
+		t.Fatalf("Blind failed: %s", err)
+	}
+	bs, err := server.Sign(bps, bm)
+	if err != nil {
+		t.Fatalf("Sign failed: %s", err)
+	}
+	cs, cmOut, err := client.Unblind(bf, cm, bs)
+	if err != nil {
+		t.Fatalf("Unblind failed: %s", err)
+	}
+	return []genericblinding.BlindingData{bpc, cmOut, bf, bm, bs, cs, bps}, pubkey
+}
+
+func Test_Signer(t *testing.T) {
+	for _, scheme := range []string{jcc.SchemeName, jjm.SchemeName, singhdas.SchemeName} {
+		if _, err := genericblinding.LookupFactory(scheme); err != nil {
+			t.Fatalf("%s not registered: %s", scheme, err)
+		}
+	}
+	for _, scheme := range genericblinding.Schemes() {
+		if _, err := genericblinding.LookupFactory(scheme); err != nil {
+			continue
+		}
+		all, pubkey := issue(t, scheme)
+		for i, bd := range all {
+			_, dataType, sdPubKey := bd.SchemeData()
+			if int(dataType) != i+1 {
+				t.Fatalf("%s: data %d has DataType %d", scheme, i, dataType)
+			}
+			b, err := bd.Marshal()
+			if err != nil {
+				t.Fatalf("%s type %d: Marshal failed: %s", scheme, dataType, err)
+			}
+			signer, err := genericblinding.Signer(b)
+			if err != nil {
+				t.Errorf("%s type %d: Signer failed: %s", scheme, dataType, err)
+				continue
+			}
+			if sdPubKey == nil {
+				if signer != nil {
+					t.Errorf("%s type %d: Signer returned a key for data without signer", scheme, dataType)
+				}
+				continue
+			}
+			if signer == nil || !eccutil.PointEqual(signer, sdPubKey) || !eccutil.PointEqual(signer, pubkey) {
+				t.Errorf("%s type %d: Signer returned wrong key", scheme, dataType)
+			}
+		}
+	}
+	if _, err := genericblinding.Signer([]byte("garbage")); err == nil {
+		t.Error("Signer must fail for garbage")
+	}
+}
@@ -0,0 +1,164 @@
+package genericblinding
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+)
+
+// ErrSpent is returned if a signature has been spent before
+var ErrSpent = errors.New("blinding: Signature already spent")
+
+// SpentStore records the IDs of redeemed tokens to prevent double redemption
+type SpentStore interface {
+	// Spend marks id as spent. It returns ErrSpent if id has been spent before. Check and insert are atomic
+	Spend(id []byte) error
+	// IsSpent returns true if id has been spent
+	IsSpent(id []byte) (bool, error)
+}
+
+// VerifyAndSpend verifies that cs is a signature of cm and marks cm as spent in store. It returns true only for
+// a valid signature of a message that has not been spent before, and ErrSpent for a valid signature of one that
+// has. The store is keyed on SpentID, so one store can serve several schemes and signers
+func VerifyAndSpend(client BlindingClient, store SpentStore, cs ClearSignature, cm ClearMessage) (bool, error) {
+	ok, err := client.Verify(cs, cm)
+	if err != nil || !ok {
+		return false, err
+	}
+	err = store.Spend(SpentID(cs, cm))
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SpentID returns the ID under which a verified signature cs of cm is spent: the scheme, the KeyID of the signer
+// and the UniqueID of cm. It is the ID of the message, not of cs, because the holder of a signature can derive
+// other valid signatures of the same message, e.g. by scaling R and r of jjm with inverse factors
+func SpentID(cs ClearSignature, cm ClearMessage) []byte {
+	scheme, _, pubKey := cs.SchemeData()
+	id := append([]byte(scheme), 0)
+	id = append(id, eccutil.KeyID(pubKey)...)
+	return append(id, cm.UniqueID()...)
+}
+
+// MemorySpentStore is a SpentStore that keeps spent IDs in memory
+type MemorySpentStore struct {
+	lock  sync.Mutex
+	spent map[string]bool
+}
+
+// NewMemorySpentStore returns an empty MemorySpentStore
+func NewMemorySpentStore() *MemorySpentStore {
+	ss := new(MemorySpentStore)
+	ss.spent = make(map[string]bool)
+	return ss
+}
+
+// Spend marks id as spent
+func (ss *MemorySpentStore) Spend(id []byte) error {
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+	if ss.spent[string(id)] {
+		return ErrSpent
+	}
+	ss.spent[string(id)] = true
+	return nil
+}
+
+// IsSpent returns true if id has been spent
+func (ss *MemorySpentStore) IsSpent(id []byte) (bool, error) {
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+	return ss.spent[string(id)], nil
+}
+
+// FileSpentStore is a SpentStore that appends spent IDs to a file, one hex encoded ID per line. All IDs are
+// loaded into memory when the file is opened. Every Spend is synced to disk before it returns. The file must
+// not be shared between processes
+type FileSpentStore struct {
+	MemorySpentStore
+	file *os.File
+}
+
+// OpenFileSpentStore opens or creates the FileSpentStore in file name. A partially written last line, left by
+// a crash, is removed
+func OpenFileSpentStore(name string) (*FileSpentStore, error) {
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	ss := &FileSpentStore{file: f}
+	ss.spent = make(map[string]bool)
+	err = ss.load()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return ss, nil
+}
+
+// load reads all IDs from the file and positions it for appending
+func (ss *FileSpentStore) load() error {
+	r := bufio.NewReader(ss.file)
+	var complete int64 // End of the last complete line
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		id, err := hex.DecodeString(string(bytes.TrimSuffix(line, []byte("\n"))))
+		if err != nil {
+			return err
+		}
+		ss.spent[string(id)] = true
+		complete += int64(len(line))
+	}
+	err := ss.file.Truncate(complete)
+	if err != nil {
+		return err
+	}
+	_, err = ss.file.Seek(complete, io.SeekStart)
+	return err
+}
+
+// Spend marks id as spent. The ID is only marked if it has been written to the file
+func (ss *FileSpentStore) Spend(id []byte) error {
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+	if ss.spent[string(id)] {
+		return ErrSpent
+	}
+	end, err := ss.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	_, err = ss.file.WriteString(hex.EncodeToString(id) + "\n")
+	if err != nil {
+		ss.file.Truncate(end) // Remove partial line
+		ss.file.Seek(end, io.SeekStart)
+		return err
+	}
+	err = ss.file.Sync()
+	if err != nil {
+		return err
+	}
+	ss.spent[string(id)] = true
+	return nil
+}
+
+// Close closes the file of the store
+func (ss *FileSpentStore) Close() error {
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+	return ss.file.Close()
+}
@@ -0,0 +1,149 @@
+package genericblinding
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+)
+
+// testSpentStore runs the tests common to all SpentStore implementations
+func testSpentStore(t *testing.T, ss SpentStore) {
+	id := []byte("0123456789abcdef0123456789abcdef")
+	spent, err := ss.IsSpent(id)
+	if err != nil || spent {
+		t.Errorf("Unspent ID reported as spent: %v", err)
+	}
+	err = ss.Spend(id)
+	if err != nil {
+		t.Fatalf("Spend failed: %s", err)
+	}
+	spent, err = ss.IsSpent(id)
+	if err != nil || !spent {
+		t.Errorf("Spent ID reported as unspent: %v", err)
+	}
+	err = ss.Spend(id)
+	if err != ErrSpent {
+		t.Errorf("Spend must fail for spent ID: %v", err)
+	}
+	id2 := []byte("second id")
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	spends := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := ss.Spend(id2); err == nil {
+				lock.Lock()
+				spends++
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if spends != 1 {
+		t.Errorf("ID spent %d times", spends)
+	}
+}
+
+func Test_MemorySpentStore(t *testing.T) {
+	testSpentStore(t, NewMemorySpentStore())
+}
+
+func Test_FileSpentStore(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "spent")
+	ss, err := OpenFileSpentStore(name)
+	if err != nil {
+		t.Fatalf("OpenFileSpentStore failed: %s", err)
+	}
+	testSpentStore(t, ss)
+	err = ss.Close()
+	if err != nil {
+		t.Fatalf("Close failed: %s", err)
+	}
+	// Simulate a crash during a write
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatalf("Open failed: %s", err)
+	}
+	f.WriteString("abcd")
+	f.Close()
+	ss, err = OpenFileSpentStore(name)
+	if err != nil {
+		t.Fatalf("Reopening store failed: %s", err)
+	}
+	defer ss.Close()
+	err = ss.Spend([]byte("0123456789abcdef0123456789abcdef"))
+	if err != ErrSpent {
+		t.Errorf("Spent ID lost after reopening: %v", err)
+	}
+	err = ss.Spend([]byte{0xab, 0xcd})
+	if err != nil {
+		t.Errorf("Partial line not removed: %v", err)
+	}
+	ss.Close()
+	ss, err = OpenFileSpentStore(name)
+	if err != nil {
+		t.Fatalf("Reopening store failed: %s", err)
+	}
+	if spent, _ := ss.IsSpent([]byte{0xab, 0xcd}); !spent {
+		t.Error("ID spent after recovery lost")
+	}
+}
+
+// testVerifier is a BlindingClient whose signatures are testData with the Value of the signed message
+type testVerifier struct {
+	testClient
+}
+
+func (tv testVerifier) Verify(cs ClearSignature, cm ClearMessage) (bool, error) {
+	s, ok := cs.(testData)
+	m, ok2 := cm.(testData)
+	if !ok || !ok2 {
+		return false, ErrBadType
+	}
+	return bytes.Equal(s.Value, m.Value), nil
+}
+
+// testToken returns a ClearSignature and ClearMessage of value signed by pubKey in scheme
+func testToken(scheme string, pubKey *eccutil.Point, value string) (testData, testData) {
+	cm := testData{SchemeName: scheme, DataType: TypeClearMessage, PubKey: *pubKey, Value: []byte(value)}
+	cs := cm
+	cs.DataType = TypeClearSignature
+	return cs, cm
+}
+
+func Test_VerifyAndSpend(t *testing.T) {
+	pubKey, other := testKey(t), testKey(t)
+	store := NewMemorySpentStore()
+	cs, cm := testToken("TST", pubKey, "Token to be redeemed once")
+	_, otherMessage := testToken("TST", pubKey, "Other token")
+	ok, err := VerifyAndSpend(testVerifier{}, store, cs, otherMessage)
+	if ok || err != nil {
+		t.Errorf("VerifyAndSpend must fail for wrong message: %v", err)
+	}
+	if spent, _ := store.IsSpent(SpentID(cs, otherMessage)); spent {
+		t.Error("Invalid signature spent")
+	}
+	ok, err = VerifyAndSpend(testVerifier{}, store, cs, cm)
+	if !ok || err != nil {
+		t.Fatalf("VerifyAndSpend failed: %v", err)
+	}
+	ok, err = VerifyAndSpend(testVerifier{}, store, cs, cm)
+	if ok || err != ErrSpent {
+		t.Errorf("VerifyAndSpend must fail for spent signature: %v", err)
+	}
+	// The same message of another signer or scheme is another token
+	otherSigner, otherSignerMessage := testToken("TST", other, "Token to be redeemed once")
+	otherScheme, otherSchemeMessage := testToken("TS2", pubKey, "Token to be redeemed once")
+	if ok, err := VerifyAndSpend(testVerifier{}, store, otherSigner, otherSignerMessage); !ok || err != nil {
+		t.Errorf("VerifyAndSpend failed for message of another signer: %v", err)
+	}
+	if ok, err := VerifyAndSpend(testVerifier{}, store, otherScheme, otherSchemeMessage); !ok || err != nil {
+		t.Errorf("VerifyAndSpend failed for message of another scheme: %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package jcc
+
+// Batch issuance and verification over the generic blinding interface
+
+import (
+	"context"
+	"github.com/ronperry/cryptoedge/genericblinding"
+)
+
+// GetParamsBatch returns n signature request parameters. Unused in JCC
+func (server GenericBlindingServer) GetParamsBatch(n int) ([]genericblinding.BlindingParamClient, []genericblinding.BlindingParamServer, error) {
+	return genericblinding.GetParamsBatch(server.GetParams, n)
+}
+
+// SignBatch signs all messages in bmi. All messages are checked before any is signed. BlindingParamServer are
+// not used in JCC, bpsi must have the same length as bmi but can contain nil
+func (server GenericBlindingServer) SignBatch(bpsi []genericblinding.BlindingParamServer, bmi []genericblinding.BlindMessage) ([]genericblinding.BlindSignature, error) {
+	if len(bpsi) != len(bmi) {
+		return nil, genericblinding.ErrBatchLength
+	}
+	bm := make([]BlindMessage, len(bmi))
+	errs := make(genericblinding.BatchError, len(bmi))
+	for i := range bmi {
+		bm[i], errs[i] = server.checkSign(bmi[i])
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	sigs := make([]genericblinding.BlindSignature, len(bm))
+	for i := range bm {
+		sigs[i], errs[i] = server.sign(context.Background(), bm[i])
+	}
+	return sigs, errs.Err()
+}
+
+// VerifyBatch verifies that csi[i] is a signature of cmi[i]. JCC verifies a signature with a single ScalarMult,
+// a random linear combination of the verification equations needs two per signature, so the signatures are
+// verified one by one
+func (client GenericBlindingClient) VerifyBatch(csi []genericblinding.ClearSignature, cmi []genericblinding.ClearMessage) (bool, error) {
+	return genericblinding.VerifyEach(client.Verify, csi, cmi)
+}
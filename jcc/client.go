@@ -32,7 +32,9 @@
 package jcc
 
 import (
+	"context"
 	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
 	"math/big"
 )
 
@@ -43,8 +45,9 @@ const MaxLoopCount = 1000
 
 // BlindingClient a blinding client
 type BlindingClient struct {
-	curve  *eccutil.Curve
-	PubKey *eccutil.Point
+	curve    *eccutil.Curve
+	PubKey   *eccutil.Point
+	Observer genericblinding.Observer // Receives the retries of Blind, may be nil
 }
 
 // NewBlindingClient returns a new BlindingClient
@@ -57,6 +60,11 @@ func NewBlindingClient(curve *eccutil.Curve, pubKey *eccutil.Point) *BlindingCli
 
 // Blind returns a blinded message and the blinding factor. bmsg is sent to signer (public), bfac is private and needed for unblinding
 func (client BlindingClient) Blind(msg []byte) (bmsg *eccutil.Point, bfac []byte, err error) {
+	return client.BlindContext(context.Background(), msg)
+}
+
+// BlindContext is Blind with a context. The parameter search stops with the error of ctx when ctx is done
+func (client BlindingClient) BlindContext(ctx context.Context, msg []byte) (bmsg *eccutil.Point, bfac []byte, err error) {
 	// blind message = scalarmult(message,scalarmult(blinding factor, scalarmult(blindingfactor,basepoint)) (POINT)
 	var loopcount int
 	if len(msg) < 10 {
@@ -70,10 +78,16 @@ func (client BlindingClient) Blind(msg []byte) (bmsg *eccutil.Point, bfac []byte
 	}
 	//cparams := client.curve.Params()
 	for {
+		if loopcount > 0 {
+			genericblinding.ObserveRetry(client.Observer, SchemeName, genericblinding.OpBlind)
+		}
 		if loopcount > MaxLoopCount {
 			return nil, nil, eccutil.ErrMaxLoop
 		}
 		loopcount++
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
 		bfact, bpoint, err := client.curve.GenerateKey()
 		if err != nil {
 			return nil, nil, err
@@ -123,7 +137,7 @@ func (client BlindingClient) Unblind(bfac, msg []byte, s *eccutil.Point) (sb *ec
 	nit := client.curve.ScalarMult(client.PubKey, bfac) // ni x Ps
 	mt := client.curve.ScalarMult(nit, msg)             // m x (ni x Ps)
 	// inverse mt to make substraction
-	mt.Y = mt.Y.Neg(mt.Y)
+	mt = client.curve.NegPoint(mt)
 	st := client.curve.AddPoints(s, mt) // s - (m x ni x Ps)
 	return st, mbx
 }
@@ -131,8 +145,11 @@ func (client BlindingClient) Unblind(bfac, msg []byte, s *eccutil.Point) (sb *ec
 // Verify a signature
 func (client BlindingClient) Verify(r, sb *eccutil.Point, mb []byte) bool {
 	//		r == s' - m' x Ps
+	if !client.curve.IsOnCurve(r) || !client.curve.IsOnCurve(sb) {
+		return false
+	}
 	c := client.curve.ScalarMult(client.PubKey, mb) // m' x Ps
-	c.Y = c.Y.Neg(c.Y)                              // neg m'
+	c = client.curve.NegPoint(c)                    // neg m'
 	cv := client.curve.AddPoints(sb, c)             // s + neg (m x Ps)
 	if r.X.Cmp(cv.X) == 0 && r.Y.Cmp(cv.Y) == 0 {   // r == s + neg (m x Ps) ?
 		return true
@@ -4,8 +4,10 @@ package jcc
 //ToDo: Test for interface conversion errors
 
 import (
+	"context"
 	"github.com/ronperry/cryptoedge/eccutil"
 	"github.com/ronperry/cryptoedge/genericblinding"
+	"time"
 )
 
 // GenericBlindingClient a blinding client using the generic interface
@@ -28,8 +30,21 @@ func NewGenericBlindingClient(curve *eccutil.Curve, pubKey *eccutil.Point) *Gene
 
 // Blind returns a blinded message and the blinding factor. BlindingParamClient can be nil
 func (client GenericBlindingClient) Blind(bpci genericblinding.BlindingParamClient, cmi genericblinding.ClearMessage) (genericblinding.BlindingFactors, genericblinding.BlindMessage, error) {
+	return client.BlindContext(context.Background(), bpci, cmi)
+}
+
+// BlindContext is Blind with a context
+func (client GenericBlindingClient) BlindContext(ctx context.Context, bpci genericblinding.BlindingParamClient, cmi genericblinding.ClearMessage) (_ genericblinding.BlindingFactors, _ genericblinding.BlindMessage, err error) {
+	start := time.Now()
+	defer func() { genericblinding.Observe(client.Observer, SchemeName, genericblinding.OpBlind, start, err) }()
 	//bpc := bpci.(BlindingParamClient) // Nil anyways
-	_, err := genericblinding.MatchMessage(cmi, SchemeName, genericblinding.TypeClearMessage, client.PubKey)
+	if bpci != nil {
+		_, err := genericblinding.MatchMessage(bpci, SchemeName, genericblinding.TypeBlindingParamClient, client.PubKey)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	_, err = genericblinding.MatchMessage(cmi, SchemeName, genericblinding.TypeClearMessage, client.PubKey)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -38,7 +53,8 @@ func (client GenericBlindingClient) Blind(bpci genericblinding.BlindingParamClie
 		return nil, nil, genericblinding.ErrBadType
 	}
 	c := NewBlindingClient(client.curve, client.PubKey)
-	bmt, bft, err := c.Blind(cm.UniqueID())
+	c.Observer = client.Observer
+	bmt, bft, err := c.BlindContext(ctx, cm.UniqueID())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -51,7 +67,17 @@ func (client GenericBlindingClient) Blind(bpci genericblinding.BlindingParamClie
 
 // Unblind unblinds a signature
 func (client GenericBlindingClient) Unblind(bfi genericblinding.BlindingFactors, cmi genericblinding.ClearMessage, bsi genericblinding.BlindSignature) (genericblinding.ClearSignature, genericblinding.ClearMessage, error) {
-	_, err := genericblinding.MatchMessage(bfi, SchemeName, genericblinding.TypeBlindingFactors, client.PubKey)
+	return client.UnblindContext(context.Background(), bfi, cmi, bsi)
+}
+
+// UnblindContext is Unblind with a context
+func (client GenericBlindingClient) UnblindContext(ctx context.Context, bfi genericblinding.BlindingFactors, cmi genericblinding.ClearMessage, bsi genericblinding.BlindSignature) (_ genericblinding.ClearSignature, _ genericblinding.ClearMessage, err error) {
+	start := time.Now()
+	defer func() { genericblinding.Observe(client.Observer, SchemeName, genericblinding.OpUnblind, start, err) }()
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	_, err = genericblinding.MatchMessage(bfi, SchemeName, genericblinding.TypeBlindingFactors, client.PubKey)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -77,6 +103,9 @@ func (client GenericBlindingClient) Unblind(bfi genericblinding.BlindingFactors,
 	if !ok {
 		return nil, nil, genericblinding.ErrBadType
 	}
+	if !client.curve.IsOnCurve(&bs.R) || !client.curve.IsOnCurve(&bs.S) {
+		return nil, nil, eccutil.ErrBadCoordinate
+	}
 
 	c := NewBlindingClient(client.curve, client.PubKey)
 	sb, mb := c.Unblind(bf.Factor, cm.UniqueID(), &bs.S)
@@ -89,25 +118,44 @@ func (client GenericBlindingClient) Unblind(bfi genericblinding.BlindingFactors,
 
 // Verify a signature
 func (client GenericBlindingClient) Verify(csi genericblinding.ClearSignature, cmi genericblinding.ClearMessage) (bool, error) {
-	_, err := genericblinding.MatchMessage(csi, SchemeName, genericblinding.TypeClearSignature, client.PubKey)
+	return client.VerifyContext(context.Background(), csi, cmi)
+}
+
+// VerifyContext is Verify with a context
+func (client GenericBlindingClient) VerifyContext(ctx context.Context, csi genericblinding.ClearSignature, cmi genericblinding.ClearMessage) (ok bool, err error) {
+	start := time.Now()
+	defer func() { genericblinding.ObserveVerify(client.Observer, SchemeName, start, ok, err) }()
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	cs, cm, err := client.checkVerify(csi, cmi)
 	if err != nil {
 		return false, err
 	}
+	c := NewBlindingClient(client.curve, client.PubKey)
+	return c.Verify(&cs.R, &cs.SB, cm.Message), nil
+}
+
+// checkVerify converts the arguments of Verify and verifies that they belong to client
+func (client GenericBlindingClient) checkVerify(csi genericblinding.ClearSignature, cmi genericblinding.ClearMessage) (ClearSignature, ClearMessage, error) {
+	_, err := genericblinding.MatchMessage(csi, SchemeName, genericblinding.TypeClearSignature, client.PubKey)
+	if err != nil {
+		return ClearSignature{}, ClearMessage{}, err
+	}
 	cs, ok := csi.(ClearSignature)
 	if !ok {
-		return false, genericblinding.ErrBadType
+		return ClearSignature{}, ClearMessage{}, genericblinding.ErrBadType
 	}
 
 	_, err = genericblinding.MatchMessage(cmi, SchemeName, genericblinding.TypeClearMessage, client.PubKey)
 	if err != nil {
-		return false, err
+		return ClearSignature{}, ClearMessage{}, err
 	}
 	cm, ok := cmi.(ClearMessage)
 	if !ok {
-		return false, genericblinding.ErrBadType
+		return ClearSignature{}, ClearMessage{}, genericblinding.ErrBadType
 	}
-	c := NewBlindingClient(client.curve, client.PubKey)
-	return c.Verify(&cs.R, &cs.SB, cm.Message), nil
+	return cs, cm, nil
 }
 
 // NewGenericBlindingServer creates a new BlindingServer
@@ -122,18 +170,39 @@ func NewGenericBlindingServer(privkey []byte, pubkey *eccutil.Point, curve *eccu
 
 // Sign a message. BlindingParamServer can be nil (not used in JCC)
 func (server GenericBlindingServer) Sign(bpsi genericblinding.BlindingParamServer, bmi genericblinding.BlindMessage) (genericblinding.BlindSignature, error) {
+	return server.SignContext(context.Background(), bpsi, bmi)
+}
+
+// SignContext is Sign with a context
+func (server GenericBlindingServer) SignContext(ctx context.Context, bpsi genericblinding.BlindingParamServer, bmi genericblinding.BlindMessage) (_ genericblinding.BlindSignature, err error) {
+	start := time.Now()
+	defer func() { genericblinding.Observe(server.Observer, SchemeName, genericblinding.OpSign, start, err) }()
 	//bpsi is nil for this scheme, not tested
-	_, err := genericblinding.MatchMessage(bmi, SchemeName, genericblinding.TypeBlindMessage, server.PubKey)
+	bm, err := server.checkSign(bmi)
 	if err != nil {
 		return nil, err
 	}
+	return server.sign(ctx, bm)
+}
+
+// checkSign converts the BlindMessage given to Sign and verifies that it belongs to server
+func (server GenericBlindingServer) checkSign(bmi genericblinding.BlindMessage) (BlindMessage, error) {
+	_, err := genericblinding.MatchMessage(bmi, SchemeName, genericblinding.TypeBlindMessage, server.PubKey)
+	if err != nil {
+		return BlindMessage{}, err
+	}
 	bm, ok := bmi.(BlindMessage)
 	if !ok {
-		return nil, genericblinding.ErrBadType
+		return BlindMessage{}, genericblinding.ErrBadType
 	}
+	return bm, nil
+}
 
+// sign signs bm after checkSign
+func (server GenericBlindingServer) sign(ctx context.Context, bm BlindMessage) (genericblinding.BlindSignature, error) {
 	bs := NewBlindingServer(server.privKey, server.PubKey, server.curve, server.uniqueTest)
-	r, s, err := bs.Sign(&bm.Message)
+	bs.Observer = server.Observer
+	r, s, err := bs.SignContext(ctx, &bm.Message)
 	if err != nil {
 		return nil, err
 	}
@@ -145,6 +214,16 @@ func (server GenericBlindingServer) Sign(bpsi genericblinding.BlindingParamServe
 
 // GetParams returns signature request parameters. Unused in JCC
 func (server GenericBlindingServer) GetParams() (genericblinding.BlindingParamClient, genericblinding.BlindingParamServer, error) {
+	return server.GetParamsContext(context.Background())
+}
+
+// GetParamsContext is GetParams with a context
+func (server GenericBlindingServer) GetParamsContext(ctx context.Context) (_ genericblinding.BlindingParamClient, _ genericblinding.BlindingParamServer, err error) {
+	start := time.Now()
+	defer func() { genericblinding.Observe(server.Observer, SchemeName, genericblinding.OpGetParams, start, err) }()
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
 	bpc := NewBlindingParamClient(server.PubKey)
 	bps := NewBlindingParamServer(server.PubKey)
 	return bpc, bps, nil
@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"github.com/ronperry/cryptoedge/eccutil"
 	"github.com/ronperry/cryptoedge/genericblinding"
+	"github.com/ronperry/cryptoedge/genericblinding/conformance"
 	"testing"
 )
 
@@ -27,4 +28,19 @@ func Test_Interface(t *testing.T) {
 	_, _ = clientParams, serverParams
 }
 
-// // does not implement wrong type for method
+func Test_Conformance(t *testing.T) {
+	c := eccutil.SetCurve(elliptic.P256, rand.Reader, eccutil.Sha1Hash)
+	conformance.Run(t, conformance.Suite{
+		NewPair: func() (genericblinding.BlindingServer, genericblinding.BlindingClient, *eccutil.Point, error) {
+			privkey, pubkey, err := c.GenerateKey()
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			return NewGenericBlindingServer(privkey, pubkey, c, Fakeunique), NewGenericBlindingClient(c, pubkey), pubkey, nil
+		},
+		NewClearMessage: func(msg []byte) genericblinding.ClearMessage {
+			return NewClearMessage(msg)
+		},
+		OneTimeParams: false,
+	})
+}
@@ -0,0 +1,117 @@
+package jcc
+
+import (
+	"github.com/ronperry/cryptoedge/genericblinding"
+)
+
+// MarshalJSON returns the JSON encoding of a BlindingParamClient
+func (blindingParamClient BlindingParamClient) MarshalJSON() ([]byte, error) {
+	return genericblinding.MarshalJSON(blindingParamClient)
+}
+
+// UnmarshalJSON loads JSON produced by MarshalJSON. blindingParamClient must have been created by NewBlindingParamClient and is used for verification like in Unmarshal
+func (blindingParamClient *BlindingParamClient) UnmarshalJSON(b []byte) error {
+	n := new(BlindingParamClient)
+	err := genericblinding.UnmarshalJSON(b, n, *blindingParamClient)
+	if err != nil {
+		return err
+	}
+	*blindingParamClient = *n
+	return nil
+}
+
+// MarshalJSON returns the JSON encoding of a BlindingParamServer
+func (blindingParamServer BlindingParamServer) MarshalJSON() ([]byte, error) {
+	return genericblinding.MarshalJSON(blindingParamServer)
+}
+
+// UnmarshalJSON loads JSON produced by MarshalJSON. blindingParamServer must have been created by NewBlindingParamServer and is used for verification like in Unmarshal
+func (blindingParamServer *BlindingParamServer) UnmarshalJSON(b []byte) error {
+	n := new(BlindingParamServer)
+	err := genericblinding.UnmarshalJSON(b, n, *blindingParamServer)
+	if err != nil {
+		return err
+	}
+	*blindingParamServer = *n
+	return nil
+}
+
+// MarshalJSON returns the JSON encoding of a ClearMessage
+func (clearMessage ClearMessage) MarshalJSON() ([]byte, error) {
+	return genericblinding.MarshalJSON(clearMessage)
+}
+
+// UnmarshalJSON loads JSON produced by MarshalJSON. clearMessage must have been created by NewClearMessage and is used for verification like in Unmarshal
+func (clearMessage *ClearMessage) UnmarshalJSON(b []byte) error {
+	n := new(ClearMessage)
+	err := genericblinding.UnmarshalJSON(b, n, *clearMessage)
+	if err != nil {
+		return err
+	}
+	*clearMessage = *n
+	return nil
+}
+
+// MarshalJSON returns the JSON encoding of a BlindingFactors
+func (blindingFactors BlindingFactors) MarshalJSON() ([]byte, error) {
+	return genericblinding.MarshalJSON(blindingFactors)
+}
+
+// UnmarshalJSON loads JSON produced by MarshalJSON. blindingFactors must have been created by NewBlindingFactors and is used for verification like in Unmarshal
+func (blindingFactors *BlindingFactors) UnmarshalJSON(b []byte) error {
+	n := new(BlindingFactors)
+	err := genericblinding.UnmarshalJSON(b, n, *blindingFactors)
+	if err != nil {
+		return err
+	}
+	*blindingFactors = *n
+	return nil
+}
+
+// MarshalJSON returns the JSON encoding of a BlindMessage
+func (blindMessage BlindMessage) MarshalJSON() ([]byte, error) {
+	return genericblinding.MarshalJSON(blindMessage)
+}
+
+// UnmarshalJSON loads JSON produced by MarshalJSON. blindMessage must have been created by NewBlindMessage and is used for verification like in Unmarshal
+func (blindMessage *BlindMessage) UnmarshalJSON(b []byte) error {
+	n := new(BlindMessage)
+	err := genericblinding.UnmarshalJSON(b, n, *blindMessage)
+	if err != nil {
+		return err
+	}
+	*blindMessage = *n
+	return nil
+}
+
+// MarshalJSON returns the JSON encoding of a BlindSignature
+func (blindSignature BlindSignature) MarshalJSON() ([]byte, error) {
+	return genericblinding.MarshalJSON(blindSignature)
+}
+
+// UnmarshalJSON loads JSON produced by MarshalJSON. blindSignature must have been created by NewBlindSignature and is used for verification like in Unmarshal
+func (blindSignature *BlindSignature) UnmarshalJSON(b []byte) error {
+	n := new(BlindSignature)
+	err := genericblinding.UnmarshalJSON(b, n, *blindSignature)
+	if err != nil {
+		return err
+	}
+	*blindSignature = *n
+	return nil
+}
+
+// MarshalJSON returns the JSON encoding of a ClearSignature
+func (clearSignature ClearSignature) MarshalJSON() ([]byte, error) {
+	return genericblinding.MarshalJSON(clearSignature)
+}
+
+// UnmarshalJSON loads JSON produced by MarshalJSON. clearSignature must have been created by NewClearSignature and is used for verification like in Unmarshal
+func (clearSignature *ClearSignature) UnmarshalJSON(b []byte) error {
+	n := new(ClearSignature)
+	err := genericblinding.UnmarshalJSON(b, n, *clearSignature)
+	if err != nil {
+		return err
+	}
+	*clearSignature = *n
+	return nil
+}
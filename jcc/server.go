@@ -1,8 +1,10 @@
 package jcc
 
 import (
+	"context"
 	"crypto/sha256"
 	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
 	"math/big"
 )
 
@@ -14,6 +16,7 @@ type BlindingServer struct {
 	privKey    []byte
 	curve      *eccutil.Curve
 	uniqueTest func([32]byte) bool
+	Observer   genericblinding.Observer // Receives the retries of Sign, may be nil
 }
 
 // Fakeunique is a test function for the uniqueness-test. Must be implemented for production use
@@ -21,6 +24,16 @@ func Fakeunique(x [32]byte) bool {
 	return true
 }
 
+// DefaultUniqueTokens is the number of tokens remembered by the uniqueness test of the registered factory
+const DefaultUniqueTokens = eccutil.DefaultUniqueTokens
+
+// NewUniqueTest returns a uniqueness test that rejects tokens it has seen among the last max tokens, see
+// eccutil.NewUniqueTest. A token is the hash of a random nv and the blind message, a repeat is only expected from
+// a failing random source, which repeats within a short window. Use a persistent test if tokens must never repeat
+func NewUniqueTest(max int) func([32]byte) bool {
+	return eccutil.NewUniqueTest(max)
+}
+
 // NewBlindingServer creates a new BlindingServer
 func NewBlindingServer(privkey []byte, pubkey *eccutil.Point, curve *eccutil.Curve, uniqueTest func([32]byte) bool) *BlindingServer {
 	bs := new(BlindingServer)
@@ -41,6 +54,11 @@ func (bs BlindingServer) uniqueToken(nv []byte, point *eccutil.Point) [32]byte {
 
 // Sign a blind message, return signature. callback for checking bmsg -> nv uniqueness. r and s are returned to client. r is public for verification
 func (bs BlindingServer) Sign(bmsg *eccutil.Point) (r, s *eccutil.Point, err error) {
+	return bs.SignContext(context.Background(), bmsg)
+}
+
+// SignContext is Sign with a context. The parameter search stops with the error of ctx when ctx is done
+func (bs BlindingServer) SignContext(ctx context.Context, bmsg *eccutil.Point) (r, s *eccutil.Point, err error) {
 	// 		r = nv x blind message (POINT);
 	// 		s = (nv+ns) x blind message (POINT)
 	// 		Test that nv produces point (not infinity etc)
@@ -58,10 +76,16 @@ func (bs BlindingServer) Sign(bmsg *eccutil.Point) (r, s *eccutil.Point, err err
 		return nil, nil, err
 	}
 	for {
+		if loopcount > 0 {
+			genericblinding.ObserveRetry(bs.Observer, SchemeName, genericblinding.OpSign)
+		}
 		if loopcount > MaxLoopCount {
 			return nil, nil, eccutil.ErrMaxLoop
 		}
 		loopcount++
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
 		nv, err := bs.curve.GenNV()
 		if err != nil {
 			return nil, nil, err
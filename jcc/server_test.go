@@ -32,3 +32,23 @@ func TestSigner_Sign(t *testing.T) {
 	}
 	_, _, _ = r, s, bfac
 }
+
+func TestUniqueTest(t *testing.T) {
+	unique := NewUniqueTest(2)
+	a, b, c := [32]byte{1}, [32]byte{2}, [32]byte{3}
+	if !unique(a) || !unique(b) {
+		t.Fatal("New tokens must be unique")
+	}
+	if unique(a) || unique(b) {
+		t.Error("Repeated tokens must not be unique")
+	}
+	if !unique(c) {
+		t.Fatal("New token must be unique")
+	}
+	if !unique(a) {
+		t.Error("Oldest token must be forgotten")
+	}
+	if unique(c) {
+		t.Error("Repeated token must not be unique")
+	}
+}
@@ -2,7 +2,6 @@ package jcc
 
 import (
 	"crypto/sha256"
-	"encoding/asn1"
 	"github.com/ronperry/cryptoedge/eccutil"
 	"github.com/ronperry/cryptoedge/genericblinding"
 )
@@ -10,6 +9,9 @@ import (
 // SchemeName is the name of this blinding scheme
 const SchemeName = "JCC"
 
+// SchemeTag identifies this blinding scheme in CBOR encodings
+const SchemeTag = 1
+
 // BlindingParamClient is not needed in JCC
 type BlindingParamClient struct {
 	SchemeName string
@@ -33,13 +35,13 @@ func (blindingParamClient BlindingParamClient) SchemeData() (string, genericblin
 
 // Marshal a BlindingParamClient
 func (blindingParamClient BlindingParamClient) Marshal() ([]byte, error) {
-	return asn1.Marshal(blindingParamClient)
+	return genericblinding.MarshalEnvelope(blindingParamClient)
 }
 
 // Unmarshal []byte into BlindingParamClient
 func (blindingParamClient BlindingParamClient) Unmarshal(b []byte) (genericblinding.BlindingData, error) {
 	n := new(BlindingParamClient)
-	_, err := asn1.Unmarshal(b, n)
+	err := genericblinding.UnmarshalEnvelope(b, n)
 	if err != nil {
 		return nil, err
 	}
@@ -87,13 +89,13 @@ func (clearMessage ClearMessage) SchemeData() (string, genericblinding.DataType,
 
 // Marshal a BlindingParamClient
 func (clearMessage ClearMessage) Marshal() ([]byte, error) {
-	return asn1.Marshal(clearMessage)
+	return genericblinding.MarshalEnvelope(clearMessage)
 }
 
 // Unmarshal []byte into BlindingParamClient
 func (clearMessage ClearMessage) Unmarshal(b []byte) (genericblinding.BlindingData, error) {
 	n := new(ClearMessage)
-	_, err := asn1.Unmarshal(b, n)
+	err := genericblinding.UnmarshalEnvelope(b, n)
 	if err != nil {
 		return nil, err
 	}
@@ -103,7 +105,7 @@ func (clearMessage ClearMessage) Unmarshal(b []byte) (genericblinding.BlindingDa
 	if n.DataType != clearMessage.DataType {
 		return nil, genericblinding.ErrBadType
 	}
-	return n, nil
+	return *n, nil
 }
 
 // UniqueID returns a unique ID for this element. Constant in this case (zeros)
@@ -140,13 +142,13 @@ func (blindingFactors BlindingFactors) SchemeData() (string, genericblinding.Dat
 
 // Marshal a BlindingParamClient
 func (blindingFactors BlindingFactors) Marshal() ([]byte, error) {
-	return asn1.Marshal(blindingFactors)
+	return genericblinding.MarshalEnvelope(blindingFactors)
 }
 
 // Unmarshal []byte into BlindingParamClient
 func (blindingFactors BlindingFactors) Unmarshal(b []byte) (genericblinding.BlindingData, error) {
 	n := new(BlindingFactors)
-	_, err := asn1.Unmarshal(b, n)
+	err := genericblinding.UnmarshalEnvelope(b, n)
 	if err != nil {
 		return nil, err
 	}
@@ -196,13 +198,13 @@ func (blindMessage BlindMessage) SchemeData() (string, genericblinding.DataType,
 
 // Marshal a BlindingParamClient
 func (blindMessage BlindMessage) Marshal() ([]byte, error) {
-	return asn1.Marshal(blindMessage)
+	return genericblinding.MarshalEnvelope(blindMessage)
 }
 
 // Unmarshal []byte into BlindingParamClient
 func (blindMessage BlindMessage) Unmarshal(b []byte) (genericblinding.BlindingData, error) {
 	n := new(BlindMessage)
-	_, err := asn1.Unmarshal(b, n)
+	err := genericblinding.UnmarshalEnvelope(b, n)
 	if err != nil {
 		return nil, err
 	}
@@ -215,7 +217,7 @@ func (blindMessage BlindMessage) Unmarshal(b []byte) (genericblinding.BlindingDa
 	if !eccutil.PointEqual(&blindMessage.PubKey, &n.PubKey) {
 		return nil, genericblinding.ErrBadSigner
 	}
-	return n, nil
+	return *n, nil
 }
 
 // UniqueID returns a unique ID for this element. Constant in this case (zeros)
@@ -255,13 +257,13 @@ func (blindSignature BlindSignature) SchemeData() (string, genericblinding.DataT
 
 // Marshal a BlindingParamClient
 func (blindSignature BlindSignature) Marshal() ([]byte, error) {
-	return asn1.Marshal(blindSignature)
+	return genericblinding.MarshalEnvelope(blindSignature)
 }
 
 // Unmarshal []byte into BlindingParamClient
 func (blindSignature BlindSignature) Unmarshal(b []byte) (genericblinding.BlindingData, error) {
 	n := new(BlindSignature)
-	_, err := asn1.Unmarshal(b, n)
+	err := genericblinding.UnmarshalEnvelope(b, n)
 	if err != nil {
 		return nil, err
 	}
@@ -316,13 +318,13 @@ func (clearSignature ClearSignature) SchemeData() (string, genericblinding.DataT
 
 // Marshal a BlindingParamClient
 func (clearSignature ClearSignature) Marshal() ([]byte, error) {
-	return asn1.Marshal(clearSignature)
+	return genericblinding.MarshalEnvelope(clearSignature)
 }
 
 // Unmarshal []byte into BlindingParamClient
 func (clearSignature ClearSignature) Unmarshal(b []byte) (genericblinding.BlindingData, error) {
 	n := new(ClearSignature)
-	_, err := asn1.Unmarshal(b, n)
+	err := genericblinding.UnmarshalEnvelope(b, n)
 	if err != nil {
 		return nil, err
 	}
@@ -335,7 +337,7 @@ func (clearSignature ClearSignature) Unmarshal(b []byte) (genericblinding.Blindi
 	if !eccutil.PointEqual(&clearSignature.PubKey, &n.PubKey) {
 		return nil, genericblinding.ErrBadSigner
 	}
-	return n, nil
+	return *n, nil
 }
 
 // UniqueID returns a unique ID for this element. Constant in this case (zeros)
@@ -376,13 +378,13 @@ func (blindingParamServer BlindingParamServer) SchemeData() (string, genericblin
 
 // Marshal a BlindingParamServer
 func (blindingParamServer BlindingParamServer) Marshal() ([]byte, error) {
-	return asn1.Marshal(blindingParamServer)
+	return genericblinding.MarshalEnvelope(blindingParamServer)
 }
 
 // Unmarshal []byte into BlindingParamServer
 func (blindingParamServer BlindingParamServer) Unmarshal(b []byte) (genericblinding.BlindingData, error) {
 	n := new(BlindingParamServer)
-	_, err := asn1.Unmarshal(b, n)
+	err := genericblinding.UnmarshalEnvelope(b, n)
 	if err != nil {
 		return nil, err
 	}
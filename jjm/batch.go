@@ -0,0 +1,121 @@
+package jjm
+
+// Batch issuance and verification over the generic blinding interface
+
+import (
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+	"math/big"
+)
+
+// GetParamsBatch returns n per-signature blinding parameters
+func (server *GenericBlindingServer) GetParamsBatch(n int) ([]genericblinding.BlindingParamClient, []genericblinding.BlindingParamServer, error) {
+	return genericblinding.GetParamsBatch(server.GetParams, n)
+}
+
+// SignBatch signs bmi[i] using bpsi[i]. All parameters and messages are checked, including for reuse within the
+// batch, before any is signed. Parameters that Sign has seen before fail the batch and use up the others
+func (server *GenericBlindingServer) SignBatch(bpsi []genericblinding.BlindingParamServer, bmi []genericblinding.BlindMessage) ([]genericblinding.BlindSignature, error) {
+	if len(bpsi) != len(bmi) {
+		return nil, genericblinding.ErrBatchLength
+	}
+	bps := make([]BlindingParamServer, len(bpsi))
+	bm := make([]BlindMessage, len(bmi))
+	errs := make(genericblinding.BatchError, len(bmi))
+	batch := make(map[string]bool)
+	for i := range bpsi {
+		bps[i], bm[i], errs[i] = server.checkSign(bpsi[i], bmi[i])
+		if errs[i] != nil {
+			continue
+		}
+		id := string(bps[i].UniqueID())
+		if batch[id] {
+			errs[i] = eccutil.ErrParamReuse
+		}
+		batch[id] = true
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	for i := range bps {
+		if !server.uniqueTest(uniqueToken(bps[i])) {
+			errs[i] = eccutil.ErrParamReuse
+		}
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	sigs := make([]genericblinding.BlindSignature, len(bm))
+	for i := range bm {
+		sigs[i], errs[i] = server.sign(bps[i], bm[i])
+	}
+	return sigs, errs.Err()
+}
+
+// VerifyBatch verifies that csi[i] is a signature of cmi[i]. All signatures are checked at once with a random
+// linear combination of their verification equations. Only if that fails are they verified one by one to find
+// the failed items
+func (client *GenericBlindingClient) VerifyBatch(csi []genericblinding.ClearSignature, cmi []genericblinding.ClearMessage) (bool, error) {
+	if len(csi) != len(cmi) {
+		return false, genericblinding.ErrBatchLength
+	}
+	errs := make(genericblinding.BatchError, len(csi))
+	var items []int
+	var cs []ClearSignature
+	var cm []ClearMessage
+	for i := range csi {
+		s, m, err := client.checkVerify(csi[i], cmi[i])
+		if err == nil && (!client.curve.IsScalar(s.ScalarS) || !client.curve.IsScalar(s.ScalarR) || !client.curve.IsOnCurve(&s.PointR)) {
+			err = genericblinding.ErrBadSignature
+		}
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		items = append(items, i)
+		cs = append(cs, s)
+		cm = append(cm, m)
+	}
+	if len(items) > 0 {
+		ok, err := client.verifyCombined(cs, cm)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			for _, i := range items {
+				errs[i] = genericblinding.VerifyItem(client.Verify, csi[i], cmi[i])
+			}
+		}
+	}
+	if err := errs.Err(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// verifyCombined tests the sum of the verification equations m x SigPub = s x Generator + r x R, each
+// multiplied by a random coefficient a:
+//
+//	(sum a*m) x SigPub =? (sum a*s) x Generator + sum (a*r) x R
+func (client *GenericBlindingClient) verifyCombined(cs []ClearSignature, cm []ClearMessage) (bool, error) {
+	a, err := client.curve.RandomCoefficients(len(cs))
+	if err != nil {
+		return false, err
+	}
+	m := new(big.Int)
+	s := new(big.Int)
+	points := []*eccutil.Point{eccutil.NewPoint(client.curve.Params.Gx, client.curve.Params.Gy)}
+	scalars := []*big.Int{s}
+	for i := range cs {
+		m.Add(m, new(big.Int).Mul(a[i], new(big.Int).SetBytes(cm[i].UniqueID())))
+		s.Add(s, new(big.Int).Mul(a[i], new(big.Int).Abs(cs[i].ScalarS)))
+		points = append(points, &cs[i].PointR)
+		scalars = append(scalars, new(big.Int).Mul(a[i], new(big.Int).Abs(cs[i].ScalarR)))
+	}
+	lsP := client.curve.ScalarMult(client.PubKey, client.curve.Mod(m).Bytes())
+	rsP, err := client.curve.LinearCombination(points, scalars)
+	if err != nil {
+		return false, err
+	}
+	return eccutil.PointEqual(lsP, rsP), nil
+}
@@ -0,0 +1,66 @@
+package jjm
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+)
+
+// batchSize is the number of signatures verified in the batch benchmarks
+const batchSize = 16
+
+// signBatch returns a client and batchSize signatures with their messages
+func signBatch(b *testing.B) (*GenericBlindingClient, []genericblinding.ClearSignature, []genericblinding.ClearMessage) {
+	c := eccutil.SetCurve(elliptic.P256, rand.Reader, eccutil.Sha1Hash)
+	privkey, pubkey, err := c.GenerateKey()
+	if err != nil {
+		b.Fatalf("Error creating keys: %s", err)
+	}
+	signer := NewGenericBlindingServer(privkey, pubkey, c)
+	client := NewGenericBlindingClient(pubkey, c)
+	css := make([]genericblinding.ClearSignature, batchSize)
+	cms := make([]genericblinding.ClearMessage, batchSize)
+	for i := range css {
+		bpc, bps, err := signer.GetParams()
+		if err != nil {
+			b.Fatalf("Error GetParams: %s", err)
+		}
+		cm := NewClearMessage([]byte{byte(i)})
+		bf, bm, err := client.Blind(bpc, cm)
+		if err != nil {
+			b.Fatalf("Error Blind: %s", err)
+		}
+		bs, err := signer.Sign(bps, bm)
+		if err != nil {
+			b.Fatalf("Error Sign: %s", err)
+		}
+		css[i], cms[i], err = client.Unblind(bf, cm, bs)
+		if err != nil {
+			b.Fatalf("Error Unblind: %s", err)
+		}
+	}
+	return client, css, cms
+}
+
+func Benchmark_VerifyBatch(b *testing.B) {
+	client, css, cms := signBatch(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if ok, err := client.VerifyBatch(css, cms); !ok || err != nil {
+			b.Fatalf("VerifyBatch failed: %v", err)
+		}
+	}
+}
+
+func Benchmark_VerifyEach(b *testing.B) {
+	client, css, cms := signBatch(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if ok, err := genericblinding.VerifyEach(client.Verify, css, cms); !ok || err != nil {
+			b.Fatalf("VerifyEach failed: %v", err)
+		}
+	}
+}
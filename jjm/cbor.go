@@ -0,0 +1,117 @@
+package jjm
+
+import (
+	"github.com/ronperry/cryptoedge/genericblinding"
+)
+
+// MarshalCBOR returns the deterministic CBOR encoding of a BlindingParamClient
+func (blindingParamClient BlindingParamClient) MarshalCBOR() ([]byte, error) {
+	return genericblinding.MarshalCBOR(blindingParamClient)
+}
+
+// UnmarshalCBOR loads CBOR produced by MarshalCBOR. blindingParamClient must have been created by NewBlindingParamClient and is used for verification like in Unmarshal
+func (blindingParamClient *BlindingParamClient) UnmarshalCBOR(b []byte) error {
+	n := new(BlindingParamClient)
+	err := genericblinding.UnmarshalCBOR(b, n, *blindingParamClient)
+	if err != nil {
+		return err
+	}
+	*blindingParamClient = *n
+	return nil
+}
+
+// MarshalCBOR returns the deterministic CBOR encoding of a BlindingParamServer
+func (blindingParamServer BlindingParamServer) MarshalCBOR() ([]byte, error) {
+	return genericblinding.MarshalCBOR(blindingParamServer)
+}
+
+// UnmarshalCBOR loads CBOR produced by MarshalCBOR. blindingParamServer must have been created by NewBlindingParamServer and is used for verification like in Unmarshal
+func (blindingParamServer *BlindingParamServer) UnmarshalCBOR(b []byte) error {
+	n := new(BlindingParamServer)
+	err := genericblinding.UnmarshalCBOR(b, n, *blindingParamServer)
+	if err != nil {
+		return err
+	}
+	*blindingParamServer = *n
+	return nil
+}
+
+// MarshalCBOR returns the deterministic CBOR encoding of a ClearMessage
+func (clearMessage ClearMessage) MarshalCBOR() ([]byte, error) {
+	return genericblinding.MarshalCBOR(clearMessage)
+}
+
+// UnmarshalCBOR loads CBOR produced by MarshalCBOR. clearMessage must have been created by NewClearMessage and is used for verification like in Unmarshal
+func (clearMessage *ClearMessage) UnmarshalCBOR(b []byte) error {
+	n := new(ClearMessage)
+	err := genericblinding.UnmarshalCBOR(b, n, *clearMessage)
+	if err != nil {
+		return err
+	}
+	*clearMessage = *n
+	return nil
+}
+
+// MarshalCBOR returns the deterministic CBOR encoding of a BlindingFactors
+func (blindingFactors BlindingFactors) MarshalCBOR() ([]byte, error) {
+	return genericblinding.MarshalCBOR(blindingFactors)
+}
+
+// UnmarshalCBOR loads CBOR produced by MarshalCBOR. blindingFactors must have been created by NewBlindingFactors and is used for verification like in Unmarshal
+func (blindingFactors *BlindingFactors) UnmarshalCBOR(b []byte) error {
+	n := new(BlindingFactors)
+	err := genericblinding.UnmarshalCBOR(b, n, *blindingFactors)
+	if err != nil {
+		return err
+	}
+	*blindingFactors = *n
+	return nil
+}
+
+// MarshalCBOR returns the deterministic CBOR encoding of a BlindMessage
+func (blindMessage BlindMessage) MarshalCBOR() ([]byte, error) {
+	return genericblinding.MarshalCBOR(blindMessage)
+}
+
+// UnmarshalCBOR loads CBOR produced by MarshalCBOR. blindMessage must have been created by NewBlindMessage and is used for verification like in Unmarshal
+func (blindMessage *BlindMessage) UnmarshalCBOR(b []byte) error {
+	n := new(BlindMessage)
+	err := genericblinding.UnmarshalCBOR(b, n, *blindMessage)
+	if err != nil {
+		return err
+	}
+	*blindMessage = *n
+	return nil
+}
+
+// MarshalCBOR returns the deterministic CBOR encoding of a BlindSignature
+func (blindSignature BlindSignature) MarshalCBOR() ([]byte, error) {
+	return genericblinding.MarshalCBOR(blindSignature)
+}
+
+// UnmarshalCBOR loads CBOR produced by MarshalCBOR. blindSignature must have been created by NewBlindSignature and is used for verification like in Unmarshal
+func (blindSignature *BlindSignature) UnmarshalCBOR(b []byte) error {
+	n := new(BlindSignature)
+	err := genericblinding.UnmarshalCBOR(b, n, *blindSignature)
+	if err != nil {
+		return err
+	}
+	*blindSignature = *n
+	return nil
+}
+
+// MarshalCBOR returns the deterministic CBOR encoding of a ClearSignature
+func (clearSignature ClearSignature) MarshalCBOR() ([]byte, error) {
+	return genericblinding.MarshalCBOR(clearSignature)
+}
+
+// UnmarshalCBOR loads CBOR produced by MarshalCBOR. clearSignature must have been created by NewClearSignature and is used for verification like in Unmarshal
+func (clearSignature *ClearSignature) UnmarshalCBOR(b []byte) error {
+	n := new(ClearSignature)
+	err := genericblinding.UnmarshalCBOR(b, n, *clearSignature)
+	if err != nil {
+		return err
+	}
+	*clearSignature = *n
+	return nil
+}
@@ -4,14 +4,17 @@ package jjm
 // Implementation of generic blinding interface over JJM
 
 import (
+	"context"
 	"fmt"
 	"github.com/ronperry/cryptoedge/eccutil"
 	"github.com/ronperry/cryptoedge/genericblinding"
+	"time"
 )
 
 // GenericBlindingServer implements JJM blinding over generic interface
 type GenericBlindingServer struct {
 	Signer
+	uniqueTest func([32]byte) bool // Rejects the UniqueID of a BlindingParamServer given to Sign before
 }
 
 // GenericBlindingClient a blinding client
@@ -25,13 +28,22 @@ func NewGenericBlindingServer(privkey []byte, pubkey *eccutil.Point, curve *eccu
 	bs.curve = curve
 	bs.pubkey = pubkey
 	bs.privkey = privkey
+	bs.uniqueTest = eccutil.NewUniqueTest(eccutil.DefaultUniqueTokens)
 	return bs
 }
 
 // GetParams returns per-signature blinding parameters
 func (server *GenericBlindingServer) GetParams() (genericblinding.BlindingParamClient, genericblinding.BlindingParamServer, error) {
+	return server.GetParamsContext(context.Background())
+}
+
+// GetParamsContext is GetParams with a context
+func (server *GenericBlindingServer) GetParamsContext(ctx context.Context) (_ genericblinding.BlindingParamClient, _ genericblinding.BlindingParamServer, err error) {
+	start := time.Now()
+	defer func() { genericblinding.Observe(server.Observer, SchemeName, genericblinding.OpGetParams, start, err) }()
 	bs := NewSigner(server.privkey, server.pubkey, server.curve)
-	pub, priv, err := bs.NewSignRequest()
+	bs.Observer = server.Observer
+	pub, priv, err := bs.NewSignRequestContext(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -49,27 +61,60 @@ func (server *GenericBlindingServer) GetParams() (genericblinding.BlindingParamC
 	return clientparams, serverparams, nil
 }
 
-// Sign a blind message
+// Sign a blind message. Each BlindingParamServer can only be used once, Sign rejects the last
+// eccutil.DefaultUniqueTokens it has seen. Keep them in a genericblinding.ParamStore to reject older ones
 func (server *GenericBlindingServer) Sign(bpsi genericblinding.BlindingParamServer, bmi genericblinding.BlindMessage) (genericblinding.BlindSignature, error) {
-	_, err := genericblinding.MatchMessage(bpsi, SchemeName, genericblinding.TypeBlindingParamServer, server.pubkey)
+	return server.SignContext(context.Background(), bpsi, bmi)
+}
+
+// SignContext is Sign with a context
+func (server *GenericBlindingServer) SignContext(ctx context.Context, bpsi genericblinding.BlindingParamServer, bmi genericblinding.BlindMessage) (_ genericblinding.BlindSignature, err error) {
+	start := time.Now()
+	defer func() { genericblinding.Observe(server.Observer, SchemeName, genericblinding.OpSign, start, err) }()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	bps, bm, err := server.checkSign(bpsi, bmi)
 	if err != nil {
 		return nil, err
 	}
+	if !server.uniqueTest(uniqueToken(bps)) {
+		return nil, eccutil.ErrParamReuse
+	}
+	return server.sign(bps, bm)
+}
+
+// uniqueToken returns the token of bps for the uniqueness test
+func uniqueToken(bps BlindingParamServer) (t [32]byte) {
+	copy(t[:], bps.UniqueID())
+	return t
+}
+
+// checkSign converts the arguments of Sign and verifies that they belong to server
+func (server *GenericBlindingServer) checkSign(bpsi genericblinding.BlindingParamServer, bmi genericblinding.BlindMessage) (BlindingParamServer, BlindMessage, error) {
+	_, err := genericblinding.MatchMessage(bpsi, SchemeName, genericblinding.TypeBlindingParamServer, server.pubkey)
+	if err != nil {
+		return BlindingParamServer{}, BlindMessage{}, err
+	}
 	bps, ok := bpsi.(BlindingParamServer)
 	if !ok {
-		return nil, genericblinding.ErrBadType
+		return BlindingParamServer{}, BlindMessage{}, genericblinding.ErrBadType
 	}
 
 	_, err = genericblinding.MatchMessage(bmi, SchemeName, genericblinding.TypeBlindMessage, server.pubkey)
 	if err != nil {
-		return nil, err
+		return BlindingParamServer{}, BlindMessage{}, err
 	}
 	bm, ok := bmi.(BlindMessage)
 	if !ok {
 		fmt.Println("Message")
-		return nil, genericblinding.ErrBadType
+		return BlindingParamServer{}, BlindMessage{}, genericblinding.ErrBadType
 	}
+	return bps, bm, nil
+}
 
+// sign signs bm with bps after checkSign
+func (server *GenericBlindingServer) sign(bps BlindingParamServer, bm BlindMessage) (genericblinding.BlindSignature, error) {
 	bs := NewSigner(server.privkey, server.pubkey, server.curve)
 	blindmessage := new(BlindMessageInt)
 	blindmessage.M1, blindmessage.M2 = bm.M1, bm.M2
@@ -98,7 +143,14 @@ func NewGenericBlindingClient(pubkey *eccutil.Point, curve *eccutil.Curve) *Gene
 
 // Blind a message
 func (client *GenericBlindingClient) Blind(bpci genericblinding.BlindingParamClient, cmi genericblinding.ClearMessage) (genericblinding.BlindingFactors, genericblinding.BlindMessage, error) {
-	_, err := genericblinding.MatchMessage(bpci, SchemeName, genericblinding.TypeBlindingParamClient, client.PubKey)
+	return client.BlindContext(context.Background(), bpci, cmi)
+}
+
+// BlindContext is Blind with a context
+func (client *GenericBlindingClient) BlindContext(ctx context.Context, bpci genericblinding.BlindingParamClient, cmi genericblinding.ClearMessage) (_ genericblinding.BlindingFactors, _ genericblinding.BlindMessage, err error) {
+	start := time.Now()
+	defer func() { genericblinding.Observe(client.Observer, SchemeName, genericblinding.OpBlind, start, err) }()
+	_, err = genericblinding.MatchMessage(bpci, SchemeName, genericblinding.TypeBlindingParamClient, client.PubKey)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -116,11 +168,12 @@ func (client *GenericBlindingClient) Blind(bpci genericblinding.BlindingParamCli
 	}
 
 	bc := NewBlindingClient(client.curve, client.PubKey)
+	bc.Observer = client.Observer
 	serverParams := new(SignRequestPublicInt)
 	serverParams.PointRs1, serverParams.PointRs2 = &bpc.PointRs1, &bpc.PointRs2
 	serverParams.ScalarLs1, serverParams.ScalarLs2 = bpc.ScalarLs1, bpc.ScalarLs2
 
-	privateParams, err := bc.CalculateBlindingParams(serverParams)
+	privateParams, err := bc.CalculateBlindingParamsContext(ctx, serverParams)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -145,7 +198,17 @@ func (client *GenericBlindingClient) Blind(bpci genericblinding.BlindingParamCli
 
 // Unblind a signature
 func (client *GenericBlindingClient) Unblind(bfi genericblinding.BlindingFactors, cmi genericblinding.ClearMessage, bsi genericblinding.BlindSignature) (genericblinding.ClearSignature, genericblinding.ClearMessage, error) {
-	_, err := genericblinding.MatchMessage(bfi, SchemeName, genericblinding.TypeBlindingFactors, client.PubKey)
+	return client.UnblindContext(context.Background(), bfi, cmi, bsi)
+}
+
+// UnblindContext is Unblind with a context
+func (client *GenericBlindingClient) UnblindContext(ctx context.Context, bfi genericblinding.BlindingFactors, cmi genericblinding.ClearMessage, bsi genericblinding.BlindSignature) (_ genericblinding.ClearSignature, _ genericblinding.ClearMessage, err error) {
+	start := time.Now()
+	defer func() { genericblinding.Observe(client.Observer, SchemeName, genericblinding.OpUnblind, start, err) }()
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	_, err = genericblinding.MatchMessage(bfi, SchemeName, genericblinding.TypeBlindingFactors, client.PubKey)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -198,28 +261,47 @@ func (client *GenericBlindingClient) Unblind(bfi genericblinding.BlindingFactors
 
 // Verify a signature
 func (client *GenericBlindingClient) Verify(csi genericblinding.ClearSignature, cmi genericblinding.ClearMessage) (bool, error) {
-	_, err := genericblinding.MatchMessage(csi, SchemeName, genericblinding.TypeClearSignature, client.PubKey)
+	return client.VerifyContext(context.Background(), csi, cmi)
+}
+
+// VerifyContext is Verify with a context
+func (client *GenericBlindingClient) VerifyContext(ctx context.Context, csi genericblinding.ClearSignature, cmi genericblinding.ClearMessage) (ok bool, err error) {
+	start := time.Now()
+	defer func() { genericblinding.ObserveVerify(client.Observer, SchemeName, start, ok, err) }()
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	cs, cm, err := client.checkVerify(csi, cmi)
 	if err != nil {
 		return false, err
 	}
+
+	bc := NewBlindingClient(client.curve, client.PubKey)
+	signature := new(SignatureInt)
+	signature.PointR = &cs.PointR
+	signature.ScalarR = cs.ScalarR
+	signature.ScalarS = cs.ScalarS
+	return bc.Verify(cm.UniqueID(), signature), nil
+}
+
+// checkVerify converts the arguments of Verify and verifies that they belong to client
+func (client *GenericBlindingClient) checkVerify(csi genericblinding.ClearSignature, cmi genericblinding.ClearMessage) (ClearSignature, ClearMessage, error) {
+	_, err := genericblinding.MatchMessage(csi, SchemeName, genericblinding.TypeClearSignature, client.PubKey)
+	if err != nil {
+		return ClearSignature{}, ClearMessage{}, err
+	}
 	cs, ok := csi.(ClearSignature)
 	if !ok {
-		return false, genericblinding.ErrBadType
+		return ClearSignature{}, ClearMessage{}, genericblinding.ErrBadType
 	}
 
 	_, err = genericblinding.MatchMessage(cmi, SchemeName, genericblinding.TypeClearMessage, client.PubKey)
 	if err != nil {
-		return false, err
+		return ClearSignature{}, ClearMessage{}, err
 	}
 	cm, ok := cmi.(ClearMessage)
 	if !ok {
-		return false, genericblinding.ErrBadType
+		return ClearSignature{}, ClearMessage{}, genericblinding.ErrBadType
 	}
-
-	bc := NewBlindingClient(client.curve, client.PubKey)
-	signature := new(SignatureInt)
-	signature.PointR = &cs.PointR
-	signature.ScalarR = cs.ScalarR
-	signature.ScalarS = cs.ScalarS
-	return bc.Verify(cm.UniqueID(), signature), nil
+	return cs, cm, nil
 }
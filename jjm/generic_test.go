@@ -4,6 +4,8 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+	"math/big"
 	"testing"
 )
 
@@ -135,3 +137,63 @@ func Test_GenericVerify(t *testing.T) {
 	}
 	_, _, _, _, _ = clearsig, clearmsg, client, clientFactors, blindMessage
 }
+
+func Test_VerifyAndSpendMalleated(t *testing.T) {
+	c := eccutil.SetCurve(elliptic.P256, rand.Reader, eccutil.Sha1Hash)
+	privkey, pubkey, err := c.GenerateKey()
+	if err != nil {
+		t.Fatalf("Error creating keys: %s", err)
+	}
+	signer := NewGenericBlindingServer(privkey, pubkey, c)
+	client := NewGenericBlindingClient(pubkey, c)
+	clientParams, serverParams, err := signer.GetParams()
+	if err != nil {
+		t.Fatalf("Error GetParams: %s", err)
+	}
+	cm := NewClearMessage([]byte("Token to be redeemed once"))
+	clientFactors, blindMessage, err := client.Blind(clientParams, cm)
+	if err != nil {
+		t.Fatalf("Error Blind: %s", err)
+	}
+	blindSignature, err := signer.Sign(serverParams, blindMessage)
+	if err != nil {
+		t.Fatalf("Error Sign: %s", err)
+	}
+	csi, clearMessage, err := client.Unblind(clientFactors, cm, blindSignature)
+	if err != nil {
+		t.Fatalf("Error Unblind: %s", err)
+	}
+	store := genericblinding.NewMemorySpentStore()
+	if ok, err := genericblinding.VerifyAndSpend(client, store, csi, clearMessage); !ok || err != nil {
+		t.Fatalf("VerifyAndSpend failed: %v", err)
+	}
+	cs := csi.(ClearSignature)
+
+	// s+N and r+N are not canonical
+	plusN := cs
+	plusN.ScalarS = new(big.Int).Add(cs.ScalarS, c.Params.N)
+	if ok, _ := genericblinding.VerifyAndSpend(client, store, plusN, clearMessage); ok {
+		t.Error("VerifyAndSpend must fail for s+N")
+	}
+	plusN = cs
+	plusN.ScalarR = new(big.Int).Add(cs.ScalarR, c.Params.N)
+	if ok, _ := genericblinding.VerifyAndSpend(client, store, plusN, clearMessage); ok {
+		t.Error("VerifyAndSpend must fail for r+N")
+	}
+
+	// f x R and r/f is a valid signature of the same message
+	f := big.NewInt(2)
+	fInv, err := c.ModInverse(f)
+	if err != nil {
+		t.Fatalf("ModInverse failed: %s", err)
+	}
+	scaled := cs
+	scaled.PointR = *c.ScalarMult(&cs.PointR, f.Bytes())
+	scaled.ScalarR = new(big.Int).Mod(new(big.Int).Mul(cs.ScalarR, fInv), c.Params.N)
+	if ok, err := client.Verify(scaled, clearMessage); !ok || err != nil {
+		t.Fatalf("Scaled signature must verify: %v", err)
+	}
+	if ok, err := genericblinding.VerifyAndSpend(client, store, scaled, clearMessage); ok || err != genericblinding.ErrSpent {
+		t.Errorf("VerifyAndSpend must fail for scaled signature of spent message: %v", err)
+	}
+}
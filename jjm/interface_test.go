@@ -3,8 +3,10 @@ package jjm
 import (
 	"crypto/elliptic"
 	"crypto/rand"
+	"encoding/asn1"
 	"github.com/ronperry/cryptoedge/eccutil"
 	"github.com/ronperry/cryptoedge/genericblinding"
+	"github.com/ronperry/cryptoedge/genericblinding/conformance"
 	"testing"
 )
 
@@ -27,4 +29,54 @@ func Test_Interface(t *testing.T) {
 	_, _ = clientParams, serverParams
 }
 
-// // does not implement wrong type for method
+func Test_Upgrade(t *testing.T) {
+	c := eccutil.SetCurve(elliptic.P256, rand.Reader, eccutil.Sha1Hash)
+	privkey, pubkey, err := c.GenerateKey()
+	if err != nil {
+		t.Fatalf("Error creating keys: %s", err)
+	}
+	signer := NewGenericBlindingServer(privkey, pubkey, c)
+	clientParams, _, err := signer.GetParams()
+	if err != nil {
+		t.Fatalf("Error GetParams: %s", err)
+	}
+	bare, err := asn1.Marshal(clientParams)
+	if err != nil {
+		t.Fatalf("Marshalling failed: %s", err)
+	}
+	b, err := genericblinding.Upgrade(bare, pubkey)
+	if err != nil {
+		t.Fatalf("Upgrade failed: %s", err)
+	}
+	e, err := genericblinding.OpenEnvelope(b)
+	if err != nil {
+		t.Fatalf("OpenEnvelope failed: %s", err)
+	}
+	if e.Version != genericblinding.EnvelopeVersion {
+		t.Errorf("Upgrade did not produce current envelope: %d", e.Version)
+	}
+	n, err := clientParams.Marshal()
+	if err != nil {
+		t.Fatalf("Marshalling failed: %s", err)
+	}
+	if string(n) != string(b) {
+		t.Error("Upgrade differs from Marshal")
+	}
+}
+
+func Test_Conformance(t *testing.T) {
+	c := eccutil.SetCurve(elliptic.P256, rand.Reader, eccutil.Sha1Hash)
+	conformance.Run(t, conformance.Suite{
+		NewPair: func() (genericblinding.BlindingServer, genericblinding.BlindingClient, *eccutil.Point, error) {
+			privkey, pubkey, err := c.GenerateKey()
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			return NewGenericBlindingServer(privkey, pubkey, c), NewGenericBlindingClient(pubkey, c), pubkey, nil
+		},
+		NewClearMessage: func(msg []byte) genericblinding.ClearMessage {
+			return NewClearMessage(msg)
+		},
+		OneTimeParams: true,
+	})
+}
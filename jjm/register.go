@@ -0,0 +1,42 @@
+package jjm
+
+import (
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+)
+
+func init() {
+	genericblinding.RegisterTag(SchemeName, SchemeTag)
+	genericblinding.RegisterFactory(SchemeName, genericblinding.Factory{
+		NewServer: func(privKey []byte, pubKey *eccutil.Point, curve *eccutil.Curve) genericblinding.BlindingServer {
+			return NewGenericBlindingServer(privKey, pubKey, curve)
+		},
+		NewClient: func(pubKey *eccutil.Point, curve *eccutil.Curve) genericblinding.BlindingClient {
+			return NewGenericBlindingClient(pubKey, curve)
+		},
+		NewClearMessage: func(msg []byte) genericblinding.ClearMessage {
+			return NewClearMessage(msg)
+		},
+	})
+	genericblinding.Register(SchemeName, genericblinding.TypeBlindingParamClient, func(pubKey *eccutil.Point) genericblinding.BlindingData {
+		return NewBlindingParamClient(pubKey)
+	})
+	genericblinding.Register(SchemeName, genericblinding.TypeClearMessage, func(pubKey *eccutil.Point) genericblinding.BlindingData {
+		return NewClearMessage(nil)
+	})
+	genericblinding.Register(SchemeName, genericblinding.TypeBlindingFactors, func(pubKey *eccutil.Point) genericblinding.BlindingData {
+		return NewBlindingFactors(pubKey)
+	})
+	genericblinding.Register(SchemeName, genericblinding.TypeBlindMessage, func(pubKey *eccutil.Point) genericblinding.BlindingData {
+		return NewBlindMessage(pubKey)
+	})
+	genericblinding.Register(SchemeName, genericblinding.TypeBlindSignature, func(pubKey *eccutil.Point) genericblinding.BlindingData {
+		return NewBlindSignature(pubKey)
+	})
+	genericblinding.Register(SchemeName, genericblinding.TypeClearSignature, func(pubKey *eccutil.Point) genericblinding.BlindingData {
+		return NewClearSignature(pubKey)
+	})
+	genericblinding.Register(SchemeName, genericblinding.TypeBlindingParamServer, func(pubKey *eccutil.Point) genericblinding.BlindingData {
+		return NewBlindingParamServer(pubKey)
+	})
+}
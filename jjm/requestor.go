@@ -48,7 +48,9 @@ Unblinding phase:
 */
 
 import (
+	"context"
 	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
 	"math/big"
 )
 
@@ -79,8 +81,9 @@ type SignatureInt struct {
 
 // BlindingClient a blinding client
 type BlindingClient struct {
-	curve  *eccutil.Curve
-	PubKey *eccutil.Point
+	curve    *eccutil.Curve
+	PubKey   *eccutil.Point
+	Observer genericblinding.Observer // Receives the retries of CalculateBlindingParams, may be nil
 }
 
 // NewBlindingClient returns a new BlindingClient
@@ -110,7 +113,8 @@ func (client BlindingClient) Unblind(blindSignature *BlindSignatureInt, Blinding
 	ScalarS1 = ScalarS1.Mod(ScalarS1, client.curve.Params.N) // s1 = (ss1 * inv(rs1) * r1 * r2 * w * a)  mod N
 	ScalarS2 = ScalarS2.Mod(ScalarS2, client.curve.Params.N) // s2 = (ss2 * inv(rs2) * r1 * r2 * z * b)  mod N
 	ScalarS := new(big.Int)
-	ScalarS = ScalarS.Add(ScalarS1, ScalarS2) // s = s1 + s2
+	ScalarS = ScalarS.Add(ScalarS1, ScalarS2)             // s = s1 + s2
+	ScalarS = ScalarS.Mod(ScalarS, client.curve.Params.N) // s = (s1 + s2) mod N, Verify only accepts s < N
 
 	PointR := client.curve.AddPoints(BlindingParams.PointR1, BlindingParams.PointR2) // R = R1 + R2
 
@@ -191,8 +195,16 @@ func (client BlindingClient) Blind(msg []byte, SignerParams *SignRequestPublicIn
 
 // CalculateBlindingParams generates the w,z,e,d,a,b privateParams blinding parameters and calculates r1,r2 (included in privateParams)
 func (client BlindingClient) CalculateBlindingParams(params *SignRequestPublicInt) (privateParams *BlindingParamsPrivateInt, err error) {
+	return client.CalculateBlindingParamsContext(context.Background(), params)
+}
+
+// CalculateBlindingParamsContext is CalculateBlindingParams with a context. The parameter search stops with the error of ctx when ctx is done
+func (client BlindingClient) CalculateBlindingParamsContext(ctx context.Context, params *SignRequestPublicInt) (privateParams *BlindingParamsPrivateInt, err error) {
 	var loopcount int
 	for {
+		if loopcount > 0 {
+			genericblinding.ObserveRetry(client.Observer, SchemeName, genericblinding.OpBlind)
+		}
 		if loopcount > eccutil.MaxLoopCount {
 			return nil, eccutil.ErrMaxLoop
 		}
@@ -206,6 +218,9 @@ func (client BlindingClient) CalculateBlindingParams(params *SignRequestPublicIn
 		}
 
 		loopcount++
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		ScalarW, err := client.curve.GenNVint() // This limits W substantially and is likely unnecessary
 		if err != nil {
 			continue
@@ -1,15 +1,18 @@
 package jjm
 
 import (
+	"context"
 	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
 	"math/big"
 )
 
 // Signer is a single signer
 type Signer struct {
-	curve   *eccutil.Curve
-	privkey []byte
-	pubkey  *eccutil.Point
+	curve    *eccutil.Curve
+	privkey  []byte
+	pubkey   *eccutil.Point
+	Observer genericblinding.Observer // Receives the retries of NewSignRequest, may be nil
 }
 
 // SignRequestPublicInt are the public parameters given to a signature requestor
@@ -44,12 +47,23 @@ func NewSigner(privkey []byte, pubkey *eccutil.Point, curve *eccutil.Curve) *Sig
 // NewSignRequest creates a new signature request parameter set.
 // Public is given to requestor, Private is kept for later signature
 func (signer *Signer) NewSignRequest() (Public *SignRequestPublicInt, Private *SignRequestPrivateInt, err error) {
+	return signer.NewSignRequestContext(context.Background())
+}
+
+// NewSignRequestContext is NewSignRequest with a context. The parameter search stops with the error of ctx when ctx is done
+func (signer *Signer) NewSignRequestContext(ctx context.Context) (Public *SignRequestPublicInt, Private *SignRequestPrivateInt, err error) {
 	var loopcount int
 	for {
+		if loopcount > 0 {
+			genericblinding.ObserveRetry(signer.Observer, SchemeName, genericblinding.OpGetParams)
+		}
 		if loopcount > eccutil.MaxLoopCount {
 			return nil, nil, eccutil.ErrMaxLoop
 		}
 		loopcount++
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
 		ScalarKs1B, PointRs1, err := signer.curve.GenerateKey()
 		if err != nil {
 			continue
@@ -15,6 +15,12 @@ Verification phase:
 // Verify verifies that a signature does actually verify for a given message and signer public key
 func (client BlindingClient) Verify(msg []byte, signature *SignatureInt) bool {
 	// m x SugPub =? s x Generator + r x R
+	if !client.curve.IsOnCurve(signature.PointR) {
+		return false
+	}
+	if !client.curve.IsScalar(signature.ScalarS) || !client.curve.IsScalar(signature.ScalarR) {
+		return false
+	}
 	lsP := client.curve.ScalarMult(client.PubKey, msg)                           // m x SugPub
 	rsP1 := client.curve.ScalarBaseMult(signature.ScalarS.Bytes())               // s x Generator
 	rsP2 := client.curve.ScalarMult(signature.PointR, signature.ScalarR.Bytes()) // r x R
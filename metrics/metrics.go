@@ -0,0 +1,229 @@
+// Package metrics collects the measurements of the blind signature schemes and exports them in the Prometheus
+// text format. A Collector is a genericblinding.Observer, set it as Observer of the generic servers and clients
+// and serve it over HTTP:
+//
+//	c := metrics.NewCollector(nil)
+//	server := singhdas.NewGenericBlindingServer(privkey, pubkey, curve)
+//	server.Observer = c
+//	http.Handle("/metrics", c)
+//
+// The Collector exports three metrics, all labelled with scheme and op:
+//
+//	cryptoedge_operations_total            counter of finished operations, also labelled with result
+//	cryptoedge_operation_duration_seconds  histogram of the duration of operations
+//	cryptoedge_retries_total               counter of discarded candidates in parameter searches
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+)
+
+// Results of operations
+const (
+	ResultOK       = "ok"
+	ResultReuse    = "reuse"    // eccutil.ErrParamReuse or genericblinding.ErrParamUsed
+	ResultMaxLoop  = "maxloop"  // eccutil.ErrMaxLoop
+	ResultInvalid  = "invalid"  // genericblinding.ErrBadSignature
+	ResultCanceled = "canceled" // Context cancelled or deadline exceeded
+	ResultError    = "error"    // All other errors
+)
+
+// DefaultBuckets are the upper bounds in seconds of the duration histogram if NewCollector is given none
+var DefaultBuckets = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5}
+
+// resultMap maps errors to results. Errors are matched with errors.Is, in order
+var resultMap = []struct {
+	err    error
+	result string
+}{
+	{eccutil.ErrParamReuse, ResultReuse},
+	{genericblinding.ErrParamUsed, ResultReuse},
+	{eccutil.ErrMaxLoop, ResultMaxLoop},
+	{genericblinding.ErrBadSignature, ResultInvalid},
+	{context.Canceled, ResultCanceled},
+	{context.DeadlineExceeded, ResultCanceled},
+}
+
+// Result returns the result that err is counted as
+func Result(err error) string {
+	if err == nil {
+		return ResultOK
+	}
+	for _, m := range resultMap {
+		if errors.Is(err, m.err) {
+			return m.result
+		}
+	}
+	return ResultError
+}
+
+// label identifies the series of an operation
+type label struct {
+	scheme, op string
+}
+
+// histogram is the duration histogram of one label. counts[i] is the number of observations in bucket i, the
+// last entry counts those above all bounds
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// Collector is a genericblinding.Observer that keeps counters and histograms in memory and serves them in the
+// Prometheus text format
+type Collector struct {
+	lock       sync.Mutex
+	buckets    []float64
+	operations map[label]map[string]uint64 // Count per result
+	durations  map[label]*histogram
+	retries    map[label]uint64
+}
+
+// NewCollector returns a Collector whose duration histogram has the upper bounds buckets, in seconds and
+// ascending. It uses DefaultBuckets if buckets is nil
+func NewCollector(buckets []float64) *Collector {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	c := new(Collector)
+	c.buckets = append([]float64(nil), buckets...)
+	sort.Float64s(c.buckets)
+	c.operations = make(map[label]map[string]uint64)
+	c.durations = make(map[label]*histogram)
+	c.retries = make(map[label]uint64)
+	return c
+}
+
+// Observe counts op of scheme with the result of err and adds d to its histogram
+func (c *Collector) Observe(scheme, op string, d time.Duration, err error) {
+	l := label{scheme, op}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.operations[l] == nil {
+		c.operations[l] = make(map[string]uint64)
+	}
+	c.operations[l][Result(err)]++
+	h := c.durations[l]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(c.buckets)+1)}
+		c.durations[l] = h
+	}
+	s := d.Seconds()
+	h.counts[sort.SearchFloat64s(c.buckets, s)]++
+	h.sum += s
+	h.count++
+}
+
+// Retry counts a retry of op of scheme
+func (c *Collector) Retry(scheme, op string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.retries[label{scheme, op}]++
+}
+
+// ServeHTTP writes all metrics in the Prometheus text format
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if r.Method == http.MethodHead {
+		return
+	}
+	w.Write([]byte(c.String()))
+}
+
+// String returns all metrics in the Prometheus text format. Series are sorted by their labels
+func (c *Collector) String() string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	b := new(strings.Builder)
+
+	writeHeader(b, "cryptoedge_operations_total", "counter", "Finished operations of blind signature schemes.")
+	var labels []label
+	for l := range c.operations {
+		labels = append(labels, l)
+	}
+	for _, l := range sortLabels(labels) {
+		results := make([]string, 0, len(c.operations[l]))
+		for result := range c.operations[l] {
+			results = append(results, result)
+		}
+		sort.Strings(results)
+		for _, result := range results {
+			fmt.Fprintf(b, "cryptoedge_operations_total{%s,result=%q} %d\n", l, result, c.operations[l][result])
+		}
+	}
+
+	writeHeader(b, "cryptoedge_operation_duration_seconds", "histogram", "Duration of operations of blind signature schemes.")
+	labels = labels[:0]
+	for l := range c.durations {
+		labels = append(labels, l)
+	}
+	for _, l := range sortLabels(labels) {
+		h := c.durations[l]
+		var cumulative uint64
+		for i, bound := range c.buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(b, "cryptoedge_operation_duration_seconds_bucket{%s,le=%q} %d\n", l, formatFloat(bound), cumulative)
+		}
+		fmt.Fprintf(b, "cryptoedge_operation_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", l, h.count)
+		fmt.Fprintf(b, "cryptoedge_operation_duration_seconds_sum{%s} %s\n", l, formatFloat(h.sum))
+		fmt.Fprintf(b, "cryptoedge_operation_duration_seconds_count{%s} %d\n", l, h.count)
+	}
+
+	writeHeader(b, "cryptoedge_retries_total", "counter", "Discarded candidates in parameter searches of blind signature schemes.")
+	labels = labels[:0]
+	for l := range c.retries {
+		labels = append(labels, l)
+	}
+	for _, l := range sortLabels(labels) {
+		fmt.Fprintf(b, "cryptoedge_retries_total{%s} %d\n", l, c.retries[l])
+	}
+	return b.String()
+}
+
+// String returns the label pairs of l
+func (l label) String() string {
+	return "scheme=\"" + escape(l.scheme) + "\",op=\"" + escape(l.op) + "\""
+}
+
+// writeHeader writes the HELP and TYPE lines of metric name
+func writeHeader(b *strings.Builder, name, typ, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// sortLabels sorts labels by scheme and op
+func sortLabels(labels []label) []label {
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].scheme != labels[j].scheme {
+			return labels[i].scheme < labels[j].scheme
+		}
+		return labels[i].op < labels[j].op
+	})
+	return labels
+}
+
+// escape escapes a label value for the text format
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// formatFloat formats f for the text format
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
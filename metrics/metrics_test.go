@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"context"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+	"github.com/ronperry/cryptoedge/singhdas"
+)
+
+func Test_Result(t *testing.T) {
+	for _, tc := range []struct {
+		err    error
+		result string
+	}{
+		{nil, ResultOK},
+		{eccutil.ErrParamReuse, ResultReuse},
+		{genericblinding.ErrParamUsed, ResultReuse},
+		{fmt.Errorf("wrapped: %w", eccutil.ErrMaxLoop), ResultMaxLoop},
+		{genericblinding.ErrBadSignature, ResultInvalid},
+		{context.DeadlineExceeded, ResultCanceled},
+		{genericblinding.ErrBadType, ResultError},
+	} {
+		if r := Result(tc.err); r != tc.result {
+			t.Errorf("%v: expected %s, got %s", tc.err, tc.result, r)
+		}
+	}
+}
+
+func Test_Collector(t *testing.T) {
+	c := NewCollector([]float64{1, 0.1})
+	c.Observe("B", genericblinding.OpSign, 50*time.Millisecond, nil)
+	c.Observe("B", genericblinding.OpSign, 500*time.Millisecond, eccutil.ErrParamReuse)
+	c.Observe("A\"", genericblinding.OpSign, 5*time.Second, nil)
+	c.Retry("B", genericblinding.OpGetParams)
+	c.Retry("B", genericblinding.OpGetParams)
+	expected := `# HELP cryptoedge_operations_total Finished operations of blind signature schemes.
+# TYPE cryptoedge_operations_total counter
+cryptoedge_operations_total{scheme="A\"",op="sign",result="ok"} 1
+cryptoedge_operations_total{scheme="B",op="sign",result="ok"} 1
+cryptoedge_operations_total{scheme="B",op="sign",result="reuse"} 1
+# HELP cryptoedge_operation_duration_seconds Duration of operations of blind signature schemes.
+# TYPE cryptoedge_operation_duration_seconds histogram
+cryptoedge_operation_duration_seconds_bucket{scheme="A\"",op="sign",le="0.1"} 0
+cryptoedge_operation_duration_seconds_bucket{scheme="A\"",op="sign",le="1"} 0
+cryptoedge_operation_duration_seconds_bucket{scheme="A\"",op="sign",le="+Inf"} 1
+cryptoedge_operation_duration_seconds_sum{scheme="A\"",op="sign"} 5
+cryptoedge_operation_duration_seconds_count{scheme="A\"",op="sign"} 1
+cryptoedge_operation_duration_seconds_bucket{scheme="B",op="sign",le="0.1"} 1
+cryptoedge_operation_duration_seconds_bucket{scheme="B",op="sign",le="1"} 2
+cryptoedge_operation_duration_seconds_bucket{scheme="B",op="sign",le="+Inf"} 2
+cryptoedge_operation_duration_seconds_sum{scheme="B",op="sign"} 0.55
+cryptoedge_operation_duration_seconds_count{scheme="B",op="sign"} 2
+# HELP cryptoedge_retries_total Discarded candidates in parameter searches of blind signature schemes.
+# TYPE cryptoedge_retries_total counter
+cryptoedge_retries_total{scheme="B",op="getParams"} 2
+`
+	if got := c.String(); got != expected {
+		t.Errorf("Wrong output:\n%s", got)
+	}
+}
+
+func Test_Scheme(t *testing.T) {
+	c := NewCollector(nil)
+	curve := eccutil.SetCurve(elliptic.P256, rand.Reader, eccutil.Sha1Hash)
+	privkey, pubkey, err := curve.GenerateKey()
+	if err != nil {
+		t.Fatalf("Error creating keys: %s", err)
+	}
+	server := singhdas.NewGenericBlindingServer(privkey, pubkey, curve)
+	server.Observer = c
+	client := singhdas.NewGenericBlindingClient(pubkey, curve)
+	client.Observer = c
+	bpc, bps, err := server.GetParams()
+	if err != nil {
+		t.Fatalf("GetParams failed: %s", err)
+	}
+	cm := singhdas.NewClearMessage([]byte("Message to sign"))
+	bf, bm, err := client.Blind(bpc, cm)
+	if err != nil {
+		t.Fatalf("Blind failed: %s", err)
+	}
+	bs, err := server.Sign(bps, bm)
+	if err != nil {
+		t.Fatalf("Sign failed: %s", err)
+	}
+	_, err = server.Sign(bps, cm)
+	if err != genericblinding.ErrBadType {
+		t.Fatalf("Sign of wrong type not detected: %v", err)
+	}
+	_, err = server.Sign(bps, bm)
+	if err != eccutil.ErrParamReuse {
+		t.Fatalf("Reuse not detected: %v", err)
+	}
+	stored := genericblinding.NewStoredServer(server, genericblinding.NewMemoryParamStore(time.Minute))
+	stored.Observer = c
+	id, bpc, err := stored.GetParams()
+	if err != nil {
+		t.Fatalf("GetParams of StoredServer failed: %s", err)
+	}
+	_, bm, err = client.Blind(bpc, cm)
+	if err != nil {
+		t.Fatalf("Blind failed: %s", err)
+	}
+	if _, err := stored.Sign(id, bm); err != nil {
+		t.Fatalf("Sign of StoredServer failed: %s", err)
+	}
+	if _, err := stored.Sign(id, bm); err != genericblinding.ErrParamUsed {
+		t.Fatalf("Reuse not detected by StoredServer: %v", err)
+	}
+	cs, _, err := client.Unblind(bf, cm, bs)
+	if err != nil {
+		t.Fatalf("Unblind failed: %s", err)
+	}
+	if ok, err := client.Verify(cs, cm); !ok || err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("Wrong content type: %s", rec.Header().Get("Content-Type"))
+	}
+	for _, line := range []string{
+		`cryptoedge_operations_total{scheme="SNG",op="getParams",result="ok"} 2`,
+		`cryptoedge_operations_total{scheme="SNG",op="blind",result="ok"} 2`,
+		`cryptoedge_operations_total{scheme="SNG",op="sign",result="ok"} 2`,
+		`cryptoedge_operations_total{scheme="SNG",op="sign",result="error"} 1`,
+		`cryptoedge_operations_total{scheme="SNG",op="sign",result="reuse"} 2`,
+		`cryptoedge_operations_total{scheme="SNG",op="unblind",result="ok"} 1`,
+		`cryptoedge_operations_total{scheme="SNG",op="verify",result="ok"} 1`,
+		`cryptoedge_operation_duration_seconds_count{scheme="SNG",op="sign"} 5`,
+	} {
+		if !strings.Contains(rec.Body.String(), line+"\n") {
+			t.Errorf("Missing %s", line)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Package pow puts a Hashcash style proof of work in front of genericblinding.BlindingServer.GetParams, which
+// generates fresh keys on every call and is expensive to flood. Server wraps a BlindingServer and runs
+// GetParams only for callers that present the Solution of a Challenge it issued. Sign is passed on unchanged.
+//
+// Challenges are stateless: they carry their difficulty and expiry and are protected by an HMAC, so the server
+// only remembers the challenges that have been solved, until they expire. The difficulty is the number of
+// leading zero bits of SHA-256 over the challenge and a counter. It rises with the rate of GetParams calls
+// above Server.TargetRate, one bit for each doubling.
+//
+// The solution is supplied by the transport through the context of the call, see WithSolution. Calls without
+// a valid solution fail with a *ChallengeError that carries a fresh challenge for the next attempt. blindhttp
+// transports challenges and solutions, its Client solves them.
+package pow
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sizes of the encoded challenge
+const (
+	nonceSize     = 16
+	macSize       = sha256.Size
+	challengeSize = 2 + 8 + nonceSize + macSize // Version, bits, expiry, nonce, MAC
+)
+
+const challengeVersion = 1
+
+var (
+	// ErrSolutionRequired is returned by GetParams if the call carries no solution
+	ErrSolutionRequired = errors.New("pow: Proof of work required")
+	// ErrBadChallenge is returned for challenges that are malformed or were not issued by the server
+	ErrBadChallenge = errors.New("pow: Invalid challenge")
+	// ErrBadSolution is returned for solutions that do not meet the difficulty of their challenge
+	ErrBadSolution = errors.New("pow: Invalid solution")
+	// ErrChallengeExpired is returned for solutions of expired challenges
+	ErrChallengeExpired = errors.New("pow: Challenge expired")
+	// ErrSolutionUsed is returned for solutions that have been used before
+	ErrSolutionUsed = errors.New("pow: Solution already used")
+)
+
+// ChallengeError is returned by GetParams for calls without a valid solution. It carries the challenge to
+// solve for the next attempt
+type ChallengeError struct {
+	Err       error // Reason, one of the errors of the package
+	Challenge Challenge
+}
+
+// Error returns the message of Err
+func (e *ChallengeError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns Err
+func (e *ChallengeError) Unwrap() error {
+	return e.Err
+}
+
+// Challenge is a puzzle issued by a Server
+type Challenge struct {
+	Bits    int       // Number of leading zero bits the hash of a solution must have
+	Expires time.Time // End of validity, in seconds
+	nonce   [nonceSize]byte
+	mac     [macSize]byte
+}
+
+// newChallenge returns a challenge of difficulty bits that expires at expires, authenticated with key
+func newChallenge(key []byte, bits int, expires time.Time) (Challenge, error) {
+	c := Challenge{Bits: bits, Expires: time.Unix(expires.Unix(), 0)}
+	if _, err := rand.Read(c.nonce[:]); err != nil {
+		return Challenge{}, err
+	}
+	copy(c.mac[:], c.computeMAC(key))
+	return c, nil
+}
+
+// payload returns the authenticated part of the encoding of c
+func (c Challenge) payload() []byte {
+	b := make([]byte, 0, challengeSize)
+	b = append(b, challengeVersion, byte(c.Bits))
+	b = binary.BigEndian.AppendUint64(b, uint64(c.Expires.Unix()))
+	return append(b, c.nonce[:]...)
+}
+
+// computeMAC returns the MAC of c under key
+func (c Challenge) computeMAC(key []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(c.payload())
+	return h.Sum(nil)
+}
+
+// Bytes returns the binary encoding of c
+func (c Challenge) Bytes() []byte {
+	return append(c.payload(), c.mac[:]...)
+}
+
+// String returns c encoded as unpadded URL safe base64
+func (c Challenge) String() string {
+	return base64.RawURLEncoding.EncodeToString(c.Bytes())
+}
+
+// ParseChallenge decodes a challenge encoded by String. The MAC is checked by the Server only
+func ParseChallenge(s string) (Challenge, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) != challengeSize || b[0] != challengeVersion {
+		return Challenge{}, ErrBadChallenge
+	}
+	var c Challenge
+	c.Bits = int(b[1])
+	c.Expires = time.Unix(int64(binary.BigEndian.Uint64(b[2:10])), 0)
+	copy(c.nonce[:], b[10:10+nonceSize])
+	copy(c.mac[:], b[10+nonceSize:])
+	return c, nil
+}
+
+// Solution is a counter whose hash together with Challenge meets the difficulty of the challenge
+type Solution struct {
+	Challenge Challenge
+	Counter   uint64
+}
+
+// String returns the challenge and the decimal counter separated by a dot
+func (s Solution) String() string {
+	return s.Challenge.String() + "." + strconv.FormatUint(s.Counter, 10)
+}
+
+// ParseSolution decodes a solution encoded by String
+func ParseSolution(s string) (Solution, error) {
+	cs, counter, ok := strings.Cut(s, ".")
+	if !ok {
+		return Solution{}, ErrBadSolution
+	}
+	c, err := ParseChallenge(cs)
+	if err != nil {
+		return Solution{}, err
+	}
+	n, err := strconv.ParseUint(counter, 10, 64)
+	if err != nil {
+		return Solution{}, ErrBadSolution
+	}
+	return Solution{Challenge: c, Counter: n}, nil
+}
+
+// Valid reports whether the hash of s has the leading zero bits its challenge demands
+func (s Solution) Valid() bool {
+	return zeroBits(s.Challenge.Bytes(), s.Counter) >= s.Challenge.Bits
+}
+
+// zeroBits returns the number of leading zero bits of SHA-256 over challenge and counter
+func zeroBits(challenge []byte, counter uint64) int {
+	b := binary.BigEndian.AppendUint64(append([]byte(nil), challenge...), counter)
+	h := sha256.Sum256(b)
+	n := 0
+	for _, x := range h {
+		if x != 0 {
+			return n + bits.LeadingZeros8(x)
+		}
+		n += 8
+	}
+	return n
+}
+
+// Solve searches the solution of c. It takes about 2^c.Bits hashes and stops with the error of ctx when ctx is
+// done
+func Solve(ctx context.Context, c Challenge) (Solution, error) {
+	challenge := c.Bytes()
+	for counter := uint64(0); ; counter++ {
+		if counter%4096 == 0 {
+			if err := ctx.Err(); err != nil {
+				return Solution{}, err
+			}
+		}
+		if zeroBits(challenge, counter) >= c.Bits {
+			return Solution{Challenge: c, Counter: counter}, nil
+		}
+	}
+}
+
+// solutionKey is the context key of the solution
+type solutionKey struct{}
+
+// WithSolution returns a context carrying the solution of the caller
+func WithSolution(ctx context.Context, s Solution) context.Context {
+	return context.WithValue(ctx, solutionKey{}, s)
+}
+
+// SolutionFrom returns the solution carried by ctx
+func SolutionFrom(ctx context.Context) (Solution, bool) {
+	s, ok := ctx.Value(solutionKey{}).(Solution)
+	return s, ok
+}
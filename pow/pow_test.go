@@ -0,0 +1,48 @@
+package pow
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_Encoding(t *testing.T) {
+	c, err := newChallenge([]byte("key"), 8, time.Unix(2000000, 0))
+	if err != nil {
+		t.Fatalf("newChallenge failed: %s", err)
+	}
+	s, err := Solve(context.Background(), c)
+	if err != nil {
+		t.Fatalf("Solve failed: %s", err)
+	}
+	if !s.Valid() {
+		t.Fatal("Solution must be valid")
+	}
+	parsed, err := ParseSolution(s.String())
+	if err != nil {
+		t.Fatalf("ParseSolution failed: %s", err)
+	}
+	if parsed != s {
+		t.Errorf("Solution changed by encoding: %v %v", parsed, s)
+	}
+	for _, bad := range []string{"", "x.1", c.String(), c.String() + ".x", c.String()[1:] + ".1"} {
+		if _, err := ParseSolution(bad); err == nil {
+			t.Errorf("ParseSolution must fail for %q", bad)
+		}
+	}
+}
+
+func Test_Solve(t *testing.T) {
+	c, err := newChallenge([]byte("key"), 200, time.Unix(2000000, 0))
+	if err != nil {
+		t.Fatalf("newChallenge failed: %s", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Solve(ctx, c); err != context.Canceled {
+		t.Errorf("Solve must stop with cancelled context: %v", err)
+	}
+	if (Solution{Challenge: c}).Valid() {
+		t.Error("Solution must be invalid")
+	}
+}
@@ -0,0 +1,179 @@
+package pow
+
+import (
+	"context"
+	"crypto/hmac"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ronperry/cryptoedge/genericblinding"
+)
+
+// Defaults of Server
+const (
+	DefaultMinBits    = 12
+	DefaultMaxBits    = 24 // Solved by clients within seconds
+	DefaultTargetRate = 10 // GetParams calls per second
+	DefaultTTL        = 2 * time.Minute
+	DefaultRateWindow = 10 * time.Second
+)
+
+// Server is a BlindingServer that only runs GetParams for calls that carry a valid solution
+type Server struct {
+	server     genericblinding.BlindingServerContext
+	key        []byte
+	lock       sync.Mutex
+	solved     map[[nonceSize]byte]time.Time // Nonces of solved challenges and their expiry
+	nextPurge  time.Time
+	rate       float64 // Decaying average of GetParams calls per second
+	rateTime   time.Time
+	MinBits    int              // Difficulty at or below TargetRate, DefaultMinBits if 0
+	MaxBits    int              // Maximum difficulty, DefaultMaxBits if 0
+	TargetRate float64          // Rate of GetParams calls per second above which difficulty rises, DefaultTargetRate if 0
+	TTL        time.Duration    // Validity of challenges, DefaultTTL if 0
+	RateWindow time.Duration    // Time constant of the rate average, DefaultRateWindow if 0
+	Clock      func() time.Time // Time source, time.Now if nil
+}
+
+// NewServer returns a Server that protects the GetParams of server with challenges authenticated with key.
+// Servers that share key accept each other's challenges but not each other's solved challenges
+func NewServer(server genericblinding.BlindingServer, key []byte) *Server {
+	s := new(Server)
+	s.server = genericblinding.ServerWithContext(server)
+	s.key = key
+	s.solved = make(map[[nonceSize]byte]time.Time)
+	return s
+}
+
+func (s *Server) now() time.Time {
+	if s.Clock != nil {
+		return s.Clock()
+	}
+	return time.Now()
+}
+
+// Challenge issues a challenge with the current difficulty
+func (s *Server) Challenge() (Challenge, error) {
+	now := s.now()
+	s.lock.Lock()
+	bits := s.difficulty(now)
+	s.lock.Unlock()
+	return newChallenge(s.key, bits, now.Add(s.ttl()))
+}
+
+func (s *Server) ttl() time.Duration {
+	if s.TTL == 0 {
+		return DefaultTTL
+	}
+	return s.TTL
+}
+
+// Difficulty returns the number of bits of the challenges issued now
+func (s *Server) Difficulty() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.difficulty(s.now())
+}
+
+// difficulty returns the difficulty at now. MinBits plus one bit for each doubling of the rate above
+// TargetRate, at most MaxBits
+func (s *Server) difficulty(now time.Time) int {
+	minBits, maxBits, target := s.MinBits, s.MaxBits, s.TargetRate
+	if minBits == 0 {
+		minBits = DefaultMinBits
+	}
+	if maxBits == 0 {
+		maxBits = DefaultMaxBits
+	}
+	if target == 0 {
+		target = DefaultTargetRate
+	}
+	bits := minBits
+	if rate := s.decayedRate(now); rate > target {
+		bits += int(math.Ceil(math.Log2(rate / target)))
+	}
+	if bits > maxBits {
+		bits = maxBits
+	}
+	return bits
+}
+
+// decayedRate returns the rate average at now
+func (s *Server) decayedRate(now time.Time) float64 {
+	if s.rateTime.IsZero() || !now.After(s.rateTime) {
+		return s.rate
+	}
+	return s.rate * math.Exp(-now.Sub(s.rateTime).Seconds()/s.rateWindow().Seconds())
+}
+
+func (s *Server) rateWindow() time.Duration {
+	if s.RateWindow == 0 {
+		return DefaultRateWindow
+	}
+	return s.RateWindow
+}
+
+// check verifies the solution carried by ctx and marks its challenge as solved
+func (s *Server) check(ctx context.Context) error {
+	solution, ok := SolutionFrom(ctx)
+	if !ok {
+		return ErrSolutionRequired
+	}
+	c := solution.Challenge
+	if !hmac.Equal(c.mac[:], c.computeMAC(s.key)) {
+		return ErrBadChallenge
+	}
+	now := s.now()
+	if !now.Before(c.Expires) {
+		return ErrChallengeExpired
+	}
+	if !solution.Valid() {
+		return ErrBadSolution
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if now.After(s.nextPurge) {
+		for nonce, expires := range s.solved {
+			if !now.Before(expires) {
+				delete(s.solved, nonce)
+			}
+		}
+		s.nextPurge = now.Add(s.ttl())
+	}
+	if _, used := s.solved[c.nonce]; used {
+		return ErrSolutionUsed
+	}
+	s.solved[c.nonce] = c.Expires
+	s.rate = s.decayedRate(now) + 1/s.rateWindow().Seconds()
+	s.rateTime = now
+	return nil
+}
+
+// GetParams generates one-time parameters if the caller solved a challenge
+func (s *Server) GetParams() (genericblinding.BlindingParamClient, genericblinding.BlindingParamServer, error) {
+	return s.GetParamsContext(context.Background())
+}
+
+// GetParamsContext is GetParams with a context, which carries the solution of the caller. If it carries no
+// valid solution, GetParamsContext returns a *ChallengeError
+func (s *Server) GetParamsContext(ctx context.Context) (genericblinding.BlindingParamClient, genericblinding.BlindingParamServer, error) {
+	if err := s.check(ctx); err != nil {
+		c, cerr := s.Challenge()
+		if cerr != nil {
+			return nil, nil, cerr
+		}
+		return nil, nil, &ChallengeError{Err: err, Challenge: c}
+	}
+	return s.server.GetParamsContext(ctx)
+}
+
+// Sign signs bm
+func (s *Server) Sign(bps genericblinding.BlindingParamServer, bm genericblinding.BlindMessage) (genericblinding.BlindSignature, error) {
+	return s.SignContext(context.Background(), bps, bm)
+}
+
+// SignContext is Sign with a context
+func (s *Server) SignContext(ctx context.Context, bps genericblinding.BlindingParamServer, bm genericblinding.BlindMessage) (genericblinding.BlindSignature, error) {
+	return s.server.SignContext(ctx, bps, bm)
+}
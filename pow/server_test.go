@@ -0,0 +1,124 @@
+package pow
+
+import (
+	"context"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/jjm"
+)
+
+// testClock is a settable time source
+type testClock struct {
+	now time.Time
+}
+
+func (tc *testClock) Now() time.Time {
+	return tc.now
+}
+
+// newTestServer returns a Server for a new jjm signer and a clock for it
+func newTestServer(t *testing.T) (*Server, *testClock) {
+	c := eccutil.SetCurve(elliptic.P256, rand.Reader, eccutil.Sha1Hash)
+	privkey, pubkey, err := c.GenerateKey()
+	if err != nil {
+		t.Fatalf("Error creating keys: %s", err)
+	}
+	s := NewServer(jjm.NewGenericBlindingServer(privkey, pubkey, c), []byte("pow key"))
+	s.MinBits = 4
+	clock := &testClock{now: time.Unix(1000000, 0)}
+	s.Clock = clock.Now
+	return s, clock
+}
+
+// challenge returns the challenge of the failed call err
+func challenge(t *testing.T, err error, expected error) Challenge {
+	t.Helper()
+	var ce *ChallengeError
+	if !errors.As(err, &ce) || !errors.Is(err, expected) {
+		t.Fatalf("Expected challenge with %v, got %v", expected, err)
+	}
+	return ce.Challenge
+}
+
+func Test_Server(t *testing.T) {
+	s, clock := newTestServer(t)
+	_, _, err := s.GetParams()
+	c := challenge(t, err, ErrSolutionRequired)
+	if c.Bits != 4 {
+		t.Errorf("Wrong difficulty: %d", c.Bits)
+	}
+	solution, err := Solve(context.Background(), c)
+	if err != nil {
+		t.Fatalf("Solve failed: %s", err)
+	}
+	ctx := WithSolution(context.Background(), solution)
+	if _, _, err := s.GetParamsContext(ctx); err != nil {
+		t.Fatalf("GetParams with solution failed: %s", err)
+	}
+	_, _, err = s.GetParamsContext(ctx)
+	challenge(t, err, ErrSolutionUsed)
+
+	foreign, err := newChallenge([]byte("other key"), 4, clock.now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("newChallenge failed: %s", err)
+	}
+	solution, _ = Solve(context.Background(), foreign)
+	_, _, err = s.GetParamsContext(WithSolution(context.Background(), solution))
+	challenge(t, err, ErrBadChallenge)
+
+	c, _ = s.Challenge()
+	solution, _ = Solve(context.Background(), c)
+	clock.now = clock.now.Add(DefaultTTL)
+	_, _, err = s.GetParamsContext(WithSolution(context.Background(), solution))
+	challenge(t, err, ErrChallengeExpired)
+
+	c, _ = s.Challenge()
+	solution = Solution{Challenge: c}
+	for solution.Valid() {
+		solution.Counter++
+	}
+	_, _, err = s.GetParamsContext(WithSolution(context.Background(), solution))
+	challenge(t, err, ErrBadSolution)
+}
+
+func Test_Difficulty(t *testing.T) {
+	s, clock := newTestServer(t)
+	s.TargetRate = 1
+	s.MaxBits = 7
+	if d := s.Difficulty(); d != 4 {
+		t.Fatalf("Wrong initial difficulty: %d", d)
+	}
+	// 30 calls at once average 3 calls per second over the rate window
+	for i := 0; i < 30; i++ {
+		c, err := s.Challenge()
+		if err != nil {
+			t.Fatalf("Challenge failed: %s", err)
+		}
+		solution, _ := Solve(context.Background(), c)
+		if _, _, err := s.GetParamsContext(WithSolution(context.Background(), solution)); err != nil {
+			t.Fatalf("GetParams failed: %s", err)
+		}
+	}
+	if d := s.Difficulty(); d != 6 {
+		t.Errorf("Difficulty must rise by two bits: %d", d)
+	}
+	s.TargetRate = 0.1
+	if d := s.Difficulty(); d != 7 {
+		t.Errorf("Difficulty must be limited by MaxBits: %d", d)
+	}
+	s.MaxBits = 0
+	s.TargetRate = 1e-9
+	if d := s.Difficulty(); d != DefaultMaxBits {
+		t.Errorf("Difficulty must be limited by DefaultMaxBits: %d", d)
+	}
+	s.TargetRate = 1
+	clock.now = clock.now.Add(time.Minute)
+	if d := s.Difficulty(); d != 4 {
+		t.Errorf("Difficulty must fall without load: %d", d)
+	}
+}
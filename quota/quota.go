@@ -0,0 +1,172 @@
+// Package quota limits how often a caller may use a genericblinding.BlindingServer. Server wraps a
+// BlindingServer and charges every GetParams and Sign against token bucket and fixed window limits, kept per
+// caller identity in a Store.
+//
+// The identity is supplied by the transport through the context of the call, see WithIdentity and
+// HTTPIdentity. Calls without identity share the empty identity. Limits are selected per scheme and signer key
+// by a Policy.
+//
+// Sign is charged after a StoredServer has taken the parameters from its ParamStore, so a rejected Sign uses
+// up the parameters. Limit GetParams to keep callers from requesting parameters they cannot use.
+package quota
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+)
+
+// Op is a set of operations of a BlindingServer
+type Op int
+
+// Operations
+const (
+	OpGetParams Op = 1 << iota
+	OpSign
+	OpAll = OpGetParams | OpSign
+)
+
+// Kind is the algorithm of a Limit
+type Kind int
+
+// Kinds of limits
+const (
+	// TokenBucket allows bursts of Count calls and refills Count calls per Period continuously
+	TokenBucket Kind = iota + 1
+	// FixedWindow allows Count calls in each Period, windows start at multiples of Period since the Unix epoch
+	FixedWindow
+)
+
+// Limit is one quota. All operations in Ops are charged against the same count
+type Limit struct {
+	Ops    Op
+	Kind   Kind
+	Count  int
+	Period time.Duration
+}
+
+var (
+	// ErrQuotaExceeded is returned if a call exceeds a quota. The error is an *ExceededError
+	ErrQuotaExceeded = errors.New("quota: Quota exceeded")
+	// ErrBadLimit is returned for limits without operations, kind, count or period
+	ErrBadLimit = errors.New("quota: Invalid limit")
+)
+
+// ExceededError describes the limit a call exceeded
+type ExceededError struct {
+	Limit      Limit
+	RetryAfter time.Duration // Time until the call would be allowed
+}
+
+// Error returns the message of ErrQuotaExceeded and the time to wait
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrQuotaExceeded, e.RetryAfter)
+}
+
+// Is reports whether target is ErrQuotaExceeded
+func (e *ExceededError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// check verifies that l is usable
+func (l Limit) check() error {
+	if l.Ops&OpAll == 0 || (l.Kind != TokenBucket && l.Kind != FixedWindow) || l.Count <= 0 || l.Period <= 0 {
+		return ErrBadLimit
+	}
+	return nil
+}
+
+// take charges one call at now against state st. It returns the new state, or an *ExceededError
+func (l Limit) take(st State, now time.Time) (State, error) {
+	if l.Kind == FixedWindow {
+		// time.Truncate would align windows to the zero time, not to the Unix epoch
+		start := time.Unix(0, now.UnixNano()-now.UnixNano()%int64(l.Period))
+		if !st.Time.Equal(start) {
+			st = State{Time: start, Expires: start.Add(l.Period)}
+		}
+		if st.Value >= float64(l.Count) {
+			return st, &ExceededError{Limit: l, RetryAfter: st.Expires.Sub(now)}
+		}
+		st.Value++
+		return st, nil
+	}
+	tokens := float64(l.Count)
+	if !st.Time.IsZero() {
+		tokens = st.Value + now.Sub(st.Time).Seconds()*l.rate()
+		if tokens > float64(l.Count) {
+			tokens = float64(l.Count)
+		}
+	}
+	if tokens < 1 {
+		wait := time.Duration((1 - tokens) / l.rate() * float64(time.Second))
+		return st, &ExceededError{Limit: l, RetryAfter: wait}
+	}
+	full := time.Duration((float64(l.Count) - tokens + 1) / l.rate() * float64(time.Second))
+	return State{Value: tokens - 1, Time: now, Expires: now.Add(full)}, nil
+}
+
+// refund returns a call charged by take. Expires is kept, a token bucket is full earlier than that
+func (l Limit) refund(st State) State {
+	if l.Kind == FixedWindow {
+		if st.Value > 0 {
+			st.Value--
+		}
+		return st
+	}
+	st.Value++
+	if st.Value > float64(l.Count) {
+		st.Value = float64(l.Count)
+	}
+	return st
+}
+
+// rate returns the refill rate of a token bucket in calls per second
+func (l Limit) rate() float64 {
+	return float64(l.Count) / l.Period.Seconds()
+}
+
+// Policy selects the limits of a signer. Limits of the key take precedence over limits of its scheme, which
+// take precedence over Default
+type Policy struct {
+	Default []Limit
+	Schemes map[string][]Limit // By scheme name
+	Keys    map[string][]Limit // By hex encoded eccutil.KeyID of the public key
+}
+
+// Limits returns the limits of the signer pubKey of scheme
+func (p Policy) Limits(scheme string, pubKey *eccutil.Point) []Limit {
+	if limits, ok := p.Keys[hex.EncodeToString(eccutil.KeyID(pubKey))]; ok {
+		return limits
+	}
+	if limits, ok := p.Schemes[scheme]; ok {
+		return limits
+	}
+	return p.Default
+}
+
+// identityKey is the context key of the caller identity
+type identityKey struct{}
+
+// WithIdentity returns a context carrying the identity of the caller
+func WithIdentity(ctx context.Context, identity string) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFrom returns the identity of the caller carried by ctx, or the empty identity
+func IdentityFrom(ctx context.Context) string {
+	identity, _ := ctx.Value(identityKey{}).(string)
+	return identity
+}
+
+// HTTPIdentity returns a handler that calls next with the identity returned by identify for the request, for
+// example the authenticated user or the remote address
+func HTTPIdentity(identify func(*http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), identify(r))))
+	})
+}
@@ -0,0 +1,154 @@
+package quota
+
+import (
+	"context"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+	"github.com/ronperry/cryptoedge/jjm"
+)
+
+// testClock is a settable time source
+type testClock struct {
+	now time.Time
+}
+
+func (tc *testClock) Now() time.Time {
+	return tc.now
+}
+
+// newTestServer returns a Server for a new jjm signer with limits and a clock for it
+func newTestServer(t *testing.T, policy Policy, store Store) (*Server, *testClock) {
+	c := eccutil.SetCurve(elliptic.P256, rand.Reader, eccutil.Sha1Hash)
+	privkey, pubkey, err := c.GenerateKey()
+	if err != nil {
+		t.Fatalf("Error creating keys: %s", err)
+	}
+	s, err := NewServer(jjm.NewGenericBlindingServer(privkey, pubkey, c), jjm.SchemeName, pubkey, policy, store)
+	if err != nil {
+		t.Fatalf("NewServer failed: %s", err)
+	}
+	clock := &testClock{now: time.Unix(1000000, 0)}
+	s.Clock = clock.Now
+	return s, clock
+}
+
+// expectCalls checks that identity can make exactly n GetParams calls
+func expectCalls(t *testing.T, s *Server, identity string, n int) {
+	t.Helper()
+	ctx := WithIdentity(context.Background(), identity)
+	for i := 0; i < n; i++ {
+		if _, _, err := s.GetParamsContext(ctx); err != nil {
+			t.Fatalf("Call %d of %s failed: %s", i, identity, err)
+		}
+	}
+	_, _, err := s.GetParamsContext(ctx)
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("Call %d of %s must exceed quota: %v", n, identity, err)
+	}
+}
+
+func Test_TokenBucket(t *testing.T) {
+	s, clock := newTestServer(t, Policy{Default: []Limit{{Ops: OpGetParams, Kind: TokenBucket, Count: 3, Period: 3 * time.Second}}}, NewMemoryStore())
+	expectCalls(t, s, "alice", 3)
+	expectCalls(t, s, "bob", 3)
+	_, _, err := s.GetParamsContext(WithIdentity(context.Background(), "alice"))
+	var e *ExceededError
+	if !errors.As(err, &e) || e.RetryAfter != time.Second {
+		t.Errorf("Wrong RetryAfter: %v", err)
+	}
+	clock.now = clock.now.Add(2 * time.Second)
+	expectCalls(t, s, "alice", 2)
+	clock.now = clock.now.Add(time.Hour)
+	expectCalls(t, s, "alice", 3)
+}
+
+func Test_FixedWindow(t *testing.T) {
+	s, clock := newTestServer(t, Policy{Default: []Limit{{Ops: OpAll, Kind: FixedWindow, Count: 2, Period: time.Minute}}}, NewMemoryStore())
+	clock.now = clock.now.Truncate(time.Minute).Add(50 * time.Second)
+	expectCalls(t, s, "", 2)
+	_, err := s.Sign(nil, nil)
+	var e *ExceededError
+	if !errors.As(err, &e) || e.RetryAfter != 10*time.Second {
+		t.Errorf("Sign must be charged against the same window: %v", err)
+	}
+	clock.now = clock.now.Add(10 * time.Second)
+	expectCalls(t, s, "", 2)
+}
+
+func Test_Expires(t *testing.T) {
+	window := Limit{Ops: OpAll, Kind: FixedWindow, Count: 2, Period: 7 * time.Hour}
+	now := time.Unix(1000*7*3600+5, 0)
+	st, err := window.take(State{}, now)
+	if err != nil {
+		t.Fatalf("take failed: %s", err)
+	}
+	if start := time.Unix(1000*7*3600, 0); !st.Time.Equal(start) || !st.Expires.Equal(start.Add(window.Period)) {
+		t.Errorf("Window must start at a multiple of Period since the Unix epoch: %v", st)
+	}
+	bucket := Limit{Ops: OpAll, Kind: TokenBucket, Count: 3, Period: 3 * time.Second}
+	st, _ = bucket.take(State{}, now)
+	st, _ = bucket.take(st, now)
+	if !st.Expires.Equal(now.Add(2 * time.Second)) {
+		t.Errorf("Bucket must expire when it is full: %v", st)
+	}
+}
+
+func Test_Refund(t *testing.T) {
+	s, clock := newTestServer(t, Policy{Default: []Limit{
+		{Ops: OpGetParams, Kind: FixedWindow, Count: 3, Period: time.Hour},
+		{Ops: OpGetParams, Kind: TokenBucket, Count: 2, Period: 2 * time.Second},
+	}}, NewMemoryStore())
+	clock.now = clock.now.Truncate(time.Hour)
+	expectCalls(t, s, "alice", 2)
+	clock.now = clock.now.Add(2 * time.Second)
+	expectCalls(t, s, "alice", 1) // The call rejected by the bucket must not count against the window
+}
+
+func Test_Policy(t *testing.T) {
+	def := []Limit{{Ops: OpAll, Kind: FixedWindow, Count: 1, Period: time.Second}}
+	scheme := []Limit{{Ops: OpAll, Kind: FixedWindow, Count: 2, Period: time.Second}}
+	key := []Limit{{Ops: OpAll, Kind: FixedWindow, Count: 3, Period: time.Second}}
+	pubKey := eccutil.NewPoint(eccutil.TestOne, eccutil.TestOne)
+	p := Policy{Default: def, Schemes: map[string][]Limit{jjm.SchemeName: scheme}}
+	if l := p.Limits("OTHER", pubKey); l[0].Count != 1 {
+		t.Error("Default limits not used")
+	}
+	if l := p.Limits(jjm.SchemeName, pubKey); l[0].Count != 2 {
+		t.Error("Scheme limits not used")
+	}
+	p.Keys = map[string][]Limit{hex.EncodeToString(eccutil.KeyID(pubKey)): key}
+	if l := p.Limits(jjm.SchemeName, pubKey); l[0].Count != 3 {
+		t.Error("Key limits not used")
+	}
+	_, err := NewServer(nil, jjm.SchemeName, pubKey, Policy{Default: []Limit{{Kind: TokenBucket, Count: 1, Period: time.Second}}}, NewMemoryStore())
+	if err != ErrBadLimit {
+		t.Errorf("Limit without operations must be rejected: %v", err)
+	}
+}
+
+func Test_Passthrough(t *testing.T) {
+	c := eccutil.SetCurve(elliptic.P256, rand.Reader, eccutil.Sha1Hash)
+	s, _ := newTestServer(t, Policy{}, NewMemoryStore())
+	bpc, bps, err := s.GetParams()
+	if err != nil {
+		t.Fatalf("GetParams failed: %s", err)
+	}
+	_, _, pubKey := bpc.SchemeData()
+	client := jjm.NewGenericBlindingClient(pubKey, c)
+	_, bm, err := client.Blind(bpc, jjm.NewClearMessage([]byte("Message")))
+	if err != nil {
+		t.Fatalf("Blind failed: %s", err)
+	}
+	_, err = s.Sign(bps, bm)
+	if err != nil {
+		t.Errorf("Sign without limits failed: %s", err)
+	}
+	var _ genericblinding.BlindingServerContext = s
+}
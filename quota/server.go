@@ -0,0 +1,97 @@
+package quota
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+)
+
+// Server is a BlindingServer that enforces the limits of its signer before passing calls on
+type Server struct {
+	server genericblinding.BlindingServerContext
+	limits []Limit
+	prefix string // Prefix of the store keys, identifies the signer
+	store  Store
+	Clock  func() time.Time // Time source, time.Now if nil
+}
+
+// NewServer returns a Server that limits the calls to server, the signer pubKey of scheme, according to
+// policy. The states of the limits are kept in store, which can be shared between servers
+func NewServer(server genericblinding.BlindingServer, scheme string, pubKey *eccutil.Point, policy Policy, store Store) (*Server, error) {
+	limits := policy.Limits(scheme, pubKey)
+	for _, l := range limits {
+		if err := l.check(); err != nil {
+			return nil, err
+		}
+	}
+	s := new(Server)
+	s.server = genericblinding.ServerWithContext(server)
+	s.limits = limits
+	s.prefix = scheme + "/" + hex.EncodeToString(eccutil.KeyID(pubKey)) + "/"
+	s.store = store
+	return s, nil
+}
+
+// charge takes one call of op by the caller of ctx from all matching limits. If a limit is exceeded, the
+// limits charged before are refunded
+func (s *Server) charge(ctx context.Context, op Op) error {
+	now := time.Now()
+	if s.Clock != nil {
+		now = s.Clock()
+	}
+	identity := IdentityFrom(ctx)
+	var charged []int
+	for i, l := range s.limits {
+		if l.Ops&op == 0 {
+			continue
+		}
+		err := s.store.Update(s.key(i, identity), func(st State) (State, error) {
+			return l.take(st, now)
+		})
+		if err != nil {
+			for _, j := range charged {
+				s.store.Update(s.key(j, identity), func(st State) (State, error) {
+					return s.limits[j].refund(st), nil
+				})
+			}
+			return err
+		}
+		charged = append(charged, i)
+	}
+	return nil
+}
+
+// key returns the store key of limit i for identity
+func (s *Server) key(i int, identity string) string {
+	return s.prefix + fmt.Sprint(i) + "/" + identity
+}
+
+// GetParams generates one-time parameters if the caller has quota left
+func (s *Server) GetParams() (genericblinding.BlindingParamClient, genericblinding.BlindingParamServer, error) {
+	return s.GetParamsContext(context.Background())
+}
+
+// GetParamsContext is GetParams with a context, which carries the identity of the caller
+func (s *Server) GetParamsContext(ctx context.Context) (genericblinding.BlindingParamClient, genericblinding.BlindingParamServer, error) {
+	if err := s.charge(ctx, OpGetParams); err != nil {
+		return nil, nil, err
+	}
+	return s.server.GetParamsContext(ctx)
+}
+
+// Sign signs bm if the caller has quota left
+func (s *Server) Sign(bps genericblinding.BlindingParamServer, bm genericblinding.BlindMessage) (genericblinding.BlindSignature, error) {
+	return s.SignContext(context.Background(), bps, bm)
+}
+
+// SignContext is Sign with a context, which carries the identity of the caller
+func (s *Server) SignContext(ctx context.Context, bps genericblinding.BlindingParamServer, bm genericblinding.BlindMessage) (genericblinding.BlindSignature, error) {
+	if err := s.charge(ctx, OpSign); err != nil {
+		return nil, err
+	}
+	return s.server.SignContext(ctx, bps, bm)
+}
@@ -0,0 +1,208 @@
+package quota
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// State is the state of one limit for one caller. Token buckets keep the number of tokens and the time of the
+// last call, fixed windows the number of calls and the start of the window. The zero State is an unused limit
+type State struct {
+	Value   float64
+	Time    time.Time
+	Expires time.Time // From then on the State equals the zero State: the bucket is full or the window over
+}
+
+// purgeInterval is the time between two removals of expired states from a MemoryStore
+const purgeInterval = time.Minute
+
+// Store keeps the states of limits
+type Store interface {
+	// Update atomically replaces the state of key with the result of f. f receives the zero State for unknown
+	// keys. If f returns an error, the state is left unchanged and Update returns the error
+	Update(key string, f func(State) (State, error)) error
+}
+
+// MemoryStore is a Store that keeps states in memory. Expired states are removed during updates, at most once
+// per minute
+type MemoryStore struct {
+	lock      sync.Mutex
+	states    map[string]State
+	nextPurge time.Time
+	Clock     func() time.Time // Time source, time.Now if nil. Must match the Clock of the Servers using the store
+}
+
+// NewMemoryStore returns an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	ms := new(MemoryStore)
+	ms.states = make(map[string]State)
+	return ms
+}
+
+// Update replaces the state of key with the result of f
+func (ms *MemoryStore) Update(key string, f func(State) (State, error)) error {
+	return ms.update(key, f, nil)
+}
+
+// update replaces the state of key with the result of f after commit, if not nil, accepted it
+func (ms *MemoryStore) update(key string, f func(State) (State, error), commit func(string, State) error) error {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	if now := ms.now(); now.After(ms.nextPurge) {
+		ms.purge(now)
+		ms.nextPurge = now.Add(purgeInterval)
+	}
+	st, err := f(ms.states[key])
+	if err != nil {
+		return err
+	}
+	if commit != nil {
+		err = commit(key, st)
+		if err != nil {
+			return err
+		}
+	}
+	ms.states[key] = st
+	return nil
+}
+
+// purge removes the states that expired at now
+func (ms *MemoryStore) purge(now time.Time) {
+	for key, st := range ms.states {
+		if !st.Expires.IsZero() && !now.Before(st.Expires) {
+			delete(ms.states, key)
+		}
+	}
+}
+
+func (ms *MemoryStore) now() time.Time {
+	if ms.Clock != nil {
+		return ms.Clock()
+	}
+	return time.Now()
+}
+
+// fileRecord is a line of the file of a FileStore
+type fileRecord struct {
+	Key     string    `json:"k"`
+	Value   float64   `json:"v"`
+	Time    time.Time `json:"t"`
+	Expires time.Time `json:"e"`
+}
+
+// FileStore is a Store that appends every update to a file, one JSON record per line, so that quotas survive
+// restarts. States are kept in memory. Updates are not synced to disk, a crash can lose the latest ones.
+// The file is compacted when it is opened and by Compact, which also drops expired states. It must not be shared between processes
+type FileStore struct {
+	MemoryStore
+	name string
+	file *os.File
+}
+
+// OpenFileStore opens or creates the FileStore in file name
+func OpenFileStore(name string) (*FileStore, error) {
+	fs := &FileStore{name: name}
+	fs.states = make(map[string]State)
+	f, err := os.OpenFile(name, os.O_RDONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	err = fs.load(f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	err = fs.Compact()
+	if err != nil {
+		return nil, err
+	}
+	return fs, nil
+}
+
+// load reads all records from f. The last record of a key wins. A partially written last line, left by a
+// crash, is ignored
+func (fs *FileStore) load(f *os.File) error {
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var rec fileRecord
+		err = json.Unmarshal(line, &rec)
+		if err != nil {
+			return err
+		}
+		fs.states[rec.Key] = State{Value: rec.Value, Time: rec.Time, Expires: rec.Expires}
+	}
+}
+
+// Update replaces the state of key with the result of f and appends it to the file
+func (fs *FileStore) Update(key string, f func(State) (State, error)) error {
+	return fs.update(key, f, fs.write)
+}
+
+// write appends the state of key to the file
+func (fs *FileStore) write(key string, st State) error {
+	b, err := json.Marshal(fileRecord{Key: key, Value: st.Value, Time: st.Time, Expires: st.Expires})
+	if err != nil {
+		return err
+	}
+	_, err = fs.file.Write(append(b, '\n'))
+	return err
+}
+
+// Compact replaces the file by one that only holds the current states that have not expired
+func (fs *FileStore) Compact() error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	fs.purge(fs.now())
+	tmp, err := os.OpenFile(fs.name+".tmp", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(tmp)
+	for key, st := range fs.states {
+		b, err := json.Marshal(fileRecord{Key: key, Value: st.Value, Time: st.Time, Expires: st.Expires})
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		w.Write(append(b, '\n'))
+	}
+	err = w.Flush()
+	if err == nil {
+		err = tmp.Sync()
+	}
+	tmp.Close()
+	if err == nil {
+		err = os.Rename(fs.name+".tmp", fs.name)
+	}
+	if err != nil {
+		os.Remove(fs.name + ".tmp")
+		return err
+	}
+	f, err := os.OpenFile(fs.name, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if fs.file != nil {
+		fs.file.Close()
+	}
+	fs.file = f
+	return nil
+}
+
+// Close closes the file
+func (fs *FileStore) Close() error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	return fs.file.Close()
+}
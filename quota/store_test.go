@@ -0,0 +1,99 @@
+package quota
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_FileStore(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "quota")
+	fs, err := OpenFileStore(name)
+	if err != nil {
+		t.Fatalf("OpenFileStore failed: %s", err)
+	}
+	now := time.Unix(1000, 0).UTC()
+	for i := 0; i < 3; i++ {
+		err = fs.Update("a", func(st State) (State, error) {
+			return State{Value: st.Value + 1, Time: now}, nil
+		})
+		if err != nil {
+			t.Fatalf("Update failed: %s", err)
+		}
+	}
+	failed := errors.New("failed")
+	err = fs.Update("a", func(st State) (State, error) {
+		return State{Value: 100}, failed
+	})
+	if err != failed {
+		t.Errorf("Update must return the error of f: %v", err)
+	}
+	fs.Close()
+
+	// Simulate a crash during a write
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatalf("Open failed: %s", err)
+	}
+	f.WriteString(`{"k":"a","v":9`)
+	f.Close()
+
+	fs, err = OpenFileStore(name)
+	if err != nil {
+		t.Fatalf("Reopening failed: %s", err)
+	}
+	defer fs.Close()
+	err = fs.Update("a", func(st State) (State, error) {
+		if st.Value != 3 || !st.Time.Equal(now) {
+			t.Errorf("State not restored: %v", st)
+		}
+		return st, nil
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %s", err)
+	}
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %s", err)
+	}
+	if len(b) == 0 || b[len(b)-1] != '\n' {
+		t.Errorf("File not compacted: %q", b)
+	}
+}
+
+func Test_Purge(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "quota")
+	fs, err := OpenFileStore(name)
+	if err != nil {
+		t.Fatalf("OpenFileStore failed: %s", err)
+	}
+	defer fs.Close()
+	now := time.Unix(1000, 0)
+	fs.Clock = func() time.Time { return now }
+	for key, expires := range map[string]time.Time{"expired": now.Add(time.Second), "never": {}, "later": now.Add(time.Hour)} {
+		fs.Update(key, func(State) (State, error) {
+			return State{Value: 1, Time: now, Expires: expires}, nil
+		})
+	}
+	now = now.Add(time.Second)
+	if err := fs.Compact(); err != nil {
+		t.Fatalf("Compact failed: %s", err)
+	}
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %s", err)
+	}
+	if n := strings.Count(string(b), "\n"); n != 2 || strings.Contains(string(b), "expired") {
+		t.Errorf("Expired state not compacted: %q", b)
+	}
+	now = now.Add(time.Hour)
+	fs.Update("new", func(State) (State, error) {
+		return State{Value: 1, Time: now}, nil
+	})
+	if len(fs.states) != 2 || fs.states["never"].Value != 1 {
+		t.Errorf("Expired states not purged: %v", fs.states)
+	}
+}
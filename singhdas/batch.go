@@ -0,0 +1,122 @@
+package singhdas
+
+// Batch issuance and verification over the generic blinding interface
+
+import (
+	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
+	"math/big"
+)
+
+// GetParamsBatch returns n per-signature blinding parameters
+func (server GenericSigner) GetParamsBatch(n int) ([]genericblinding.BlindingParamClient, []genericblinding.BlindingParamServer, error) {
+	return genericblinding.GetParamsBatch(server.GetParams, n)
+}
+
+// SignBatch signs bmi[i] using bpsi[i]. All parameters and messages are checked, including for reuse within the
+// batch, before any is signed. Parameters that Sign has seen before fail the batch and use up the others
+func (server GenericSigner) SignBatch(bpsi []genericblinding.BlindingParamServer, bmi []genericblinding.BlindMessage) ([]genericblinding.BlindSignature, error) {
+	if len(bpsi) != len(bmi) {
+		return nil, genericblinding.ErrBatchLength
+	}
+	bps := make([]BlindingParamServer, len(bpsi))
+	bm := make([]BlindMessage, len(bmi))
+	errs := make(genericblinding.BatchError, len(bmi))
+	batch := make(map[string]bool)
+	for i := range bpsi {
+		bps[i], bm[i], errs[i] = server.checkSign(bpsi[i], bmi[i])
+		if errs[i] != nil {
+			continue
+		}
+		id := string(bps[i].UniqueID())
+		if batch[id] {
+			errs[i] = eccutil.ErrParamReuse
+		}
+		batch[id] = true
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	for i := range bps {
+		if !server.uniqueTest(uniqueToken(bps[i])) {
+			errs[i] = eccutil.ErrParamReuse
+		}
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	sigs := make([]genericblinding.BlindSignature, len(bm))
+	for i := range bm {
+		sigs[i], errs[i] = server.sign(bps[i], bm[i])
+	}
+	return sigs, errs.Err()
+}
+
+// VerifyBatch verifies that sigi[i] is a signature of cmi[i]. All signatures are checked at once with a random
+// linear combination of their verification equations. Only if that fails are they verified one by one to find
+// the failed items
+func (client GenericSignerClient) VerifyBatch(sigi []genericblinding.ClearSignature, cmi []genericblinding.ClearMessage) (bool, error) {
+	if len(sigi) != len(cmi) {
+		return false, genericblinding.ErrBatchLength
+	}
+	errs := make(genericblinding.BatchError, len(sigi))
+	var items []int
+	var sigs []ClearSignature
+	for i := range sigi {
+		sig, cm, err := client.checkVerify(sigi[i], cmi[i])
+		if err == nil && (!client.curve.IsScalar(sig.S) || !client.curve.IsScalar(sig.R2) || sig.Hm == nil || !client.curve.IsOnCurve(&sig.R)) {
+			err = eccutil.ErrSigWrong
+		}
+		if err == nil && client.curve.GenHash(cm.UniqueID()).Cmp(sig.Hm) != 0 {
+			err = eccutil.ErrHashDif
+		}
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		items = append(items, i)
+		sigs = append(sigs, sig)
+	}
+	if len(items) > 0 {
+		ok, err := client.verifyCombined(sigs)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			for _, i := range items {
+				errs[i] = genericblinding.VerifyItem(client.Verify, sigi[i], cmi[i])
+			}
+		}
+	}
+	if err := errs.Err(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// verifyCombined tests the sum of the verification equations S x G = r2 x B + Hm x R, each multiplied by a
+// random coefficient a. The hash of each message must have been compared to Hm before:
+//
+//	(sum a*S) x G =? (sum a*r2) x B + sum (a*Hm) x R
+func (client GenericSignerClient) verifyCombined(sigs []ClearSignature) (bool, error) {
+	a, err := client.curve.RandomCoefficients(len(sigs))
+	if err != nil {
+		return false, err
+	}
+	s := new(big.Int)
+	r2 := new(big.Int)
+	points := []*eccutil.Point{client.pubkey}
+	scalars := []*big.Int{r2}
+	for i := range sigs {
+		s.Add(s, new(big.Int).Mul(a[i], new(big.Int).Abs(sigs[i].S)))
+		r2.Add(r2, new(big.Int).Mul(a[i], new(big.Int).Abs(sigs[i].R2)))
+		points = append(points, &sigs[i].R)
+		scalars = append(scalars, new(big.Int).Mul(a[i], sigs[i].Hm))
+	}
+	SG := client.curve.ScalarBaseMult(client.curve.Mod(s).Bytes())
+	R2BHmR, err := client.curve.LinearCombination(points, scalars)
+	if err != nil {
+		return false, err
+	}
+	return eccutil.PointEqual(SG, R2BHmR), nil
+}
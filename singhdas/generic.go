@@ -1,14 +1,17 @@
 package singhdas
 
 import (
+	"context"
 	"github.com/ronperry/cryptoedge/eccutil"
 	"github.com/ronperry/cryptoedge/genericblinding"
 	"math/big"
+	"time"
 )
 
 // GenericSigner is a generic interface signer instance
 type GenericSigner struct {
 	Signer
+	uniqueTest func([32]byte) bool // Rejects the UniqueID of a BlindingParamServer given to Sign before
 }
 
 // NewGenericBlindingServer returns a new signer
@@ -18,6 +21,7 @@ func NewGenericBlindingServer(privkey []byte, pubkey *eccutil.Point, curve *eccu
 	s.privkey = s.privkey.SetBytes(privkey)
 	s.pubkey = pubkey
 	s.curve = curve
+	s.uniqueTest = eccutil.NewUniqueTest(eccutil.DefaultUniqueTokens)
 	return s
 }
 
@@ -36,7 +40,14 @@ func NewGenericBlindingClient(pubkey *eccutil.Point, curve *eccutil.Curve) *Gene
 
 // Blind a ClearMessage with server-supplied BlindingParamClient
 func (client GenericSignerClient) Blind(bpci genericblinding.BlindingParamClient, cmi genericblinding.ClearMessage) (genericblinding.BlindingFactors, genericblinding.BlindMessage, error) {
-	_, err := genericblinding.MatchMessage(bpci, SchemeName, genericblinding.TypeBlindingParamClient, client.pubkey)
+	return client.BlindContext(context.Background(), bpci, cmi)
+}
+
+// BlindContext is Blind with a context
+func (client GenericSignerClient) BlindContext(ctx context.Context, bpci genericblinding.BlindingParamClient, cmi genericblinding.ClearMessage) (_ genericblinding.BlindingFactors, _ genericblinding.BlindMessage, err error) {
+	start := time.Now()
+	defer func() { genericblinding.Observe(client.Observer, SchemeName, genericblinding.OpBlind, start, err) }()
+	_, err = genericblinding.MatchMessage(bpci, SchemeName, genericblinding.TypeBlindingParamClient, client.pubkey)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -56,7 +67,8 @@ func (client GenericSignerClient) Blind(bpci genericblinding.BlindingParamClient
 	bc := new(SignerClient)
 	bc.pubkey = client.pubkey
 	bc.curve = client.curve
-	bm, bfac, err := bc.Blind(cm.UniqueID(), &bpc.Q)
+	bc.Observer = client.Observer
+	bm, bfac, err := bc.BlindContext(ctx, cm.UniqueID(), &bpc.Q)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -82,7 +94,17 @@ func (client GenericSignerClient) Blind(bpci genericblinding.BlindingParamClient
 
 // Unblind a BlindSignature of ClearMessage using BlindingFactors
 func (client GenericSignerClient) Unblind(bfaci genericblinding.BlindingFactors, cmi genericblinding.ClearMessage, bsigi genericblinding.BlindSignature) (genericblinding.ClearSignature, genericblinding.ClearMessage, error) {
-	_, err := genericblinding.MatchMessage(bfaci, SchemeName, genericblinding.TypeBlindingFactors, client.pubkey)
+	return client.UnblindContext(context.Background(), bfaci, cmi, bsigi)
+}
+
+// UnblindContext is Unblind with a context
+func (client GenericSignerClient) UnblindContext(ctx context.Context, bfaci genericblinding.BlindingFactors, cmi genericblinding.ClearMessage, bsigi genericblinding.BlindSignature) (_ genericblinding.ClearSignature, _ genericblinding.ClearMessage, err error) {
+	start := time.Now()
+	defer func() { genericblinding.Observe(client.Observer, SchemeName, genericblinding.OpUnblind, start, err) }()
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	_, err = genericblinding.MatchMessage(bfaci, SchemeName, genericblinding.TypeBlindingFactors, client.pubkey)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -108,6 +130,9 @@ func (client GenericSignerClient) Unblind(bfaci genericblinding.BlindingFactors,
 	if !ok {
 		return nil, nil, genericblinding.ErrBadType
 	}
+	if !eccutil.PointEqual(&bsig.SignerBlind, &bfac.SignerBlind) {
+		return nil, nil, eccutil.ErrBadBlindParam
+	}
 
 	bc := new(SignerClient)
 	bc.pubkey = client.pubkey
@@ -142,23 +167,20 @@ func (client GenericSignerClient) Unblind(bfaci genericblinding.BlindingFactors,
 
 // Verify that ClearSignature is a signature of ClearMessage
 func (client GenericSignerClient) Verify(sigi genericblinding.ClearSignature, cmi genericblinding.ClearMessage) (bool, error) {
-	_, err := genericblinding.MatchMessage(sigi, SchemeName, genericblinding.TypeClearSignature, client.pubkey)
-	if err != nil {
+	return client.VerifyContext(context.Background(), sigi, cmi)
+}
+
+// VerifyContext is Verify with a context
+func (client GenericSignerClient) VerifyContext(ctx context.Context, sigi genericblinding.ClearSignature, cmi genericblinding.ClearMessage) (ok bool, err error) {
+	start := time.Now()
+	defer func() { genericblinding.ObserveVerify(client.Observer, SchemeName, start, ok, err) }()
+	if err := ctx.Err(); err != nil {
 		return false, err
 	}
-	sig, ok := sigi.(ClearSignature)
-	if !ok {
-		return false, genericblinding.ErrBadType
-	}
-
-	_, err = genericblinding.MatchMessage(cmi, SchemeName, genericblinding.TypeClearMessage, client.pubkey)
+	sig, cm, err := client.checkVerify(sigi, cmi)
 	if err != nil {
 		return false, err
 	}
-	cm, ok := cmi.(ClearMessage)
-	if !ok {
-		return false, genericblinding.ErrBadType
-	}
 
 	bc := new(SignerClient)
 	bc.pubkey = client.pubkey
@@ -172,13 +194,43 @@ func (client GenericSignerClient) Verify(sigi genericblinding.ClearSignature, cm
 
 }
 
+// checkVerify converts the arguments of Verify and verifies that they belong to client
+func (client GenericSignerClient) checkVerify(sigi genericblinding.ClearSignature, cmi genericblinding.ClearMessage) (ClearSignature, ClearMessage, error) {
+	_, err := genericblinding.MatchMessage(sigi, SchemeName, genericblinding.TypeClearSignature, client.pubkey)
+	if err != nil {
+		return ClearSignature{}, ClearMessage{}, err
+	}
+	sig, ok := sigi.(ClearSignature)
+	if !ok {
+		return ClearSignature{}, ClearMessage{}, genericblinding.ErrBadType
+	}
+
+	_, err = genericblinding.MatchMessage(cmi, SchemeName, genericblinding.TypeClearMessage, client.pubkey)
+	if err != nil {
+		return ClearSignature{}, ClearMessage{}, err
+	}
+	cm, ok := cmi.(ClearMessage)
+	if !ok {
+		return ClearSignature{}, ClearMessage{}, genericblinding.ErrBadType
+	}
+	return sig, cm, nil
+}
+
 // GetParams generates one-time BlindingParam
 func (server GenericSigner) GetParams() (genericblinding.BlindingParamClient, genericblinding.BlindingParamServer, error) {
+	return server.GetParamsContext(context.Background())
+}
+
+// GetParamsContext is GetParams with a context
+func (server GenericSigner) GetParamsContext(ctx context.Context) (_ genericblinding.BlindingParamClient, _ genericblinding.BlindingParamServer, err error) {
+	start := time.Now()
+	defer func() { genericblinding.Observe(server.Observer, SchemeName, genericblinding.OpGetParams, start, err) }()
 	bs := new(Signer)
 	bs.curve = server.curve
 	bs.pubkey = server.pubkey
 	bs.privkey = server.privkey
-	signparams, err := bs.NewRequest()
+	bs.Observer = server.Observer
+	signparams, err := bs.NewRequestContext(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -194,26 +246,59 @@ func (server GenericSigner) GetParams() (genericblinding.BlindingParamClient, ge
 	return clientparams, serverparams, nil
 }
 
-// Sign a BlindMessage usign BlindingParam
+// Sign a BlindMessage usign BlindingParam. Each BlindingParamServer can only be used once, Sign rejects the last
+// eccutil.DefaultUniqueTokens it has seen. Keep them in a genericblinding.ParamStore to reject older ones
 func (server GenericSigner) Sign(bpsi genericblinding.BlindingParamServer, bmi genericblinding.BlindMessage) (genericblinding.BlindSignature, error) {
-	_, err := genericblinding.MatchMessage(bpsi, SchemeName, genericblinding.TypeBlindingParamServer, server.pubkey)
+	return server.SignContext(context.Background(), bpsi, bmi)
+}
+
+// SignContext is Sign with a context
+func (server GenericSigner) SignContext(ctx context.Context, bpsi genericblinding.BlindingParamServer, bmi genericblinding.BlindMessage) (_ genericblinding.BlindSignature, err error) {
+	start := time.Now()
+	defer func() { genericblinding.Observe(server.Observer, SchemeName, genericblinding.OpSign, start, err) }()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	bps, bm, err := server.checkSign(bpsi, bmi)
 	if err != nil {
 		return nil, err
 	}
+	if !server.uniqueTest(uniqueToken(bps)) {
+		return nil, eccutil.ErrParamReuse
+	}
+	return server.sign(bps, bm)
+}
+
+// uniqueToken returns the token of bps for the uniqueness test
+func uniqueToken(bps BlindingParamServer) (t [32]byte) {
+	copy(t[:], bps.UniqueID())
+	return t
+}
+
+// checkSign converts the arguments of Sign and verifies that they belong to server
+func (server GenericSigner) checkSign(bpsi genericblinding.BlindingParamServer, bmi genericblinding.BlindMessage) (BlindingParamServer, BlindMessage, error) {
+	_, err := genericblinding.MatchMessage(bpsi, SchemeName, genericblinding.TypeBlindingParamServer, server.pubkey)
+	if err != nil {
+		return BlindingParamServer{}, BlindMessage{}, err
+	}
 	bps, ok := bpsi.(BlindingParamServer)
 	if !ok {
-		return nil, genericblinding.ErrBadType
+		return BlindingParamServer{}, BlindMessage{}, genericblinding.ErrBadType
 	}
 
 	_, err = genericblinding.MatchMessage(bmi, SchemeName, genericblinding.TypeBlindMessage, server.pubkey)
 	if err != nil {
-		return nil, err
+		return BlindingParamServer{}, BlindMessage{}, err
 	}
 	bm, ok := bmi.(BlindMessage)
 	if !ok {
-		return nil, genericblinding.ErrBadType
+		return BlindingParamServer{}, BlindMessage{}, genericblinding.ErrBadType
 	}
+	return bps, bm, nil
+}
 
+// sign signs bm with bps after checkSign
+func (server GenericSigner) sign(bps BlindingParamServer, bm BlindMessage) (genericblinding.BlindSignature, error) {
 	bs := new(Signer)
 	bs.curve = server.curve
 	bs.pubkey = server.pubkey
@@ -5,6 +5,8 @@ import (
 	"crypto/rand"
 	"github.com/ronperry/cryptoedge/eccutil"
 	"github.com/ronperry/cryptoedge/genericblinding"
+	"github.com/ronperry/cryptoedge/genericblinding/conformance"
+	"math/big"
 	"testing"
 )
 
@@ -28,4 +30,60 @@ func Test_Interface(t *testing.T) {
 	_, _ = clientParams, serverParams
 }
 
-// // does not implement wrong type for method
+func Test_Conformance(t *testing.T) {
+	c := eccutil.SetCurve(elliptic.P256, rand.Reader, eccutil.Sha1Hash)
+	conformance.Run(t, conformance.Suite{
+		NewPair: func() (genericblinding.BlindingServer, genericblinding.BlindingClient, *eccutil.Point, error) {
+			privkey, pubkey, err := c.GenerateKey()
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			return NewGenericBlindingServer(privkey, pubkey, c), NewGenericBlindingClient(pubkey, c), pubkey, nil
+		},
+		NewClearMessage: func(msg []byte) genericblinding.ClearMessage {
+			return NewClearMessage(msg)
+		},
+		OneTimeParams: true,
+	})
+}
+
+func Test_VerifyMalleated(t *testing.T) {
+	c := eccutil.SetCurve(elliptic.P256, rand.Reader, eccutil.Sha1Hash)
+	privkey, pubkey, err := c.GenerateKey()
+	if err != nil {
+		t.Fatalf("Error creating keys: %s", err)
+	}
+	signer := NewGenericBlindingServer(privkey, pubkey, c)
+	client := NewGenericBlindingClient(pubkey, c)
+	clientParams, serverParams, err := signer.GetParams()
+	if err != nil {
+		t.Fatalf("Error GetParams: %s", err)
+	}
+	cm := NewClearMessage([]byte("Message to sign"))
+	blindingFactors, blindMessage, err := client.Blind(clientParams, cm)
+	if err != nil {
+		t.Fatalf("Error Blind: %s", err)
+	}
+	blindSignature, err := signer.Sign(serverParams, blindMessage)
+	if err != nil {
+		t.Fatalf("Error Sign: %s", err)
+	}
+	clearSignature, clearMessage, err := client.Unblind(blindingFactors, cm, blindSignature)
+	if err != nil {
+		t.Fatalf("Error Unblind: %s", err)
+	}
+	if ok, err := client.Verify(clearSignature, clearMessage); !ok || err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	// S+N and r2+N are not canonical
+	malleated := clearSignature.(ClearSignature)
+	malleated.S = new(big.Int).Add(malleated.S, c.Params.N)
+	if ok, _ := client.Verify(malleated, clearMessage); ok {
+		t.Error("Verify must fail for S+N")
+	}
+	malleated = clearSignature.(ClearSignature)
+	malleated.R2 = new(big.Int).Add(malleated.R2, c.Params.N)
+	if ok, _ := client.Verify(malleated, clearMessage); ok {
+		t.Error("Verify must fail for r2+N")
+	}
+}
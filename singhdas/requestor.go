@@ -3,14 +3,17 @@
 package singhdas
 
 import (
+	"context"
 	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
 	"math/big"
 )
 
 // SignerClient encapsulates a client to a signer
 type SignerClient struct {
-	pubkey *eccutil.Point
-	curve  *eccutil.Curve
+	pubkey   *eccutil.Point
+	curve    *eccutil.Curve
+	Observer genericblinding.Observer // Receives the retries of Blind, may be nil
 }
 
 // BlindingFactorsInt holds parameters required for unblinding
@@ -49,6 +52,11 @@ func NewSignerClient(pubkey *eccutil.Point, curve *eccutil.Curve) *SignerClient
 
 // Blind blinds a message msg for signerBlind and returns the blinded message and the blinding factors
 func (client SignerClient) Blind(message []byte, signerBlind *eccutil.Point) (blindMessage *BlindMessageInt, blindingFactors *BlindingFactorsInt, err error) {
+	return client.BlindContext(context.Background(), message, signerBlind)
+}
+
+// BlindContext is Blind with a context. The parameter search stops with the error of ctx when ctx is done
+func (client SignerClient) BlindContext(ctx context.Context, message []byte, signerBlind *eccutil.Point) (blindMessage *BlindMessageInt, blindingFactors *BlindingFactorsInt, err error) {
 	var loopcount int
 	var M, N, r2 *big.Int
 	var R *eccutil.Point
@@ -60,10 +68,16 @@ func (client SignerClient) Blind(message []byte, signerBlind *eccutil.Point) (bl
 		return nil, nil, eccutil.ErrBadBlindParam
 	}
 	for {
+		if loopcount > 0 {
+			genericblinding.ObserveRetry(client.Observer, SchemeName, genericblinding.OpBlind)
+		}
 		if loopcount > eccutil.MaxLoopCount {
 			return nil, nil, eccutil.ErrMaxLoop
 		}
 		loopcount++
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
 		M, err = client.curve.RandomElement()
 		if err != nil {
 			continue
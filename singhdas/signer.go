@@ -1,15 +1,18 @@
 package singhdas
 
 import (
+	"context"
 	"github.com/ronperry/cryptoedge/eccutil"
+	"github.com/ronperry/cryptoedge/genericblinding"
 	"math/big"
 )
 
 // Signer is a signer instance
 type Signer struct {
-	privkey *big.Int
-	pubkey  *eccutil.Point
-	curve   *eccutil.Curve
+	privkey  *big.Int
+	pubkey   *eccutil.Point
+	curve    *eccutil.Curve
+	Observer genericblinding.Observer // Receives the retries of NewRequest, may be nil
 }
 
 // SignParamsInt encapsulates a single signature temporary key
@@ -38,12 +41,23 @@ func NewSigner(privkey []byte, pubkey *eccutil.Point, curve *eccutil.Curve) *Sig
 
 // NewRequest issues a new request keypair
 func (signer Signer) NewRequest() (signparams *SignParamsInt, err error) {
+	return signer.NewRequestContext(context.Background())
+}
+
+// NewRequestContext is NewRequest with a context. The parameter search stops with the error of ctx when ctx is done
+func (signer Signer) NewRequestContext(ctx context.Context) (signparams *SignParamsInt, err error) {
 	var loopcount int
 	for {
+		if loopcount > 0 {
+			genericblinding.ObserveRetry(signer.Observer, SchemeName, genericblinding.OpGetParams)
+		}
 		if loopcount > eccutil.MaxLoopCount {
 			return nil, eccutil.ErrMaxLoop
 		}
 		loopcount++
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		Kt, Qt, err := signer.curve.GenerateKey()
 		if err != nil {
 			continue
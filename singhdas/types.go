@@ -2,7 +2,6 @@ package singhdas
 
 import (
 	"crypto/sha256"
-	"encoding/asn1"
 	"github.com/ronperry/cryptoedge/eccutil"
 	"github.com/ronperry/cryptoedge/genericblinding"
 	"math/big"
@@ -11,6 +10,9 @@ import (
 // SchemeName is the name of this blinding scheme
 const SchemeName = "SNG"
 
+// SchemeTag identifies this blinding scheme in CBOR encodings
+const SchemeTag = 3
+
 // BlindingParamClient is not needed in SNG
 type BlindingParamClient struct {
 	SchemeName string
@@ -35,13 +37,13 @@ func (blindingParamClient BlindingParamClient) SchemeData() (string, genericblin
 
 // Marshal a BlindingParamClient
 func (blindingParamClient BlindingParamClient) Marshal() ([]byte, error) {
-	return asn1.Marshal(blindingParamClient)
+	return genericblinding.MarshalEnvelope(blindingParamClient)
 }
 
 // Unmarshal []byte into BlindingParamClient
 func (blindingParamClient BlindingParamClient) Unmarshal(b []byte) (genericblinding.BlindingData, error) {
 	n := new(BlindingParamClient)
-	_, err := asn1.Unmarshal(b, n)
+	err := genericblinding.UnmarshalEnvelope(b, n)
 	if err != nil {
 		return nil, err
 	}
@@ -97,13 +99,13 @@ func (blindingParamServer BlindingParamServer) SchemeData() (string, genericblin
 
 // Marshal a BlindingParamServer
 func (blindingParamServer BlindingParamServer) Marshal() ([]byte, error) {
-	return asn1.Marshal(blindingParamServer)
+	return genericblinding.MarshalEnvelope(blindingParamServer)
 }
 
 // Unmarshal []byte into BlindingParamServer
 func (blindingParamServer BlindingParamServer) Unmarshal(b []byte) (genericblinding.BlindingData, error) {
 	n := new(BlindingParamServer)
-	_, err := asn1.Unmarshal(b, n)
+	err := genericblinding.UnmarshalEnvelope(b, n)
 	if err != nil {
 		return nil, err
 	}
@@ -157,13 +159,13 @@ func (clearMessage ClearMessage) SchemeData() (string, genericblinding.DataType,
 
 // Marshal a BlindingParamClient
 func (clearMessage ClearMessage) Marshal() ([]byte, error) {
-	return asn1.Marshal(clearMessage)
+	return genericblinding.MarshalEnvelope(clearMessage)
 }
 
 // Unmarshal []byte into BlindingParamClient
 func (clearMessage ClearMessage) Unmarshal(b []byte) (genericblinding.BlindingData, error) {
 	n := new(ClearMessage)
-	_, err := asn1.Unmarshal(b, n)
+	err := genericblinding.UnmarshalEnvelope(b, n)
 	if err != nil {
 		return nil, err
 	}
@@ -218,13 +220,13 @@ func (blindingFactors BlindingFactors) SchemeData() (string, genericblinding.Dat
 
 // Marshal a BlindingParamClient
 func (blindingFactors BlindingFactors) Marshal() ([]byte, error) {
-	return asn1.Marshal(blindingFactors)
+	return genericblinding.MarshalEnvelope(blindingFactors)
 }
 
 // Unmarshal []byte into BlindingParamClient
 func (blindingFactors BlindingFactors) Unmarshal(b []byte) (genericblinding.BlindingData, error) {
 	n := new(BlindingFactors)
-	_, err := asn1.Unmarshal(b, n)
+	err := genericblinding.UnmarshalEnvelope(b, n)
 	if err != nil {
 		return nil, err
 	}
@@ -285,13 +287,13 @@ func (blindMessage BlindMessage) SchemeData() (string, genericblinding.DataType,
 
 // Marshal a BlindingParamClient
 func (blindMessage BlindMessage) Marshal() ([]byte, error) {
-	return asn1.Marshal(blindMessage)
+	return genericblinding.MarshalEnvelope(blindMessage)
 }
 
 // Unmarshal []byte into BlindingParamClient
 func (blindMessage BlindMessage) Unmarshal(b []byte) (genericblinding.BlindingData, error) {
 	n := new(BlindMessage)
-	_, err := asn1.Unmarshal(b, n)
+	err := genericblinding.UnmarshalEnvelope(b, n)
 	if err != nil {
 		return nil, err
 	}
@@ -346,13 +348,13 @@ func (blindSignature BlindSignature) SchemeData() (string, genericblinding.DataT
 
 // Marshal a BlindingParamClient
 func (blindSignature BlindSignature) Marshal() ([]byte, error) {
-	return asn1.Marshal(blindSignature)
+	return genericblinding.MarshalEnvelope(blindSignature)
 }
 
 // Unmarshal []byte into BlindingParamClient
 func (blindSignature BlindSignature) Unmarshal(b []byte) (genericblinding.BlindingData, error) {
 	n := new(BlindSignature)
-	_, err := asn1.Unmarshal(b, n)
+	err := genericblinding.UnmarshalEnvelope(b, n)
 	if err != nil {
 		return nil, err
 	}
@@ -409,13 +411,13 @@ func (clearSignature ClearSignature) SchemeData() (string, genericblinding.DataT
 
 // Marshal a BlindingParamClient
 func (clearSignature ClearSignature) Marshal() ([]byte, error) {
-	return asn1.Marshal(clearSignature)
+	return genericblinding.MarshalEnvelope(clearSignature)
 }
 
 // Unmarshal []byte into BlindingParamClient
 func (clearSignature ClearSignature) Unmarshal(b []byte) (genericblinding.BlindingData, error) {
 	n := new(ClearSignature)
-	_, err := asn1.Unmarshal(b, n)
+	err := genericblinding.UnmarshalEnvelope(b, n)
 	if err != nil {
 		return nil, err
 	}
@@ -10,6 +10,12 @@ func (client SignerClient) Verify(message []byte, signature *SignatureInt) (bool
 	if Hm.Cmp(signature.Hm) != 0 {
 		return false, eccutil.ErrHashDif
 	}
+	if !client.curve.IsOnCurve(signature.R) {
+		return false, eccutil.ErrSigWrong
+	}
+	if !client.curve.IsScalar(signature.S) || !client.curve.IsScalar(signature.r2) {
+		return false, eccutil.ErrSigWrong
+	}
 	SG := client.curve.ScalarBaseMult(signature.S.Bytes())
 	r2B := client.curve.ScalarMult(client.pubkey, signature.r2.Bytes())
 	HmR := client.curve.ScalarMult(signature.R, Hm.Bytes())